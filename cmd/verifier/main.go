@@ -8,16 +8,34 @@ import (
 
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
 	_ "github.com/nomadlabsinc/h2-client-test-harness/verifier/cases/generic"
+	_ "github.com/nomadlabsinc/h2-client-test-harness/verifier/cases/grpc"
 	_ "github.com/nomadlabsinc/h2-client-test-harness/verifier/cases/hpack"
 	_ "github.com/nomadlabsinc/h2-client-test-harness/verifier/cases/http2"
+	_ "github.com/nomadlabsinc/h2-client-test-harness/verifier/cases/rfc7838"
+	_ "github.com/nomadlabsinc/h2-client-test-harness/verifier/cases/rfc8336"
+	_ "github.com/nomadlabsinc/h2-client-test-harness/verifier/cases/rfc8441"
+	_ "github.com/nomadlabsinc/h2-client-test-harness/verifier/cases/rfc9113"
+	_ "github.com/nomadlabsinc/h2-client-test-harness/verifier/cases/rfc9218"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+)
+
+const (
+	// exitNoMutant is the exit status of --mutate for a test case without a
+	// registered mutant.
+	exitNoMutant = 2
+	// exitSkipped is the exit status for a test case the harness skipped,
+	// as the client's settings left it nothing to check.
+	exitSkipped = 4
 )
 
 func main() {
 	testCaseID := flag.String("test", "", "The ID of the test case to run (e.g., '6.5/1')")
+	faults := flag.String("faults", "", "Run against the mutant client with these comma-separated faults ('none' for a compliant mutant)")
+	mutate := flag.Bool("mutate", false, "Run against the mutant registered for the test case and pass only if the test catches it")
 	flag.Parse()
 
 	if *testCaseID == "" {
-		fmt.Println("Usage: go run ./cmd/verifier --test=<test_case_id>")
+		fmt.Println("Usage: go run ./cmd/verifier --test=<test_case_id> [--faults=<fault,...>|--mutate]")
 		verifier.PrintAllTests()
 		os.Exit(1)
 	}
@@ -27,10 +45,46 @@ func main() {
 		log.Fatalf("Test case '%s' not found.", *testCaseID)
 	}
 
+	if *mutate {
+		set, ok := verifier.GetMutant(*testCaseID)
+		if !ok {
+			log.Printf("No mutant registered for test case: %s", *testCaseID)
+			os.Exit(exitNoMutant)
+		}
+		verifier.UseTransport(newMutant(set))
+		log.Printf("Running verifier for test case %s against mutant client (%s)", *testCaseID, set)
+		if err := testFunc(); err != nil {
+			log.Printf("Mutant killed for test case %s: %v", *testCaseID, err)
+			return
+		}
+		log.Fatalf("Mutant survived: test case %s passed against a client with faults %s", *testCaseID, set)
+	}
+
+	if *faults != "" {
+		set, err := mutant.ParseFaults(*faults)
+		if err != nil {
+			log.Fatalf("Invalid --faults: %v", err)
+		}
+		verifier.UseTransport(newMutant(set))
+		log.Printf("Using mutant client with faults: %s", set)
+	}
+
 	log.Printf("Running verifier for test case: %s", *testCaseID)
 	if err := testFunc(); err != nil {
 		log.Fatalf("Verifier failed for test case %s: %v", *testCaseID, err)
 	}
+	if reason, ok := verifier.Skipped(); ok {
+		log.Printf("Verifier skipped test case %s: %s", *testCaseID, reason)
+		os.Exit(exitSkipped)
+	}
 
 	log.Printf("Verifier passed for test case: %s", *testCaseID)
-}
\ No newline at end of file
+}
+
+// newMutant returns a mutant client with faults set that resolves names
+// like the verifier's clients.
+func newMutant(set mutant.FaultSet) *mutant.Transport {
+	t := mutant.NewTransport(set)
+	t.Resolve = verifier.Resolve
+	return t
+}
@@ -1,88 +1,114 @@
 package cases
 
 import (
+	"encoding/binary"
 	"log"
 	"net"
 
 	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
-// Test Case 4.1/1: Sends a frame with unknown type.
-// The client should ignore and discard frames with unknown types.
+// Test Case 4.1/1: Sends a frame with unknown type between the frames of the response.
+// The client should ignore and discard the unknown frame and deliver the response body.
 func RunTest4_1_1(conn net.Conn, framer *http2.Framer) {
 	log.Println("Running test case 4.1/1...")
 
-	// Send a frame with unknown type (255)
-	// RFC 7540 Section 4.1: Implementations MUST ignore and discard unknown frame types
-	unknownFrame := []byte{
-		0x00, 0x00, 0x08, // Length: 8 bytes
-		0xFF,             // Type: Unknown (255)
-		0x00,             // Flags: none
-		0x00, 0x00, 0x00, 0x00, // Stream ID: 0
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 8 bytes of payload
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
 	}
 
-	if _, err := conn.Write(unknownFrame); err != nil {
-		log.Printf("Failed to write unknown frame type: %v", err)
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, false, []byte("unknown ")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
 		return
 	}
 
-	// Send a PING frame to verify connection is still active
-	pingFrame := []byte{
-		0x00, 0x00, 0x08, // Length: 8 bytes
-		0x06,             // Type: PING (6)
-		0x00,             // Flags: none
-		0x00, 0x00, 0x00, 0x00, // Stream ID: 0
-		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, // PING payload
+	// RFC 7540 Section 4.1: Implementations MUST ignore and discard any frame
+	// that has a type that is unknown.
+	if err := framer.WriteRawFrame(0xfa, 0x00, streamID, []byte("discard me")); err != nil {
+		log.Printf("Failed to write unknown frame: %v", err)
+		return
 	}
 
-	if _, err := conn.Write(pingFrame); err != nil {
-		log.Printf("Failed to write PING frame: %v", err)
+	if err := framer.WriteData(streamID, true, []byte("frame discarded")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
 		return
 	}
-	log.Println("Sent unknown frame type followed by PING - client should ignore unknown frame and respond to PING")
+	log.Println("Sent response with an unknown frame type in the middle - client should deliver the body")
+	linger(conn, framer)
 }
 
-// Test Case 4.1/2: Sends a frame with undefined flag.
-// The client should ignore undefined flags.
+// Test Case 4.1/2: Sends the response in HEADERS and DATA frames with undefined flags.
+// The client should ignore the undefined flags and deliver the response body.
 func RunTest4_1_2(conn net.Conn, framer *http2.Framer) {
 	log.Println("Running test case 4.1/2...")
 
-	// Send PING frame with all flags set (including undefined ones)
-	// RFC 7540 Section 4.1: Flags that have no defined semantics are ignored
-	pingFrameWithFlags := []byte{
-		0x00, 0x00, 0x08, // Length: 8 bytes
-		0x06,             // Type: PING (6)
-		0xFF,             // Flags: all flags set (255) - most are undefined for PING
-		0x00, 0x00, 0x00, 0x00, // Stream ID: 0
-		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, // PING payload
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
 	}
 
-	if _, err := conn.Write(pingFrameWithFlags); err != nil {
-		log.Printf("Failed to write PING frame with undefined flags: %v", err)
+	// RFC 7540 Section 4.1: Flags that have no defined semantics MUST be ignored.
+	// 0x02 and 0x10 are undefined for HEADERS, 0x02 and 0x04 for DATA.
+	block := encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"})
+	if err := framer.WriteRawFrame(http2.FrameHeaders, http2.FlagHeadersEndHeaders|0x02|0x10, streamID, block); err != nil {
+		log.Printf("Failed to write HEADERS frame with undefined flags: %v", err)
+		return
+	}
+	if err := framer.WriteRawFrame(http2.FrameData, http2.FlagDataEndStream|0x02|0x04, streamID, []byte("undefined flags ignored")); err != nil {
+		log.Printf("Failed to write DATA frame with undefined flags: %v", err)
 		return
 	}
-	log.Println("Sent PING frame with undefined flags - client should ignore undefined flags and process frame")
+	log.Println("Sent response frames with undefined flags - client should deliver the body")
+	linger(conn, framer)
 }
 
-// Test Case 4.1/3: Sends a frame with reserved field bit.
-// The client should ignore the reserved field bit value.
+// Test Case 4.1/3: Sends the response DATA frame with the reserved stream identifier bit set.
+// The client should ignore the reserved bit and deliver the response body.
 func RunTest4_1_3(conn net.Conn, framer *http2.Framer) {
 	log.Println("Running test case 4.1/3...")
 
-	// Send PING frame with reserved bit set in stream ID
-	// RFC 7540 Section 4.1: Reserved bit MUST remain unset when sending and MUST be ignored when receiving
-	pingFrameWithReserved := []byte{
-		0x00, 0x00, 0x08, // Length: 8 bytes
-		0x06,             // Type: PING (6)
-		0x00,             // Flags: none
-		0x80, 0x00, 0x00, 0x00, // Stream ID: 0 with reserved bit set (0x80000000)
-		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, // PING payload
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
 	}
 
-	if _, err := conn.Write(pingFrameWithReserved); err != nil {
-		log.Printf("Failed to write PING frame with reserved bit: %v", err)
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
 		return
 	}
-	log.Println("Sent PING frame with reserved bit set - client should ignore reserved bit and process frame")
-}
\ No newline at end of file
+
+	// RFC 7540 Section 4.1: The reserved bit MUST be ignored when receiving.
+	payload := []byte("reserved bit ignored")
+	dataFrame := []byte{
+		0x00, 0x00, byte(len(payload)), // Length
+		0x00,                   // Type: DATA (0x0)
+		0x01,                   // Flags: END_STREAM
+		0x00, 0x00, 0x00, 0x00, // Stream ID: set below
+	}
+	binary.BigEndian.PutUint32(dataFrame[5:], streamID|0x80000000) // Reserved bit set
+	dataFrame = append(dataFrame, payload...)
+
+	if _, err := conn.Write(dataFrame); err != nil {
+		log.Printf("Failed to write DATA frame with reserved bit: %v", err)
+		return
+	}
+	log.Println("Sent DATA frame with reserved bit set - client should deliver the body")
+	linger(conn, framer)
+}
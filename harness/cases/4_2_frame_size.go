@@ -1,105 +1,172 @@
 package cases
 
 import (
+	"bytes"
+	"fmt"
 	"log"
 	"net"
 
 	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
+// maxFrameLength is the largest length the 24-bit frame length field can
+// carry (RFC 7540 Section 4.1).
+const maxFrameLength = 1<<24 - 1
+
 // Test Case 4.2/1: Sends a DATA frame with 2^14 octets in length.
 // The client should be capable of receiving and processing frames up to 2^14 octets.
 func RunTest4_2_1(conn net.Conn, framer *http2.Framer) {
 	log.Println("Running test case 4.2/1...")
 
-	// Create a DATA frame with maximum default frame size (16384 = 2^14 bytes)
-	frameSize := 16384
-	frame := make([]byte, 9+frameSize)
-	
-	// Frame header
-	frame[0] = byte(frameSize >> 16)     // Length (high byte)
-	frame[1] = byte(frameSize >> 8)      // Length (middle byte)
-	frame[2] = byte(frameSize)           // Length (low byte)
-	frame[3] = 0x00                      // Type: DATA
-	frame[4] = 0x01                      // Flags: END_STREAM
-	frame[5] = 0x00                      // Stream ID (4 bytes)
-	frame[6] = 0x00
-	frame[7] = 0x00
-	frame[8] = 0x01
-
-	// Fill payload with data
-	for i := 9; i < len(frame); i++ {
-		frame[i] = byte(i % 256)
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
 	}
 
-	if _, err := conn.Write(frame); err != nil {
+	if window := SettingsOf(conn).InitialWindowSize(); window < 16384 {
+		log.Printf("Client's SETTINGS_INITIAL_WINDOW_SIZE of %d is smaller than the frame; it must be allowed to refuse it.", window)
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, true, bytes.Repeat([]byte("x"), 16384)); err != nil {
 		log.Printf("Failed to write maximum size DATA frame: %v", err)
 		return
 	}
 	log.Println("Sent DATA frame with 2^14 octets - client should process successfully")
+	linger(conn, framer)
 }
 
-// Test Case 4.2/2: Sends a large size DATA frame that exceeds the SETTINGS_MAX_FRAME_SIZE.
-// The client should detect a FRAME_SIZE_ERROR.
+// Test Case 4.2/2: Sends a DATA frame one octet larger than the client's SETTINGS_MAX_FRAME_SIZE.
+// The client should detect a FRAME_SIZE_ERROR. The harness skips the case for a client that admits frames of 2^24-1 octets.
 func RunTest4_2_2(conn net.Conn, framer *http2.Framer) {
 	log.Println("Running test case 4.2/2...")
 
-	// Send a DATA frame larger than default max frame size (16384 bytes)
-	frameSize := 16385 // One byte over the limit
-	frame := make([]byte, 9+frameSize)
-	
-	// Frame header
-	frame[0] = byte(frameSize >> 16)     // Length (high byte)
-	frame[1] = byte(frameSize >> 8)      // Length (middle byte)
-	frame[2] = byte(frameSize)           // Length (low byte)
-	frame[3] = 0x00                      // Type: DATA
-	frame[4] = 0x01                      // Flags: END_STREAM
-	frame[5] = 0x00                      // Stream ID (4 bytes)
-	frame[6] = 0x00
-	frame[7] = 0x00
-	frame[8] = 0x01
-
-	// Fill payload with data
-	for i := 9; i < len(frame); i++ {
-		frame[i] = byte(i % 256)
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
 	}
 
-	if _, err := conn.Write(frame); err != nil {
+	maxFrameSize := SettingsOf(conn).MaxFrameSize()
+	if maxFrameSize >= maxFrameLength {
+		skipRequest(conn, framer, streamID, fmt.Sprintf("the client's SETTINGS_MAX_FRAME_SIZE of %d admits every frame length", maxFrameSize))
+		return
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, true, make([]byte, maxFrameSize+1)); err != nil {
 		log.Printf("Failed to write oversized DATA frame: %v", err)
 		return
 	}
-	log.Println("Sent oversized DATA frame - client should detect FRAME_SIZE_ERROR")
+	log.Printf("Sent DATA frame of %d octets - client should detect FRAME_SIZE_ERROR", maxFrameSize+1)
+	linger(conn, framer)
 }
 
-// Test Case 4.2/3: Sends a large size HEADERS frame that exceeds the SETTINGS_MAX_FRAME_SIZE.
-// The client should detect a FRAME_SIZE_ERROR.
+// Test Case 4.2/3: Sends a HEADERS frame one octet larger than the client's SETTINGS_MAX_FRAME_SIZE.
+// The client should detect a FRAME_SIZE_ERROR. The harness skips the case for a client that admits frames of 2^24-1 octets.
 func RunTest4_2_3(conn net.Conn, framer *http2.Framer) {
 	log.Println("Running test case 4.2/3...")
 
-	// Send a HEADERS frame larger than default max frame size (16384 bytes)
-	frameSize := 16385 // One byte over the limit
-	frame := make([]byte, 9+frameSize)
-	
-	// Frame header
-	frame[0] = byte(frameSize >> 16)     // Length (high byte)
-	frame[1] = byte(frameSize >> 8)      // Length (middle byte)
-	frame[2] = byte(frameSize)           // Length (low byte)
-	frame[3] = 0x01                      // Type: HEADERS
-	frame[4] = 0x05                      // Flags: END_STREAM | END_HEADERS
-	frame[5] = 0x00                      // Stream ID (4 bytes)
-	frame[6] = 0x00
-	frame[7] = 0x00
-	frame[8] = 0x01
-
-	// Fill payload with header data (starting with valid indexed header)
-	frame[9] = 0x82 // :method: GET
-	for i := 10; i < len(frame); i++ {
-		frame[i] = byte(i % 256)
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
 	}
 
-	if _, err := conn.Write(frame); err != nil {
+	maxFrameSize := SettingsOf(conn).MaxFrameSize()
+	if maxFrameSize >= maxFrameLength {
+		skipRequest(conn, framer, streamID, fmt.Sprintf("the client's SETTINGS_MAX_FRAME_SIZE of %d admits every frame length", maxFrameSize))
+		return
+	}
+
+	// A valid block: :status 200 and one literal field, not Huffman
+	// encoded, whose value fills the frame to exactly maxFrameSize+1 octets.
+	name := "x-oversized"
+	block := []byte{0x88, 0x00, byte(len(name))}
+	block = append(block, name...)
+	valueLen := uint64(maxFrameSize) + 1 - uint64(len(block))
+	for len(appendHPACKInt(block, 7, 0x00, valueLen))+int(valueLen) > int(maxFrameSize)+1 {
+		valueLen--
+	}
+	block = appendHPACKInt(block, 7, 0x00, valueLen)
+	block = append(block, bytes.Repeat([]byte("h"), int(valueLen))...)
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: block,
+		EndStream:     true,
+		EndHeaders:    true,
+	}); err != nil {
 		log.Printf("Failed to write oversized HEADERS frame: %v", err)
 		return
 	}
-	log.Println("Sent oversized HEADERS frame - client should detect FRAME_SIZE_ERROR")
-}
\ No newline at end of file
+	log.Printf("Sent HEADERS frame of %d octets - client should detect FRAME_SIZE_ERROR", len(block))
+	linger(conn, framer)
+}
+
+// upload4_2_4 opens the windows wide, so that only the minimum
+// SETTINGS_MAX_FRAME_SIZE limits the client's DATA frames. The minimum is
+// also the initial value; the preface announces it explicitly all the same,
+// so that the test case grades a client against a SETTINGS_MAX_FRAME_SIZE
+// the server did send.
+var upload4_2_4 = uploadPlan{
+	preface: []http2.Setting{
+		{ID: http2.SettingMaxFrameSize, Val: 16384},
+		{ID: http2.SettingInitialWindowSize, Val: 1 << 20},
+	},
+	minGrant: 1 << 20,
+	maxGrant: 1 << 20,
+}
+
+// Preface4_2_4 announces the minimum SETTINGS_MAX_FRAME_SIZE and a 1 MiB
+// initial window in the server preface.
+func Preface4_2_4(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, upload4_2_4.preface...)
+}
+
+// Test Case 4.2/4: Explicitly announces the minimum SETTINGS_MAX_FRAME_SIZE of 2^14, also its initial value, with a 1 MiB initial window and receives a 1 MiB request body.
+// The client must split the body into DATA frames of at most 2^14 octets; the harness checks every frame and the body's digest.
+func RunTest4_2_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 4.2/4...")
+	receiveUpload(conn, framer, upload4_2_4)
+}
+
+// upload4_2_5 starts with a SETTINGS_MAX_FRAME_SIZE of 2^16 and lowers it
+// to the minimum a quarter into the upload.
+var upload4_2_5 = uploadPlan{
+	preface: []http2.Setting{
+		{ID: http2.SettingMaxFrameSize, Val: 1 << 16},
+		{ID: http2.SettingInitialWindowSize, Val: 1 << 20},
+	},
+	minGrant:    1 << 20,
+	maxGrant:    1 << 20,
+	changeAfter: 256 << 10,
+	change:      []http2.Setting{{ID: http2.SettingMaxFrameSize, Val: 16384}},
+}
+
+// Preface4_2_5 announces a SETTINGS_MAX_FRAME_SIZE of 2^16 and a 1 MiB
+// initial window in the server preface.
+func Preface4_2_5(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, upload4_2_5.preface...)
+}
+
+// Test Case 4.2/5: Announces a SETTINGS_MAX_FRAME_SIZE of 2^16, receives a 1 MiB request body and lowers the limit to 2^14 after 256 KiB.
+// Once it acknowledges the change the client must send DATA frames of at most 2^14 octets; the harness checks every frame and the body's digest.
+func RunTest4_2_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 4.2/5...")
+	receiveUpload(conn, framer, upload4_2_5)
+}
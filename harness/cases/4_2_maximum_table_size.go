@@ -33,3 +33,60 @@ func RunTestHpack4_2_1(conn net.Conn, framer *http2.Framer) {
 	}
 	log.Println("Sent HEADERS frame with dynamic table size update at the end. Test complete.")
 }
+
+// Test Case hpack/4.2/2: Starts the response header block with two dynamic table size updates, shrinking the table to 0 and growing it back to the client's SETTINGS_HEADER_TABLE_SIZE.
+// The client should accept both updates and decode the rest of the block.
+func RunTestHpack4_2_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case hpack/4.2/2...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	// RFC 7541 Section 4.2: Multiple updates to the maximum table size can
+	// occur at the beginning of a header block.
+	block := tableSizeUpdate(0)
+	block = append(block, tableSizeUpdate(SettingsOf(conn).HeaderTableSize())...)
+	block = append(block, encodeHeaders(
+		hpack.HeaderField{Name: ":status", Value: "200"},
+		hpack.HeaderField{Name: "x-table-size", Value: "resized"},
+	)...)
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: block,
+		EndStream:     false,
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, true, []byte("ok")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	log.Println("Sent header block starting with two table size updates - client should decode it")
+	linger(conn, framer)
+}
+
+// PrefaceHpack4_2_3 announces a SETTINGS_HEADER_TABLE_SIZE of 0 in the
+// server preface.
+func PrefaceHpack4_2_3(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, http2.Setting{ID: http2.SettingHeaderTableSize, Val: 0})
+}
+
+// Test Case hpack/4.2/3: Announces a SETTINGS_HEADER_TABLE_SIZE of 0 in the server preface and serves three requests.
+// The request validator reports a client whose first header block after the ACK does not signal a table size of 0.
+func RunTestHpack4_2_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case hpack/4.2/3...")
+	serveRequests(conn, framer, 3, "ok")
+}
+
+// Test Case hpack/4.2/4: Shrinks SETTINGS_HEADER_TABLE_SIZE to 0 after the first request and regrows it to 4096 after the second.
+// The request validator reports size updates that are missing, misplaced or above the acknowledged size.
+func RunTestHpack4_2_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case hpack/4.2/4...")
+	serveTableResizes(conn, framer, 0, 4096)
+}
@@ -63,4 +63,11 @@ func RunTest5_1_1_2(conn net.Conn, framer *http2.Framer) {
 		return
 	}
 	log.Println("Sent HEADERS frame with decreasing stream ID - client should detect PROTOCOL_ERROR")
-}
\ No newline at end of file
+}
+
+// Test Case 5.1.1/3: Serves three sequential requests on one connection.
+// The request validator reports a stream identifier that is reused or does not increase.
+func RunTest5_1_1_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 5.1.1/3...")
+	serveRequests(conn, framer, 3, "ok")
+}
@@ -55,4 +55,28 @@ func RunTest5_1_2_1(conn net.Conn, framer *http2.Framer) {
 		return
 	}
 	log.Println("Sent HEADERS frames exceeding concurrent stream limit - client should detect PROTOCOL_ERROR or REFUSED_STREAM")
-}
\ No newline at end of file
+}
+
+// Preface5_1_2_2 limits the client to a single concurrent stream in the server preface.
+func Preface5_1_2_2(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, http2.Setting{ID: http2.SettingMaxConcurrentStreams, Val: 1})
+}
+
+// Test Case 5.1.2/2: Advertises a SETTINGS_MAX_CONCURRENT_STREAMS of 1 and serves a first request and then a workload of 6 parallel requests.
+// The client must queue its requests or open further connections; a stream beyond the limit is refused and reported.
+func RunTest5_1_2_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 5.1.2/2...")
+	serveParallelRequests(conn, framer, concurrencyPlan{limit: 1, requests: 7})
+}
+
+// Preface5_1_2_3 limits the client to four concurrent streams in the server preface.
+func Preface5_1_2_3(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, http2.Setting{ID: http2.SettingMaxConcurrentStreams, Val: 4})
+}
+
+// Test Case 5.1.2/3: Advertises a SETTINGS_MAX_CONCURRENT_STREAMS of 4, lowers it to 1 while streams are open and raises it to 3 again, under a workload of 12 parallel requests that follows a first request.
+// Streams already open may complete, but the client must not open new ones beyond the lowered limit.
+func RunTest5_1_2_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 5.1.2/3...")
+	serveParallelRequests(conn, framer, concurrencyPlan{limit: 4, requests: 13, changes: []uint32{1, 3}})
+}
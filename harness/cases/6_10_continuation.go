@@ -184,3 +184,60 @@ func RunTest6_10_6(conn net.Conn, framer *http2.Framer) {
 	}
 	log.Println("Sent unexpected CONTINUATION frame. Test complete.")
 }
+
+// Test Case 6.10/7: Splits the response header block across a HEADERS frame and two CONTINUATION frames.
+// The client should reassemble the block and expose every header field.
+func RunTest6_10_7(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.10/7...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	block := encodeHeaders(
+		hpack.HeaderField{Name: ":status", Value: "200"},
+		hpack.HeaderField{Name: "x-continued", Value: "reassembled-from-three-frames"},
+	)
+	third := len(block) / 3
+
+	// RFC 7540 Section 6.10: A header block fragment may be continued by any
+	// number of CONTINUATION frames, the last of which sets END_HEADERS.
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: block[:third],
+		EndStream:     false,
+		EndHeaders:    false,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteContinuation(streamID, false, block[third:2*third]); err != nil {
+		log.Printf("Failed to write first CONTINUATION frame: %v", err)
+		return
+	}
+	if err := framer.WriteContinuation(streamID, true, block[2*third:]); err != nil {
+		log.Printf("Failed to write second CONTINUATION frame: %v", err)
+		return
+	}
+	log.Println("Sent header block split across HEADERS and two CONTINUATION frames.")
+
+	if err := framer.WriteData(streamID, true, []byte("ok")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	linger(conn, framer)
+}
+
+// Preface6_10_8 announces the minimum SETTINGS_MAX_FRAME_SIZE in the server preface.
+func Preface6_10_8(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, http2.Setting{ID: http2.SettingMaxFrameSize, Val: 16384})
+}
+
+// Test Case 6.10/8: Announces a SETTINGS_MAX_FRAME_SIZE of 2^14 and answers a request carrying a 40000-octet x-large header field with the length of the value it decoded.
+// The client must split the header block into HEADERS and CONTINUATION frames of at most 2^14 octets; the request validator reports larger frames.
+func RunTest6_10_8(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.10/8...")
+	serveHeaderLength(conn, framer, "x-large")
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 
 	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
 // Test Case 6.1/1: Sends a DATA frame with 0x0 stream identifier.
@@ -72,4 +73,72 @@ func RunTest6_1_3(conn net.Conn, framer *http2.Framer) {
 		return
 	}
 	log.Println("Sent DATA frame with invalid padding - client should detect PROTOCOL_ERROR")
-}
\ No newline at end of file
+}
+
+// Test Case 6.1/4: Sends the response body in padded DATA frames.
+// The client should strip the padding and deliver only the data.
+func RunTest6_1_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.1/4...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 6.1: Padding octets are not part of the frame's data.
+	if err := framer.WriteDataPadded(streamID, false, []byte("padding "), make([]byte, 32)); err != nil {
+		log.Printf("Failed to write padded DATA frame: %v", err)
+		return
+	}
+	if err := framer.WriteDataPadded(streamID, true, []byte("stripped"), make([]byte, 255)); err != nil {
+		log.Printf("Failed to write padded DATA frame: %v", err)
+		return
+	}
+	log.Println("Sent response body in padded DATA frames - client should deliver it without padding")
+	linger(conn, framer)
+}
+
+// Test Case 6.1/5: Sends the response body with zero-length DATA frames before the last one.
+// The client should accept the empty frames and deliver the complete body.
+func RunTest6_1_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.1/5...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 6.1: DATA frames may carry no data at all.
+	for i := 0; i < 3; i++ {
+		if err := framer.WriteData(streamID, false, nil); err != nil {
+			log.Printf("Failed to write empty DATA frame: %v", err)
+			return
+		}
+	}
+	if err := framer.WriteData(streamID, true, []byte("after empty frames")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	log.Println("Sent empty DATA frames followed by the body - client should deliver the body")
+	linger(conn, framer)
+}
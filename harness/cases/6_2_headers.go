@@ -5,6 +5,7 @@ import (
 	"net"
 
 	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
 // Test Case 6.2/1: Sends a HEADERS frame without the END_HEADERS flag, and a PRIORITY frame.
@@ -118,4 +119,42 @@ func RunTest6_2_4(conn net.Conn, framer *http2.Framer) {
 		return
 	}
 	log.Println("Sent HEADERS frame with invalid padding - client should detect PROTOCOL_ERROR")
-}
\ No newline at end of file
+}
+
+// Test Case 6.2/5: Sends the response HEADERS with the PRIORITY and PADDED flags set.
+// The client should skip the priority fields and padding and decode the header block.
+func RunTest6_2_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.2/5...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 6.2: Pad Length, E, Stream Dependency and Weight precede the header block.
+	block := encodeHeaders(
+		hpack.HeaderField{Name: ":status", Value: "200"},
+		hpack.HeaderField{Name: "x-header-flags", Value: "priority-and-padding"},
+	)
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: block,
+		EndHeaders:    true,
+		PadLength:     16,
+		Priority: http2.PriorityParam{
+			StreamDep: 0,
+			Exclusive: true,
+			Weight:    200,
+		},
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame with PRIORITY and PADDED flags: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, true, []byte("ok")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	log.Println("Sent padded, prioritised response HEADERS - client should decode the header block")
+	linger(conn, framer)
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 
 	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
 // Test Case 6.4/1: Sends a RST_STREAM frame with 0x0 stream identifier.
@@ -71,4 +72,63 @@ func RunTest6_4_3(conn net.Conn, framer *http2.Framer) {
 		return
 	}
 	log.Println("Sent RST_STREAM frame with incorrect length - client should detect FRAME_SIZE_ERROR")
-}
\ No newline at end of file
+}
+
+// Test Case 6.4/4: Resets the stream with INTERNAL_ERROR after part of the response body.
+// The client is expected to fail the response body with a stream error carrying INTERNAL_ERROR.
+func RunTest6_4_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.4/4...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, false, []byte("truncated")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 6.4: RST_STREAM causes immediate termination of the stream.
+	if err := framer.WriteRSTStream(streamID, http2.ErrCodeInternal); err != nil {
+		log.Printf("Failed to write RST_STREAM frame: %v", err)
+		return
+	}
+	log.Println("Sent RST_STREAM with INTERNAL_ERROR mid-body - client should fail the body with INTERNAL_ERROR")
+	linger(conn, framer)
+}
+
+// Test Case 6.4/5: Sends RST_STREAM with NO_ERROR right after a complete response.
+// The client should keep the complete response it already received.
+func RunTest6_4_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.4/5...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	if err := writeResponse(framer, streamID, "complete before reset"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 8.1: A server can send RST_STREAM with NO_ERROR after a
+	// complete response; clients MUST NOT discard responses as a result.
+	if err := framer.WriteRSTStream(streamID, http2.ErrCodeNo); err != nil {
+		log.Printf("Failed to write RST_STREAM frame: %v", err)
+		return
+	}
+	log.Println("Sent RST_STREAM with NO_ERROR after the response - client should keep the response")
+	linger(conn, framer)
+}
@@ -1,6 +1,7 @@
 package cases
 
 import (
+	"fmt"
 	"log"
 	"net"
 
@@ -106,3 +107,71 @@ func RunTest6_5_2_5(conn net.Conn, framer *http2.Framer) {
 		}
 	}
 }
+
+// Test Case 6.5.2/6: Sends a response header list exactly as large as the client's SETTINGS_MAX_HEADER_LIST_SIZE.
+// The client is expected to accept the response.
+func RunTest6_5_2_6(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.5.2/6...")
+	serveHeaderList(conn, framer, 0)
+}
+
+// Test Case 6.5.2/7: Sends a response header list one octet larger than the client's SETTINGS_MAX_HEADER_LIST_SIZE.
+// The client is expected to refuse the response.
+func RunTest6_5_2_7(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.5.2/7...")
+	serveHeaderList(conn, framer, 1)
+}
+
+// serveHeaderList answers the client's request with a header list of the
+// client's SETTINGS_MAX_HEADER_LIST_SIZE plus excess octets, split into as
+// many CONTINUATION frames as the client's SETTINGS_MAX_FRAME_SIZE requires.
+// It skips the test case if the client announced no limit, or one too small
+// to build the list from.
+func serveHeaderList(conn net.Conn, framer *http2.Framer, excess uint32) {
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	settings := SettingsOf(conn)
+	limit, ok := settings.MaxHeaderListSize()
+	if !ok {
+		skipRequest(conn, framer, streamID, "the client announced no SETTINGS_MAX_HEADER_LIST_SIZE, so its header list size is unlimited")
+		return
+	}
+
+	// Repeat one entry that fits the client's dynamic table, so that the
+	// block stays small however large the decoded list is.
+	entrySize := settings.HeaderTableSize()
+	if entrySize > 4000 {
+		entrySize = 4000
+	}
+	if entrySize < 64 {
+		entrySize = 64
+	}
+	size := limit + excess
+	if size < 2*entrySize {
+		skipRequest(conn, framer, streamID, fmt.Sprintf("the client's SETTINGS_MAX_HEADER_LIST_SIZE of %d is smaller than two fields of %d octets, from which the header list is built", limit, entrySize))
+		return
+	}
+	block := encodeHeaderList(settings, headerListOfSize(size, entrySize))
+	if err := writeHeaderBlock(framer, streamID, block, true, settings.MaxFrameSize()); err != nil {
+		log.Printf("Failed to write header block: %v", err)
+		return
+	}
+	log.Printf("Sent a header list of %d octets against a limit of %d in a block of %d octets.", size, limit, len(block))
+	linger(conn, framer)
+}
+
+// Preface6_5_2_8 announces a SETTINGS_MAX_HEADER_LIST_SIZE of 4096 in the server preface.
+func Preface6_5_2_8(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, http2.Setting{ID: http2.SettingMaxHeaderListSize, Val: 4096})
+}
+
+// Test Case 6.5.2/8: Announces a SETTINGS_MAX_HEADER_LIST_SIZE of 4096 and serves the client's requests.
+// The client must fail a request whose header list exceeds the limit locally; the request validator reports one that is sent.
+func RunTest6_5_2_8(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.5.2/8...")
+	serveRequests(conn, framer, 2, "ok")
+}
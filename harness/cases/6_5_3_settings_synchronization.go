@@ -3,10 +3,27 @@ package cases
 import (
 	"log"
 	"net"
+	"time"
 
 	"golang.org/x/net/http2"
 )
 
+// settingsAckTimeout bounds how long the harness serves a test case that
+// controls the ACK of the client's SETTINGS.
+const settingsAckTimeout = 5 * time.Second
+
+// settingsAckPlan describes how the harness acknowledges the client's
+// initial SETTINGS frame while it serves the client's requests.
+type settingsAckPlan struct {
+	// withhold delays the ACK; never withholds it for the whole test case.
+	withhold time.Duration
+	never    bool
+	// acks is the number of ACKs the harness sends for the one frame.
+	acks int
+	// requests is the number of requests the client sends.
+	requests int
+}
+
 // Test Case 6.5.3/2: Sends a SETTINGS frame and expects an ACK.
 // The client is expected to immediately send a SETTINGS frame with the ACK flag.
 func RunTest6_5_3_2(conn net.Conn, framer *http2.Framer) {
@@ -41,3 +58,175 @@ func RunTest6_5_3_2(conn net.Conn, framer *http2.Framer) {
 		}
 	}
 }
+
+// Test Case 6.5.3/3: Sends two SETTINGS frames back to back and answers the request only after both are acknowledged.
+// The client is expected to send one SETTINGS ACK per SETTINGS frame.
+func RunTest6_5_3_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.5.3/3...")
+
+	// RFC 7540 Section 6.5.3: Upon receiving a SETTINGS frame without the ACK
+	// flag, the recipient MUST immediately emit a SETTINGS frame with the ACK flag.
+	if err := framer.WriteSettings(http2.Setting{ID: http2.SettingMaxConcurrentStreams, Val: 10}); err != nil {
+		log.Printf("Failed to write first SETTINGS frame: %v", err)
+		return
+	}
+	if err := framer.WriteSettings(http2.Setting{ID: http2.SettingMaxConcurrentStreams, Val: 20}); err != nil {
+		log.Printf("Failed to write second SETTINGS frame: %v", err)
+		return
+	}
+	log.Println("Sent two SETTINGS frames, awaiting an ACK for each.")
+
+	// One ACK for the initial server SETTINGS and one for each frame above.
+	streamID, err := awaitRequestAndAcks(framer, 3)
+	if err != nil {
+		log.Printf("Failed to read request and SETTINGS ACKs: %v", err)
+		return
+	}
+
+	if err := writeResponse(framer, streamID, "both settings acknowledged"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Println("Sent response after both SETTINGS ACKs.")
+	linger(conn, framer)
+}
+
+// Preface6_5_3_4 sends the server SETTINGS but leaves the client's SETTINGS
+// for the test case to acknowledge.
+func Preface6_5_3_4(conn net.Conn, framer *http2.Framer) {
+	if err := framer.WriteSettings(); err != nil {
+		log.Printf("Failed to write initial server SETTINGS frame: %v", err)
+		return
+	}
+	log.Println("Initial server SETTINGS frame sent; the client's SETTINGS are not acknowledged yet.")
+}
+
+// Test Case 6.5.3/4: Never acknowledges the client's SETTINGS while it serves four requests over three seconds.
+// The client may keep using the connection or close it with a SETTINGS_TIMEOUT connection error.
+func RunTest6_5_3_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.5.3/4...")
+	serveSettingsAcks(conn, framer, settingsAckPlan{never: true, requests: 4})
+}
+
+// Test Case 6.5.3/5: Acknowledges the client's SETTINGS only after 1.5 seconds, while it serves four requests.
+// The client is expected to wait for the late ACK without closing the connection.
+func RunTest6_5_3_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.5.3/5...")
+	serveSettingsAcks(conn, framer, settingsAckPlan{withhold: 1500 * time.Millisecond, acks: 1, requests: 4})
+}
+
+// Test Case 6.5.3/6: Acknowledges the client's only SETTINGS frame twice.
+// The client may ignore the second ACK or close the connection with a PROTOCOL_ERROR connection error.
+func RunTest6_5_3_6(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.5.3/6...")
+	serveSettingsAcks(conn, framer, settingsAckPlan{acks: 2, requests: 2})
+}
+
+// Early6_5_3_7 sends the server connection preface and a SETTINGS ACK as
+// soon as the connection is accepted, before the client's SETTINGS have
+// arrived.
+func Early6_5_3_7(conn net.Conn, framer *http2.Framer) {
+	if err := framer.WriteSettings(); err != nil {
+		log.Printf("Failed to write initial server SETTINGS frame: %v", err)
+		return
+	}
+	if err := framer.WriteSettingsAck(); err != nil {
+		log.Printf("Failed to write SETTINGS ACK frame: %v", err)
+		return
+	}
+	log.Println("Sent the server SETTINGS and an unsolicited SETTINGS ACK before reading the client preface.")
+}
+
+// Preface6_5_3_7 sends nothing: Early6_5_3_7 has sent the server preface.
+func Preface6_5_3_7(conn net.Conn, framer *http2.Framer) {
+	log.Println("Server SETTINGS already sent.")
+}
+
+// Test Case 6.5.3/7: Sends a SETTINGS ACK before any client SETTINGS has arrived, and then acknowledges the client's SETTINGS.
+// The client may ignore the surplus ACK or close the connection with a PROTOCOL_ERROR connection error.
+func RunTest6_5_3_7(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.5.3/7...")
+	serveSettingsAcks(conn, framer, settingsAckPlan{acks: 1, requests: 2})
+}
+
+// serveSettingsAcks answers the client's requests with "ok" while it
+// acknowledges the client's initial SETTINGS as plan says, and reports how
+// the client took it. RFC 7540 Section 6.5.3 lets a client whose SETTINGS
+// go unacknowledged close the connection with SETTINGS_TIMEOUT; the harness
+// reports a SETTINGS_TIMEOUT that follows its ACK.
+func serveSettingsAcks(conn net.Conn, framer *http2.Framer, plan settingsAckPlan) {
+	start := time.Now()
+	deadline := start.Add(settingsAckTimeout)
+	var acked time.Time
+	served := 0
+	for {
+		if acked.IsZero() && !plan.never && time.Since(start) >= plan.withhold {
+			for i := 0; i < plan.acks; i++ {
+				if err := framer.WriteSettingsAck(); err != nil {
+					log.Printf("Failed to write SETTINGS ACK frame: %v", err)
+					return
+				}
+			}
+			acked = time.Now()
+			log.Printf("Sent %d SETTINGS ACK(s) for the client's SETTINGS after %v.", plan.acks, acked.Sub(start).Round(time.Millisecond))
+		}
+		if served == plan.requests && !plan.never {
+			log.Printf("The client completed %d requests.", served)
+			linger(conn, framer)
+			return
+		}
+
+		wait := deadline
+		if acked.IsZero() && !plan.never {
+			wait = start.Add(plan.withhold)
+		}
+		conn.SetReadDeadline(wait)
+		frame, err := framer.ReadFrame()
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			if time.Now().Before(deadline) {
+				continue
+			}
+			log.Printf("The client got %d of %d responses in %v.", served, plan.requests, settingsAckTimeout)
+			return
+		}
+		if err != nil {
+			if acked.IsZero() {
+				log.Printf("The client kept the connection for %v without an ACK of its SETTINGS and closed it after %d response(s): %v", time.Since(start).Round(time.Millisecond), served, err)
+			} else {
+				log.Printf("The client closed the connection after %d response(s): %v", served, err)
+			}
+			return
+		}
+
+		switch f := frame.(type) {
+		case *http2.HeadersFrame:
+			if err := writeResponse(framer, f.StreamID, "ok"); err != nil {
+				log.Printf("Failed to write response: %v", err)
+				return
+			}
+			served++
+			log.Printf("Sent response on stream %d.", f.StreamID)
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		case *http2.GoAwayFrame:
+			elapsed := time.Since(start).Round(time.Millisecond)
+			switch {
+			case f.ErrCode == http2.ErrCodeSettingsTimeout && !acked.IsZero():
+				reportViolation(conn, "client sent GOAWAY with SETTINGS_TIMEOUT %v after the harness acknowledged its SETTINGS", time.Since(acked).Round(time.Millisecond))
+			case f.ErrCode == http2.ErrCodeSettingsTimeout:
+				log.Printf("The client gave up waiting for the ACK of its SETTINGS after %v with GOAWAY SETTINGS_TIMEOUT.", elapsed)
+			default:
+				log.Printf("The client sent GOAWAY with %v after %v: %q", f.ErrCode, elapsed, f.DebugData())
+			}
+			return
+		default:
+			log.Printf("Ignoring frame of type %T.", f)
+		}
+	}
+}
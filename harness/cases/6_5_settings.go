@@ -82,3 +82,33 @@ func RunTest6_5_3(conn net.Conn, framer *http2.Framer) {
 
 	log.Println("Sent malformed SETTINGS frame with invalid length. Test complete.")
 }
+
+// Test Case 6.5/4: Sends a SETTINGS frame with an unknown identifier and answers the request once it is acknowledged.
+// The client should ignore the unknown setting, acknowledge the frame and receive the response.
+func RunTest6_5_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.5/4...")
+
+	// RFC 7540 Section 6.5.2: An endpoint that receives a SETTINGS frame with
+	// any unknown or unsupported identifier MUST ignore that setting.
+	if err := framer.WriteSettings(
+		http2.Setting{ID: 0xff, Val: 0xdeadbeef},
+		http2.Setting{ID: http2.SettingMaxConcurrentStreams, Val: 100},
+	); err != nil {
+		log.Printf("Failed to write SETTINGS frame: %v", err)
+		return
+	}
+	log.Println("Sent SETTINGS frame with unknown identifier, awaiting ACK.")
+
+	streamID, err := awaitRequestAndAcks(framer, 2)
+	if err != nil {
+		log.Printf("Failed to read request and SETTINGS ACK: %v", err)
+		return
+	}
+
+	if err := writeResponse(framer, streamID, "unknown setting ignored"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Println("Sent response after the SETTINGS ACK.")
+	linger(conn, framer)
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 
 	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
 // Test Case 6.7/1: Sends a PING frame.
@@ -132,3 +133,50 @@ func RunTest6_7_4(conn net.Conn, framer *http2.Framer) {
 
 	log.Println("Sent malformed PING frame with invalid length. Test complete.")
 }
+
+// Test Case 6.7/5: Sends a PING between the response HEADERS and DATA and holds the body until the PING is answered.
+// The client is expected to answer the PING with a PING ACK carrying the same payload.
+func RunTest6_7_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.7/5...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 6.7: Receivers of a PING frame that does not include an
+	// ACK flag MUST send a PING frame with the ACK flag set in response, with
+	// an identical payload.
+	pingData := [8]byte{'m', 'i', 'd', '-', 'b', 'o', 'd', 'y'}
+	if err := framer.WritePing(false, pingData); err != nil {
+		log.Printf("Failed to write PING frame: %v", err)
+		return
+	}
+	log.Println("Sent PING frame mid-response, awaiting ACK.")
+
+	if _, err := readUntil(framer, func(f http2.Frame) bool {
+		ping, ok := f.(*http2.PingFrame)
+		return ok && ping.IsAck() && ping.Data == pingData
+	}); err != nil {
+		log.Printf("Failed to read PING ACK: %v", err)
+		return
+	}
+	log.Println("Received PING ACK with correct data.")
+
+	if err := framer.WriteData(streamID, true, []byte("ping answered")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	log.Println("Sent response body after the PING ACK.")
+	linger(conn, framer)
+}
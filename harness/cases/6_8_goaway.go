@@ -5,6 +5,7 @@ import (
 	"net"
 
 	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
 // Test Case 6.8/1: Sends a GOAWAY frame with a non-zero stream identifier.
@@ -29,3 +30,58 @@ func RunTest6_8_1(conn net.Conn, framer *http2.Framer) {
 
 	log.Println("Sent malformed GOAWAY frame with non-zero stream ID. Test complete.")
 }
+
+// Test Case 6.8/2: Sends GOAWAY with the request's stream as last-stream-id between the response HEADERS and DATA.
+// The client should complete the response, as the stream is covered by the GOAWAY.
+func RunTest6_8_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.8/2...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 6.8: Streams up to and including the last stream
+	// identifier can still be completed successfully.
+	if err := framer.WriteGoAway(streamID, http2.ErrCodeNo, []byte("graceful shutdown")); err != nil {
+		log.Printf("Failed to write GOAWAY frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, true, []byte("completed after goaway")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	log.Println("Sent response body after GOAWAY - client should complete the stream")
+	linger(conn, framer)
+}
+
+// Test Case 6.8/3: Sends GOAWAY with a last-stream-id that covers two of four parallel requests, then answers only those two.
+// The client may retry the other two on a new connection or fail them, but must send its next request there; the harness logs which it did.
+func RunTest6_8_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.8/3...")
+	serveGoAwayWorkload(conn, framer, goAwayPlan{requests: 4, covered: []int{2}, later: 1})
+}
+
+// Test Case 6.8/4: Sends a GOAWAY that covers three of four parallel requests, then a second one that lowers the last-stream-id to the first request.
+// The client must not wait on the three requests above the lower last-stream-id; it may retry them on a new connection or fail them, and the harness logs which it did.
+func RunTest6_8_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.8/4...")
+	serveGoAwayWorkload(conn, framer, goAwayPlan{requests: 4, covered: []int{3, 1}, later: 1})
+}
+
+// Test Case 6.8/5: Sends a GOAWAY that covers three parallel requests and answers them, then a second one that raises the last-stream-id to 2^31-1.
+// The client may treat the increase as a connection error of type PROTOCOL_ERROR, but must not open new streams on the connection; the harness grades the streams it opens.
+func RunTest6_8_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.8/5...")
+	serveGoAwayWorkload(conn, framer, goAwayPlan{requests: 3, covered: []int{3, allStreams}, later: 1})
+}
@@ -1,46 +1,34 @@
 package cases
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"log"
 	"net"
+	"strconv"
+	"time"
 
 	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
-// Test Case 6.9.1/1: Sends SETTINGS frame to set the initial window size to 1 and sends HEADERS frame.
-// The client should respect the flow control window size.
-func RunTest6_9_1_1(conn net.Conn, framer *http2.Framer) {
-	log.Println("Running test case 6.9.1/1...")
-
-	// Send SETTINGS frame to set initial window size to 1
-	settingsFrame := []byte{
-		0x00, 0x00, 0x06, // Length: 6 bytes (one setting)
-		0x04,             // Type: SETTINGS
-		0x00,             // Flags: none
-		0x00, 0x00, 0x00, 0x00, // Stream ID: 0
-		0x00, 0x04,       // Setting ID: SETTINGS_INITIAL_WINDOW_SIZE (4)
-		0x00, 0x00, 0x00, 0x01, // Setting Value: 1
-	}
-
-	if _, err := conn.Write(settingsFrame); err != nil {
-		log.Printf("Failed to write SETTINGS frame: %v", err)
-		return
-	}
+// upload6_9_1_1 sets the client's initial stream window to a single octet.
+var upload6_9_1_1 = uploadPlan{
+	preface:  []http2.Setting{{ID: http2.SettingInitialWindowSize, Val: 1}},
+	minGrant: 1,
+	maxGrant: 1024,
+}
 
-	// Send HEADERS frame to open a stream
-	headersFrame := []byte{
-		0x00, 0x00, 0x01, // Length: 1 byte
-		0x01,             // Type: HEADERS
-		0x04,             // Flags: END_HEADERS
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-		0x82, // Header: :method: GET
-	}
+// Preface6_9_1_1 sets SETTINGS_INITIAL_WINDOW_SIZE to 1 in the server preface.
+func Preface6_9_1_1(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, upload6_9_1_1.preface...)
+}
 
-	if _, err := conn.Write(headersFrame); err != nil {
-		log.Printf("Failed to write HEADERS frame: %v", err)
-		return
-	}
-	log.Println("Sent SETTINGS with window size 1 - client should respect flow control")
+// Test Case 6.9.1/1: Sets the initial window size to 1 and receives a 256 KiB request body, granting stream credit one octet at first and doubling up to 1 KiB.
+// The client must never send DATA beyond its windows and must resume after every WINDOW_UPDATE; the harness checks the body's digest.
+func RunTest6_9_1_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.1/1...")
+	receiveUpload(conn, framer, upload6_9_1_1)
 }
 
 // Test Case 6.9.1/2: Sends multiple WINDOW_UPDATE frames increasing the flow control window to above 2^31-1.
@@ -126,4 +114,140 @@ func RunTest6_9_1_3(conn net.Conn, framer *http2.Framer) {
 		return
 	}
 	log.Println("Sent WINDOW_UPDATE causing stream overflow - client should detect FLOW_CONTROL_ERROR")
-}
\ No newline at end of file
+}
+
+// Test Case 6.9.1/4: Answers the request with DATA one octet beyond the smaller of the client's connection and stream receive windows.
+// The client should detect a FLOW_CONTROL_ERROR on the connection or the stream, whichever window is smaller.
+func RunTest6_9_1_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.1/4...")
+	overrunWindow(conn, framer, 0)
+}
+
+// Test Case 6.9.1/5: Answers the request with DATA whose payload fits the client's receive window but whose padding overruns it by one octet.
+// The client should detect a FLOW_CONTROL_ERROR, since padding counts toward flow control.
+func RunTest6_9_1_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.1/5...")
+	overrunWindow(conn, framer, 255)
+}
+
+// Test Case 6.9.1/6: Answers the request with DATA and padding that fill the smaller of the client's receive windows exactly.
+// The client should accept the complete response.
+func RunTest6_9_1_6(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.1/6...")
+
+	windows, err := awaitRequestWindows(conn, framer)
+	if err != nil {
+		log.Printf("Failed to read request: %v", err)
+		return
+	}
+	limit, _ := windows.limit()
+	if err := writeWindowResponse(framer, windows.streamID, SettingsOf(conn).MaxFrameSize(), limit, 255, true); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Printf("Sent %d octets of DATA and padding, filling the receive window exactly - client should accept them", limit)
+	linger(conn, framer)
+}
+
+// overrunWindow answers the client's request with one octet more than its
+// receive windows admit. With a non-zero padLen the last DATA frame is
+// padded, so that the data alone would have fitted.
+func overrunWindow(conn net.Conn, framer *http2.Framer, padLen uint8) {
+	windows, err := awaitRequestWindows(conn, framer)
+	if err != nil {
+		log.Printf("Failed to read request: %v", err)
+		return
+	}
+	limit, connLevel := windows.limit()
+	level := "stream"
+	if connLevel {
+		level = "connection"
+	}
+	if err := writeWindowResponse(framer, windows.streamID, SettingsOf(conn).MaxFrameSize(), limit+1, padLen, false); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Printf("Sent %d octets against a %s window of %d (%d of them padding) - client should detect FLOW_CONTROL_ERROR", limit+1, level, limit, padLen)
+	linger(conn, framer)
+}
+
+// Test Case 6.9.1/7: Serves an 8 MiB response body in full-size DATA frames without ever exceeding the client's windows.
+// The client must keep replenishing its windows; the harness records the WINDOW_UPDATE cadence and fails a client that deadlocks the transfer or over-credits a window beyond 2^31-1.
+func RunTest6_9_1_7(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.1/7...")
+	serveLargeBody(conn, framer, 8<<20, int(SettingsOf(conn).MaxFrameSize()), 0, false)
+}
+
+// Test Case 6.9.1/8: Serves an 8 MiB response body in 1 KiB DATA frames that each carry 255 octets of padding.
+// The client must return the credit for the padding as well as for the data, or the transfer deadlocks.
+func RunTest6_9_1_8(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.1/8...")
+	serveLargeBody(conn, framer, 8<<20, 1024, 255, false)
+}
+
+// Test Case 6.9.1/9: Serves a response body that exhausts the smaller of the client's windows with the DATA frame carrying END_STREAM.
+// The client may replenish the connection window but must not send WINDOW_UPDATE on the closed stream.
+func RunTest6_9_1_9(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.1/9...")
+	serveLargeBody(conn, framer, 0, int(SettingsOf(conn).MaxFrameSize()), 0, true)
+}
+
+// serveLargeBody answers the client's request with size octets of body,
+// or with exactly its smaller receive window if size is 0, respecting the
+// client's flow-control windows throughout. The response carries the
+// SHA-256 digest of the body in x-body-sha256. With checkClosed the harness
+// then grades the WINDOW_UPDATE frames the client sends for the closed
+// stream.
+func serveLargeBody(conn net.Conn, framer *http2.Framer, size, frameSize int, padLen uint8, checkClosed bool) {
+	windows, err := awaitRequestWindows(conn, framer)
+	if err != nil {
+		log.Printf("Failed to read request: %v", err)
+		return
+	}
+	if size == 0 {
+		limit, _ := windows.limit()
+		size = int(limit)
+	}
+
+	body := patternBody(size)
+	digest := sha256.Sum256(body)
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID: windows.streamID,
+		BlockFragment: encodeHeaders(
+			hpack.HeaderField{Name: ":status", Value: "200"},
+			hpack.HeaderField{Name: "content-length", Value: strconv.Itoa(size)},
+			hpack.HeaderField{Name: "x-body-sha256", Value: hex.EncodeToString(digest[:])},
+		),
+		EndHeaders: true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+
+	start := time.Now()
+	if !sendFlowControlled(conn, framer, windows, body, frameSize, padLen) {
+		linger(conn, framer)
+		return
+	}
+	windows.report(size, time.Since(start))
+	if checkClosed {
+		checkClosedStreamCredit(conn, framer, windows.streamID)
+		return
+	}
+	linger(conn, framer)
+}
+
+// upload6_9_1_10 withholds stream credit for half a second every time the
+// client exhausts its window.
+var upload6_9_1_10 = uploadPlan{
+	minGrant: 16384,
+	maxGrant: 16384,
+	pause:    500 * time.Millisecond,
+}
+
+// Test Case 6.9.1/10: Receives a 128 KiB request body, holding back each WINDOW_UPDATE for half a second after the client exhausts its stream window.
+// The client must wait while blocked and resume as soon as the credit arrives; the harness fails a client that stalls for two seconds with credit granted.
+func RunTest6_9_1_10(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.1/10...")
+	receiveUpload(conn, framer, upload6_9_1_10)
+}
@@ -7,6 +7,51 @@ import (
 	"golang.org/x/net/http2"
 )
 
+// upload6_9_2_1 shrinks the client's initial window from 1 MiB to 512 KiB
+// once 128 KiB of the body have arrived.
+var upload6_9_2_1 = uploadPlan{
+	preface:     []http2.Setting{{ID: http2.SettingInitialWindowSize, Val: 1 << 20}},
+	minGrant:    64 << 10,
+	maxGrant:    64 << 10,
+	changeAfter: 128 << 10,
+	change:      []http2.Setting{{ID: http2.SettingInitialWindowSize, Val: 512 << 10}},
+}
+
+// Preface6_9_2_1 sets SETTINGS_INITIAL_WINDOW_SIZE to 1 MiB in the server preface.
+func Preface6_9_2_1(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, upload6_9_2_1.preface...)
+}
+
+// Test Case 6.9.2/1: Lowers SETTINGS_INITIAL_WINDOW_SIZE from 1 MiB to 512 KiB in the middle of a 2 MiB request body.
+// The client must shrink the window of the open stream by the difference and send no more than it then allows until WINDOW_UPDATE.
+func RunTest6_9_2_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.2/1...")
+	receiveUpload(conn, framer, upload6_9_2_1)
+}
+
+// upload6_9_2_2 lowers the client's initial window from 256 KiB to 16 KiB
+// once 192 KiB of the body have arrived, which takes the stream window
+// below zero.
+var upload6_9_2_2 = uploadPlan{
+	preface:     []http2.Setting{{ID: http2.SettingInitialWindowSize, Val: 256 << 10}},
+	minGrant:    64 << 10,
+	maxGrant:    64 << 10,
+	changeAfter: 192 << 10,
+	change:      []http2.Setting{{ID: http2.SettingInitialWindowSize, Val: 16 << 10}},
+}
+
+// Preface6_9_2_2 sets SETTINGS_INITIAL_WINDOW_SIZE to 256 KiB in the server preface.
+func Preface6_9_2_2(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, upload6_9_2_2.preface...)
+}
+
+// Test Case 6.9.2/2: Lowers SETTINGS_INITIAL_WINDOW_SIZE from 256 KiB to 16 KiB in the middle of a 1 MiB request body.
+// The client's stream window goes negative; it must send nothing more on the stream until WINDOW_UPDATE frames lift the window above zero.
+func RunTest6_9_2_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.2/2...")
+	receiveUpload(conn, framer, upload6_9_2_2)
+}
+
 // Test Case 6.9.2/3: Sends a SETTINGS_INITIAL_WINDOW_SIZE settings with an exceeded maximum window size value.
 // The client is expected to detect a FLOW_CONTROL_ERROR.
 func RunTest6_9_2_3(conn net.Conn, framer *http2.Framer) {
@@ -30,3 +75,26 @@ func RunTest6_9_2_3(conn net.Conn, framer *http2.Framer) {
 
 	log.Println("Sent malformed SETTINGS frame with invalid window size. Test complete.")
 }
+
+// upload6_9_2_4 drops the initial window of three concurrent uploads from
+// 128 KiB to 0 once 192 KiB of body have arrived over all of them.
+var upload6_9_2_4 = uploadPlan{
+	preface:     []http2.Setting{{ID: http2.SettingInitialWindowSize, Val: 128 << 10}},
+	uploads:     3,
+	minGrant:    32 << 10,
+	maxGrant:    128 << 10,
+	changeAfter: 192 << 10,
+	change:      []http2.Setting{{ID: http2.SettingInitialWindowSize, Val: 0}},
+}
+
+// Preface6_9_2_4 sets SETTINGS_INITIAL_WINDOW_SIZE to 128 KiB in the server preface.
+func Preface6_9_2_4(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, upload6_9_2_4.preface...)
+}
+
+// Test Case 6.9.2/4: Lowers SETTINGS_INITIAL_WINDOW_SIZE to 0 while three 256 KiB request bodies are uploading at once.
+// The client must shrink the window of every open stream, down to zero or below, and send on each only the credit its own WINDOW_UPDATE frames grant.
+func RunTest6_9_2_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9.2/4...")
+	receiveUpload(conn, framer, upload6_9_2_4)
+}
@@ -74,3 +74,34 @@ func RunTest6_9_3(conn net.Conn, framer *http2.Framer) {
 
 	log.Println("Sent malformed WINDOW_UPDATE frame with invalid length. Test complete.")
 }
+
+// Test Case 6.9/4: Sends WINDOW_UPDATE frames on the connection and on the half-closed (local) request stream before the response.
+// The client should accept them and receive the response.
+func RunTest6_9_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 6.9/4...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 5.1: A stream in the "half-closed (local)" state can
+	// receive frames of any type; WINDOW_UPDATE may arrive even after the
+	// client has finished sending.
+	if err := framer.WriteWindowUpdate(0, 1024); err != nil {
+		log.Printf("Failed to write connection WINDOW_UPDATE frame: %v", err)
+		return
+	}
+	if err := framer.WriteWindowUpdate(streamID, 1024); err != nil {
+		log.Printf("Failed to write stream WINDOW_UPDATE frame: %v", err)
+		return
+	}
+
+	if err := writeResponse(framer, streamID, "window update accepted"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Println("Sent WINDOW_UPDATE frames followed by the response.")
+	linger(conn, framer)
+}
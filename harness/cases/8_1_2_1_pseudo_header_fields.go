@@ -121,3 +121,16 @@ func RunTest8_1_2_1_4(conn net.Conn, framer *http2.Framer) {
 	}
 	log.Println("Sent HEADERS frame with pseudo-header after regular header. Test complete.")
 }
+
+// Test Case 8.1.2.1/5: Serves a request that carries a regular x-order header field the verifier sets.
+// The client must send every pseudo-header field before it; the harness fails a header block that does not.
+func RunTest8_1_2_1_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1.2.1/5...")
+	serveCheckedRequests(conn, framer, 1, "8.1.2.1", func(headers *http2.MetaHeadersFrame) {
+		for _, f := range headers.RegularFields() {
+			if f.Name == "x-order" {
+				log.Printf("Received x-order after %d pseudo-header fields.", len(headers.PseudoFields()))
+			}
+		}
+	})
+}
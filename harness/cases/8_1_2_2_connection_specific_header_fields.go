@@ -54,3 +54,17 @@ func RunTest8_1_2_2_2(conn net.Conn, framer *http2.Framer) {
 	}
 	log.Println("Sent HEADERS frame with invalid TE header. Test complete.")
 }
+
+// Test Case 8.1.2.2/3: Serves a request for which the verifier sets "Connection: keep-alive".
+// The request validator reports a connection-specific header field the client forwarded.
+func RunTest8_1_2_2_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1.2.2/3...")
+	serveRequest(conn, framer, "ok")
+}
+
+// Test Case 8.1.2.2/4: Serves a request for which the verifier sets "TE: gzip".
+// The request validator reports a TE header field with any value other than "trailers".
+func RunTest8_1_2_2_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1.2.2/4...")
+	serveRequest(conn, framer, "ok")
+}
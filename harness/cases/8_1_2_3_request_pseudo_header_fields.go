@@ -175,3 +175,45 @@ func RunTest8_1_2_3_7(conn net.Conn, framer *http2.Framer) {
 	}
 	log.Println("Sent HEADERS frame with duplicated :path. Test complete.")
 }
+
+// checkedPath is the path and query the verifier requests in 8.1.2.3/8.
+const checkedPath = "/search?q=h2"
+
+// virtualHost is the host the verifier requests in 8.1.2.3/10, on the
+// harness's address.
+const virtualHost = "vhost.test:8080"
+
+// Test Case 8.1.2.3/8: Serves a request for /search?q=h2.
+// The client must carry the path and query in ":path"; the harness fails a request without them.
+func RunTest8_1_2_3_8(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1.2.3/8...")
+	serveCheckedRequests(conn, framer, 1, "8.1.2.3", func(headers *http2.MetaHeadersFrame) {
+		if path := headers.PseudoValue("path"); path != checkedPath {
+			reportViolation(conn, "request on stream %d with :path %q instead of %q (RFC 7540 Section 8.1.2.3)", headers.StreamID, path, checkedPath)
+		}
+	})
+}
+
+// Test Case 8.1.2.3/9: Serves three requests in turn on one connection, whose header blocks the client may encode from its dynamic table.
+// The client must send each pseudo-header field exactly once in every request; the harness fails a header block that does not.
+func RunTest8_1_2_3_9(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1.2.3/9...")
+	serveCheckedRequests(conn, framer, 3, "8.1.2.3", func(headers *http2.MetaHeadersFrame) {
+		for _, name := range []string{"method", "scheme", "path"} {
+			if headers.PseudoValue(name) == "" {
+				reportViolation(conn, "request on stream %d without :%s (RFC 7540 Section 8.1.2.3)", headers.StreamID, name)
+			}
+		}
+	})
+}
+
+// Test Case 8.1.2.3/10: Serves a request for the virtual host vhost.test:8080 on the harness's address.
+// The client must carry the host in ":authority" rather than only in a host header field; the harness fails it otherwise.
+func RunTest8_1_2_3_10(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1.2.3/10...")
+	serveCheckedRequests(conn, framer, 1, "8.1.2.3", func(headers *http2.MetaHeadersFrame) {
+		if authority := headers.PseudoValue("authority"); authority != virtualHost {
+			reportViolation(conn, "request on stream %d with :authority %q instead of %q (RFC 9113 Section 8.3.1)", headers.StreamID, authority, virtualHost)
+		}
+	})
+}
@@ -72,3 +72,39 @@ func RunTest8_1_2_6_2(conn net.Conn, framer *http2.Framer) {
 	}
 	log.Println("Sent second DATA frame. Test complete.")
 }
+
+// Test Case 8.1.2.6/3: Ends the response stream before the number of octets announced by "content-length" has been sent.
+// The client is expected to treat the response as malformed with a PROTOCOL_ERROR.
+func RunTest8_1_2_6_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1.2.6/3...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID: streamID,
+		BlockFragment: encodeHeaders(
+			hpack.HeaderField{Name: ":status", Value: "200"},
+			hpack.HeaderField{Name: "content-length", Value: "100"},
+		),
+		EndStream:  false,
+		EndHeaders: true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	log.Println("Sent HEADERS frame with content-length: 100.")
+
+	// RFC 7540 Section 8.1.2.6: A response is malformed if the value of a
+	// content-length header field does not equal the sum of the DATA frame
+	// payload lengths that form the body.
+	if err := framer.WriteData(streamID, true, []byte("short")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	log.Println("Sent DATA frame with END_STREAM after 5 octets - client should detect PROTOCOL_ERROR")
+	linger(conn, framer)
+}
@@ -31,3 +31,10 @@ func RunTest8_1_2_1(conn net.Conn, framer *http2.Framer) {
 	}
 	log.Println("Sent HEADERS frame with uppercase header field name. Test complete.")
 }
+
+// Test Case 8.1.2/2: Serves a request that carries a header field the verifier sets in mixed case.
+// The request validator reports a header field name the client did not send in lowercase.
+func RunTest8_1_2_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1.2/2...")
+	serveRequest(conn, framer, "ok")
+}
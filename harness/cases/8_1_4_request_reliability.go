@@ -0,0 +1,54 @@
+package cases
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	"golang.org/x/net/http2"
+)
+
+// Test Cases 8.1.4/1 to 8.1.4/17: Reset the client's request with the
+// RST_STREAM code of resetCases, once the request is complete, and then
+// serve the client's attempts to send it again.
+//
+// Only REFUSED_STREAM guarantees that the server did not process the
+// request (RFC 7540 Section 8.1.4). After it the client may send the
+// request again, even a POST; after any other code it may fail the request
+// or send it again, though RFC 9110 Section 9.2.2 advises against sending a
+// POST again. The harness logs which the client did and how long it waited,
+// without failing it either way; it fails the client only for sending the
+// request again on a stream it already used. Requests a GOAWAY leaves
+// unprocessed are followed by test cases 6.8/3 and 6.8/4.
+var resetCases = []resetPlan{
+	{code: http2.ErrCodeNo, resets: 1},
+	{code: http2.ErrCodeProtocol, resets: 1},
+	{code: http2.ErrCodeInternal, resets: 1},
+	{code: http2.ErrCodeFlowControl, resets: 1},
+	{code: http2.ErrCodeSettingsTimeout, resets: 1},
+	{code: http2.ErrCodeStreamClosed, resets: 1},
+	{code: http2.ErrCodeFrameSize, resets: 1},
+	{code: http2.ErrCodeRefusedStream, resets: 1},
+	{code: http2.ErrCodeCancel, resets: 1},
+	{code: http2.ErrCodeCompression, resets: 1},
+	{code: http2.ErrCodeConnect, resets: 1},
+	{code: http2.ErrCodeEnhanceYourCalm, resets: 1},
+	{code: http2.ErrCodeInadequateSecurity, resets: 1},
+	{code: http2.ErrCodeHTTP11Required, resets: 1}, // answered over HTTP/1.1 if the client falls back
+	{code: http2.ErrCodeRefusedStream, resets: 1},  // the verifier sends a POST
+	{code: http2.ErrCodeCancel, resets: 1},         // the verifier sends a POST
+	{code: http2.ErrCodeRefusedStream, resets: 3},
+}
+
+// ResetTests returns test cases 8.1.4/1 to 8.1.4/17 by their IDs.
+func ResetTests() map[string]func(conn net.Conn, framer *http2.Framer) {
+	tests := make(map[string]func(conn net.Conn, framer *http2.Framer))
+	for i, plan := range resetCases {
+		id := fmt.Sprintf("8.1.4/%d", i+1)
+		tests[id] = func(conn net.Conn, framer *http2.Framer) {
+			log.Printf("Running test case %s...", id)
+			serveResets(conn, framer, plan)
+		}
+	}
+	return tests
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"log"
 	"net"
+	"strings"
 
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/hpack"
@@ -50,3 +51,159 @@ func RunTest8_1_1(conn net.Conn, framer *http2.Framer) {
 	}
 	log.Println("Sent second HEADERS frame, which should trigger an error. Test complete.")
 }
+
+// Test Case 8.1/2: Answers the request with a single HEADERS frame carrying :status 204 and END_STREAM.
+// The client should report the status with an empty body.
+func RunTest8_1_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/2...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 8.1: A response may consist of a single HEADERS frame
+	// with END_STREAM when it has no payload body.
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "204"}),
+		EndStream:     true,
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	log.Println("Sent HEADERS-only 204 response.")
+	linger(conn, framer)
+}
+
+// Test Case 8.1/3: Sends a 103 (Early Hints) response with a link header field before the final response.
+// The client should skip the informational response and report the 200 response with body "ok".
+func RunTest8_1_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/3...")
+	serveInformational(conn, framer, earlyHints("/style.css"))
+}
+
+// Test Case 8.1/4: Sends three 103 (Early Hints) responses before the final response.
+// The client should skip every informational response and report the 200 response with body "ok".
+func RunTest8_1_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/4...")
+	serveInformational(conn, framer, earlyHints("/style.css"), earlyHints("/script.js"), earlyHints("/font.woff2"))
+}
+
+// Test Case 8.1/5: Holds 100 Continue back from an upload that expects it, then reads the body.
+// The client should deliver the body intact and report the 200 response; the harness logs whether it waited.
+func RunTest8_1_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/5...")
+	receiveContinuedUpload(conn, framer)
+}
+
+// Test Case 8.1/6: Answers an upload that expects 100 Continue with a complete 413 response and RST_STREAM NO_ERROR.
+// The client should stop sending the body and report the 413 response.
+func RunTest8_1_6(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/6...")
+	refuseUpload(conn, framer)
+}
+
+// Test Case 8.1/7: Sends a 103 response with END_STREAM, then a final response on the closed stream.
+// The client is expected to treat the response as malformed and detect a PROTOCOL_ERROR.
+func RunTest8_1_7(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/7...")
+	hints := earlyHints("/style.css")
+	hints.endStream = true
+	serveInformational(conn, framer, hints)
+}
+
+// Test Case 8.1/8: Sends a 101 (Switching Protocols) response before the final response.
+// The client is expected to treat the response as malformed and detect a PROTOCOL_ERROR.
+func RunTest8_1_8(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/8...")
+	serveInformational(conn, framer, informational{status: "101"})
+}
+
+// Test Case 8.1/9: Ends a response with body "ok" with trailers after the DATA frame.
+// The client should report the body and expose the x-result and x-records trailers.
+func RunTest8_1_9(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/9...")
+	serveTrailers(conn, framer, trailerPlan{
+		body: "ok",
+		trailers: []hpack.HeaderField{
+			{Name: "x-result", Value: "complete"},
+			{Name: "x-records", Value: "1"},
+		},
+	})
+}
+
+// Test Case 8.1/10: Follows the response HEADERS directly with trailers, without any DATA frame.
+// The client should report an empty body and expose the x-result trailer.
+func RunTest8_1_10(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/10...")
+	serveTrailers(conn, framer, trailerPlan{
+		trailers: []hpack.HeaderField{{Name: "x-result", Value: "empty"}},
+	})
+}
+
+// Test Case 8.1/11: Splits the trailers across a HEADERS frame and CONTINUATION frames of 64 octets.
+// The client should reassemble the trailers and expose the x-result trailer.
+func RunTest8_1_11(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/11...")
+	serveTrailers(conn, framer, trailerPlan{
+		body: "ok",
+		trailers: []hpack.HeaderField{
+			{Name: "x-padding", Value: strings.Repeat("p", 200)},
+			{Name: "x-result", Value: "split"},
+		},
+		fragment: 64,
+	})
+}
+
+// Test Case 8.1/12: Sends trailers that repeat the x-result field.
+// The client should expose both values of the x-result trailer in order.
+func RunTest8_1_12(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/12...")
+	serveTrailers(conn, framer, trailerPlan{
+		body: "ok",
+		trailers: []hpack.HeaderField{
+			{Name: "x-result", Value: "first"},
+			{Name: "x-result", Value: "second"},
+		},
+	})
+}
+
+// Test Case 8.1/13: Sends trailers without END_STREAM, then ends the stream with an empty DATA frame.
+// The client is expected to treat the response as malformed and detect a PROTOCOL_ERROR.
+func RunTest8_1_13(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/13...")
+	serveTrailers(conn, framer, trailerPlan{
+		body:     "ok",
+		trailers: []hpack.HeaderField{{Name: "x-result", Value: "complete"}},
+		open:     true,
+	})
+}
+
+// Test Case 8.1/14: Sends trailers that carry the connection-specific connection field.
+// The client is expected to treat the response as malformed and detect a PROTOCOL_ERROR.
+func RunTest8_1_14(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/14...")
+	serveTrailers(conn, framer, trailerPlan{
+		body: "ok",
+		trailers: []hpack.HeaderField{
+			{Name: "x-result", Value: "complete"},
+			{Name: "connection", Value: "close"},
+		},
+	})
+}
+
+// Test Case 8.1/15: Sends trailers that carry the connection-specific transfer-encoding field.
+// The client is expected to treat the response as malformed and detect a PROTOCOL_ERROR.
+func RunTest8_1_15(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.1/15...")
+	serveTrailers(conn, framer, trailerPlan{
+		body: "ok",
+		trailers: []hpack.HeaderField{
+			{Name: "x-result", Value: "complete"},
+			{Name: "transfer-encoding", Value: "chunked"},
+		},
+	})
+}
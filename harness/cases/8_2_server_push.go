@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"log"
 	"net"
+	"time"
 
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/hpack"
@@ -33,3 +34,280 @@ func RunTest8_2_1(conn net.Conn, framer *http2.Framer) {
 	}
 	log.Println("Sent PUSH_PROMISE frame. Test complete.")
 }
+
+// pushTimeout bounds how long the harness waits for the client to answer a
+// PUSH_PROMISE.
+const pushTimeout = 2 * time.Second
+
+// awaitPushRequest waits for the client's request and returns its stream
+// identifier. Test cases 8.2/2 to 8.2/9 push to the client, so they only
+// apply to a client that enabled server push: if the client disabled it,
+// awaitPushRequest skips the test case and returns false. The reference
+// client always disables push, so only the verifier's mutant client, which
+// enables it, is graded by them.
+func awaitPushRequest(conn net.Conn, framer *http2.Framer) (uint32, bool) {
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return 0, false
+	}
+	if !SettingsOf(conn).EnablePush() {
+		skipRequest(conn, framer, streamID, "the client disabled server push with SETTINGS_ENABLE_PUSH=0")
+		return 0, false
+	}
+	return streamID, true
+}
+
+// writePromise sends a PUSH_PROMISE on streamID that reserves promiseID for
+// a method request of path.
+func writePromise(framer *http2.Framer, streamID, promiseID uint32, method, path string) error {
+	if err := framer.WritePushPromise(http2.PushPromiseParam{
+		StreamID:  streamID,
+		PromiseID: promiseID,
+		BlockFragment: encodeHeaders(
+			hpack.HeaderField{Name: ":method", Value: method},
+			hpack.HeaderField{Name: ":scheme", Value: "https"},
+			hpack.HeaderField{Name: ":authority", Value: "127.0.0.1:8080"},
+			hpack.HeaderField{Name: ":path", Value: path},
+		),
+		EndHeaders: true,
+	}); err != nil {
+		return err
+	}
+	log.Printf("Sent PUSH_PROMISE on stream %d reserving stream %d for %s %s.", streamID, promiseID, method, path)
+	return nil
+}
+
+// awaitReset waits up to pushTimeout for the client to reset streamID and
+// returns the error code, or false if it does not.
+func awaitReset(conn net.Conn, framer *http2.Framer, streamID uint32) (http2.ErrCode, bool) {
+	conn.SetReadDeadline(time.Now().Add(pushTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	frame, err := readUntil(framer, func(f http2.Frame) bool {
+		rst, ok := f.(*http2.RSTStreamFrame)
+		return ok && rst.StreamID == streamID
+	})
+	if err != nil {
+		return 0, false
+	}
+	return frame.(*http2.RSTStreamFrame).ErrCode, true
+}
+
+// Test Case 8.2/2: Promises GET /pushed on the client's request and pushes a complete response on the reserved stream.
+// A client that enables push is expected to receive both the response and the pushed response.
+func RunTest8_2_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.2/2...")
+	streamID, ok := awaitPushRequest(conn, framer)
+	if !ok {
+		return
+	}
+	if err := writePromise(framer, streamID, 2, "GET", "/pushed"); err != nil {
+		log.Printf("Failed to write PUSH_PROMISE frame: %v", err)
+		return
+	}
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	if err := writeResponse(framer, 2, "pushed"); err != nil {
+		log.Printf("Failed to write pushed response: %v", err)
+		return
+	}
+	log.Println("Sent the response and the pushed response on stream 2.")
+	linger(conn, framer)
+}
+
+// Test Case 8.2/3: Promises GET /pushed and, once the client refuses the push, sends the pushed response that was already in flight.
+// A client that refuses the push with RST_STREAM CANCEL is expected to ignore the pushed response and complete its request.
+func RunTest8_2_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.2/3...")
+	streamID, ok := awaitPushRequest(conn, framer)
+	if !ok {
+		return
+	}
+	if err := writePromise(framer, streamID, 2, "GET", "/pushed"); err != nil {
+		log.Printf("Failed to write PUSH_PROMISE frame: %v", err)
+		return
+	}
+	if code, ok := awaitReset(conn, framer, 2); ok {
+		log.Printf("The client refused the push with RST_STREAM %v.", code)
+	} else {
+		log.Printf("The client did not refuse the push within %v.", pushTimeout)
+	}
+
+	// RFC 7540 Section 5.1: frames that were in flight when the client
+	// reset the stream must be ignored.
+	if err := writeResponse(framer, 2, "pushed"); err != nil {
+		log.Printf("Failed to write pushed response: %v", err)
+		return
+	}
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Println("Sent the pushed response after the refusal, then the response.")
+	linger(conn, framer)
+}
+
+// Test Case 8.2/4: Sends a second PUSH_PROMISE that reserves stream 2 again.
+// A client that enables push is expected to detect a PROTOCOL_ERROR.
+func RunTest8_2_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.2/4...")
+	streamID, ok := awaitPushRequest(conn, framer)
+	if !ok {
+		return
+	}
+	if err := writePromise(framer, streamID, 2, "GET", "/first"); err != nil {
+		log.Printf("Failed to write PUSH_PROMISE frame: %v", err)
+		return
+	}
+	if err := writePromise(framer, streamID, 2, "GET", "/second"); err != nil {
+		log.Printf("Failed to write PUSH_PROMISE frame: %v", err)
+		return
+	}
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Println("Reused promised stream 2 - client should detect PROTOCOL_ERROR")
+	linger(conn, framer)
+}
+
+// Test Case 8.2/5: Sends a PUSH_PROMISE that reserves the odd stream 3.
+// A client that enables push is expected to detect a PROTOCOL_ERROR.
+func RunTest8_2_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.2/5...")
+	streamID, ok := awaitPushRequest(conn, framer)
+	if !ok {
+		return
+	}
+	if err := writePromise(framer, streamID, 3, "GET", "/pushed"); err != nil {
+		log.Printf("Failed to write PUSH_PROMISE frame: %v", err)
+		return
+	}
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Println("Promised odd stream 3 - client should detect PROTOCOL_ERROR")
+	linger(conn, framer)
+}
+
+// Test Case 8.2/6: Completes the response and then sends a PUSH_PROMISE on the closed request stream.
+// A client that enables push is expected to close the connection with a PROTOCOL_ERROR or STREAM_CLOSED GOAWAY; the harness grades the GOAWAY.
+func RunTest8_2_6(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.2/6...")
+	streamID, ok := awaitPushRequest(conn, framer)
+	if !ok {
+		return
+	}
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	if err := writePromise(framer, streamID, 2, "GET", "/pushed"); err != nil {
+		log.Printf("Failed to write PUSH_PROMISE frame: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 6.6: a PUSH_PROMISE on a stream that is neither
+	// open nor half-closed (local) is a connection error.
+	conn.SetReadDeadline(time.Now().Add(pushTimeout))
+	frame, err := readUntil(framer, func(f http2.Frame) bool {
+		_, ok := f.(*http2.GoAwayFrame)
+		return ok
+	})
+	if err != nil {
+		reportViolation(conn, "client accepted a PUSH_PROMISE on closed stream %d instead of closing the connection with PROTOCOL_ERROR (RFC 7540 Section 6.6)", streamID)
+		return
+	}
+	switch code := frame.(*http2.GoAwayFrame).ErrCode; code {
+	case http2.ErrCodeProtocol, http2.ErrCodeStreamClosed:
+		log.Printf("The client closed the connection with GOAWAY %v.", code)
+	default:
+		reportViolation(conn, "client answered a PUSH_PROMISE on closed stream %d with GOAWAY %v instead of PROTOCOL_ERROR (RFC 7540 Section 6.6)", streamID, code)
+	}
+}
+
+// Test Case 8.2/7: Sends a PUSH_PROMISE for a POST request.
+// A client that enables push is expected to reset the promised stream with PROTOCOL_ERROR; the harness grades the RST_STREAM.
+func RunTest8_2_7(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.2/7...")
+	streamID, ok := awaitPushRequest(conn, framer)
+	if !ok {
+		return
+	}
+	if err := writePromise(framer, streamID, 2, "POST", "/upload"); err != nil {
+		log.Printf("Failed to write PUSH_PROMISE frame: %v", err)
+		return
+	}
+
+	// RFC 7540 Section 8.2: a promised request must be safe and cacheable;
+	// the client answers any other with a stream error of type
+	// PROTOCOL_ERROR.
+	switch code, ok := awaitReset(conn, framer, 2); {
+	case !ok:
+		reportViolation(conn, "client accepted a promised POST request instead of resetting stream 2 with PROTOCOL_ERROR (RFC 7540 Section 8.2)")
+	case code != http2.ErrCodeProtocol:
+		reportViolation(conn, "client reset the promised POST request on stream 2 with %v instead of PROTOCOL_ERROR (RFC 7540 Section 8.2)", code)
+	default:
+		log.Println("The client reset the promised POST request with PROTOCOL_ERROR.")
+	}
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	linger(conn, framer)
+}
+
+// Test Case 8.2/8: Sends response HEADERS on stream 2 before the PUSH_PROMISE that reserves it.
+// A client that enables push is expected to detect a PROTOCOL_ERROR.
+func RunTest8_2_8(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.2/8...")
+	streamID, ok := awaitPushRequest(conn, framer)
+	if !ok {
+		return
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      2,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := writePromise(framer, streamID, 2, "GET", "/pushed"); err != nil {
+		log.Printf("Failed to write PUSH_PROMISE frame: %v", err)
+		return
+	}
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Println("Sent HEADERS on stream 2 ahead of its promise - client should detect PROTOCOL_ERROR")
+	linger(conn, framer)
+}
+
+// Test Case 8.2/9: Sends DATA on stream 2 after promising it and before its response HEADERS.
+// A client that enables push is expected to detect a PROTOCOL_ERROR.
+func RunTest8_2_9(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.2/9...")
+	streamID, ok := awaitPushRequest(conn, framer)
+	if !ok {
+		return
+	}
+	if err := writePromise(framer, streamID, 2, "GET", "/pushed"); err != nil {
+		log.Printf("Failed to write PUSH_PROMISE frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(2, false, []byte("pushed")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Println("Sent DATA on reserved stream 2 - client should detect PROTOCOL_ERROR")
+	linger(conn, framer)
+}
@@ -0,0 +1,154 @@
+package cases
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// tunnelTimeout bounds how long a tunnel waits for the client's next
+// frame.
+const tunnelTimeout = 5 * time.Second
+
+// awaitConnect waits for the HEADERS of the client's CONNECT request and
+// returns them, decoded. It logs the target and, for an extended CONNECT,
+// the protocol.
+func awaitConnect(conn net.Conn, framer *http2.Framer) (*http2.MetaHeadersFrame, error) {
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	conn.SetReadDeadline(time.Now().Add(tunnelTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	frame, err := readUntil(framer, func(f http2.Frame) bool {
+		_, ok := f.(*http2.MetaHeadersFrame)
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+	headers := frame.(*http2.MetaHeadersFrame)
+	if protocol := headers.PseudoValue("protocol"); protocol != "" {
+		log.Printf("Received %s request for %s%s with protocol %q on stream %d.", headers.PseudoValue("method"), headers.PseudoValue("authority"), headers.PseudoValue("path"), protocol, headers.StreamID)
+	} else {
+		log.Printf("Received %s request for %s on stream %d.", headers.PseudoValue("method"), headers.PseudoValue("authority"), headers.StreamID)
+	}
+	return headers, nil
+}
+
+// writeStatus writes a response header block with status on streamID.
+func writeStatus(framer *http2.Framer, streamID uint32, status string, endStream bool) error {
+	return framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: status}),
+		EndStream:     endStream,
+		EndHeaders:    true,
+	})
+}
+
+// relayTunnel reads the frames of the tunnel on streamID and hands the
+// payload of each DATA frame to onData, whose reply, if any, it writes
+// back. It returns the credit of every DATA frame at once and returns
+// when the client ends the stream, resets it, or stops sending, or when
+// onData reports that the tunnel is done.
+func relayTunnel(conn net.Conn, framer *http2.Framer, streamID uint32, onData func(data []byte, end bool) (reply []byte, done bool)) {
+	conn.SetReadDeadline(time.Now().Add(tunnelTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			log.Printf("Tunnel on stream %d ended: %v", streamID, err)
+			return
+		}
+		switch f := frame.(type) {
+		case *http2.DataFrame:
+			if f.StreamID != streamID {
+				continue
+			}
+			if n := f.Header().Length; n > 0 {
+				framer.WriteWindowUpdate(0, n)
+				framer.WriteWindowUpdate(streamID, n)
+			}
+			reply, done := onData(f.Data(), f.StreamEnded())
+			if len(reply) > 0 || f.StreamEnded() {
+				if err := framer.WriteData(streamID, f.StreamEnded(), reply); err != nil {
+					log.Printf("Failed to write DATA frame: %v", err)
+					return
+				}
+			}
+			if done || f.StreamEnded() {
+				return
+			}
+		case *http2.RSTStreamFrame:
+			if f.StreamID == streamID {
+				log.Printf("The client reset the tunnel on stream %d with %v.", streamID, f.ErrCode)
+				return
+			}
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		}
+	}
+}
+
+// serveTunnel accepts the client's CONNECT request with a 200 response,
+// sends banner through the tunnel if it is not empty, and then echoes
+// everything the client tunnels until the client closes its side, which
+// the harness answers by closing its own.
+func serveTunnel(conn net.Conn, framer *http2.Framer, banner string) {
+	headers, err := awaitConnect(conn, framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	streamID := headers.StreamID
+	if method := headers.PseudoValue("method"); method != "CONNECT" {
+		log.Printf("Expected a CONNECT request, but got %s; answering 405.", method)
+		writeStatus(framer, streamID, "405", true)
+		linger(conn, framer)
+		return
+	}
+	if err := writeStatus(framer, streamID, "200", false); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if banner != "" {
+		if err := framer.WriteData(streamID, false, []byte(banner)); err != nil {
+			log.Printf("Failed to write DATA frame: %v", err)
+			return
+		}
+	}
+	log.Printf("Opened the tunnel on stream %d.", streamID)
+	if headers.StreamEnded() {
+		reportViolation(conn, "CONNECT request on stream %d ended the stream with its HEADERS", streamID)
+		return
+	}
+	echoed := 0
+	relayTunnel(conn, framer, streamID, func(data []byte, end bool) ([]byte, bool) {
+		echoed += len(data)
+		if end {
+			log.Printf("The client closed the tunnel on stream %d after %d octets; closing it too.", streamID, echoed)
+		}
+		return data, false
+	})
+	linger(conn, framer)
+}
+
+// Test Case 8.3/1: Accepts the client's CONNECT to tunnel.test:443 and echoes everything it tunnels.
+// The request validator checks that the CONNECT carries only :method and :authority, without :scheme and :path.
+func RunTest8_3_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.3/1...")
+	serveTunnel(conn, framer, "")
+}
+
+// Test Case 8.3/2: Accepts the client's CONNECT and tunnels a banner right after the 200 response, before the client sends anything.
+// The client must deliver the tunnelled banner, then echo traffic, as a TCP stream over DATA frames.
+func RunTest8_3_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 8.3/2...")
+	serveTunnel(conn, framer, "220 tunnel ready\r\n")
+}
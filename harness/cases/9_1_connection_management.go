@@ -0,0 +1,182 @@
+package cases
+
+import (
+	"crypto/x509"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// CertificateNames lists the host names the harness's certificate covers,
+// besides the address 127.0.0.1. Names under .test resolve to the harness
+// for the verifier's clients; a client under test must resolve the names
+// its test case logs to 127.0.0.1 itself.
+var CertificateNames = []string{"localhost", "a.coalesce.test", "b.coalesce.test"}
+
+// uncoveredHost resolves to the harness like the names of the certificate,
+// but the certificate does not cover it.
+const uncoveredHost = "uncovered.test"
+
+// certificate is the certificate the harness serves.
+var certificate *x509.Certificate
+
+// UseCertificate tells the test cases which certificate the harness serves,
+// so that they can tell which origins a connection is authoritative for.
+func UseCertificate(cert *x509.Certificate) {
+	certificate = cert
+}
+
+// authorityHost returns the host of authority, without its port.
+func authorityHost(authority string) string {
+	if host, _, err := net.SplitHostPort(authority); err == nil {
+		return host
+	}
+	return authority
+}
+
+// checkAuthority fails the client for sending request r on a connection
+// that is not authoritative for it: one whose certificate does not cover
+// the request's host and on which the client did not ask for that host in
+// the TLS handshake (RFC 7540 Section 9.1.1).
+func checkAuthority(conn net.Conn, r servedRequest) {
+	host := authorityHost(r.authority)
+	if host == r.serverName || certificate != nil && certificate.VerifyHostname(host) == nil {
+		return
+	}
+	reportViolation(conn, "request for %s on connection %d, whose certificate does not cover it; the client may only send it on a connection for which the server is authoritative (RFC 7540 Section 9.1.1)", r.authority, r.conn)
+}
+
+// logCoalescing logs whether the client sent the requests for different
+// origins on one connection.
+func logCoalescing(served []servedRequest) {
+	first := make(map[string]int)
+	for _, r := range served {
+		if _, ok := first[r.authority]; !ok {
+			first[r.authority] = r.conn
+		}
+	}
+	if len(served) == 0 {
+		return
+	}
+	for authority, id := range first {
+		if authority == served[0].authority {
+			continue
+		}
+		if id == served[0].conn {
+			log.Printf("The client coalesced its request for %s onto connection %d, opened for %s.", authority, id, served[0].authority)
+		} else {
+			log.Printf("The client opened connection %d for %s.", id, authority)
+		}
+	}
+}
+
+// logServing logs the hosts the client must resolve to the harness and the
+// names its certificate covers.
+func logServing(hosts []string) {
+	log.Printf("Serving %s on 127.0.0.1:8080; the certificate covers %s and 127.0.0.1.", strings.Join(hosts, ", "), strings.Join(CertificateNames, ", "))
+}
+
+// serveOrigins serves the client's request for each of hosts, on whichever
+// connection it arrives, failing the client for any request on a connection
+// that is not authoritative for it.
+func serveOrigins(conn net.Conn, framer *http2.Framer, hosts ...string) {
+	logServing(hosts)
+	served := serveConnections(conn, framer, len(hosts), func(conn net.Conn, framer *http2.Framer, r servedRequest) (int, error) {
+		checkAuthority(conn, r)
+		return answerOK(conn, framer, r)
+	})
+	logCoalescing(served)
+	linger(conn, framer)
+}
+
+// misdirection answers 421 Misdirected Request to requests the harness
+// refuses to serve on the connection they arrived on, and fails the client
+// for sending such a request again on the same connection.
+type misdirection struct {
+	// refuse reports whether the harness refuses r on its connection.
+	refuse func(r servedRequest) bool
+
+	mu sync.Mutex
+	// refused holds the authorities each connection answered with 421.
+	refused map[int]map[string]bool
+}
+
+// answer answers request r with 421 or with a 200 response carrying body
+// "ok".
+func (m *misdirection) answer(conn net.Conn, framer *http2.Framer, r servedRequest) (int, error) {
+	checkAuthority(conn, r)
+	m.mu.Lock()
+	again := m.refused[r.conn][r.authority]
+	refuse := again || m.refuse(r)
+	if refuse {
+		if m.refused[r.conn] == nil {
+			m.refused[r.conn] = make(map[string]bool)
+		}
+		m.refused[r.conn][r.authority] = true
+	}
+	m.mu.Unlock()
+
+	if again {
+		reportViolation(conn, "request for %s sent again on connection %d, which answered it with 421 Misdirected Request; the client may only retry it on a different connection (RFC 7540 Section 9.1.2)", r.authority, r.conn)
+	}
+	if !refuse {
+		return answerOK(conn, framer, r)
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      r.streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "421"}),
+		EndStream:     true,
+		EndHeaders:    true,
+	}); err != nil {
+		return 0, err
+	}
+	log.Printf("Answered the request for %s on connection %d with 421 Misdirected Request.", r.authority, r.conn)
+	return http.StatusMisdirectedRequest, nil
+}
+
+// serveMisdirected serves the client's request for each of hosts, on
+// whichever connection it arrives, answering 421 where refuse says so.
+func serveMisdirected(conn net.Conn, framer *http2.Framer, refuse func(r servedRequest) bool, hosts ...string) {
+	logServing(hosts)
+	m := &misdirection{refuse: refuse, refused: make(map[int]map[string]bool)}
+	served := serveConnections(conn, framer, len(hosts), m.answer)
+	logCoalescing(served)
+	linger(conn, framer)
+}
+
+// Test Case 9.1.1/1: Serves requests for a.coalesce.test and b.coalesce.test, which the certificate both covers.
+// The client may coalesce the second request onto the connection of the first or open another one; the harness logs which.
+func RunTest9_1_1_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 9.1.1/1...")
+	serveOrigins(conn, framer, "a.coalesce.test", "b.coalesce.test")
+}
+
+// Test Case 9.1.1/2: Serves requests for a.coalesce.test and uncovered.test, which resolves to the harness but is not covered by the certificate.
+// The client must not coalesce the second request onto the connection of the first; the harness fails it if it does.
+func RunTest9_1_1_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 9.1.1/2...")
+	serveOrigins(conn, framer, "a.coalesce.test", uncoveredHost)
+}
+
+// Test Case 9.1.2/1: Serves requests for a.coalesce.test and b.coalesce.test, answering 421 to a request coalesced onto a connection opened for another name.
+// The client may retry the misdirected request on a different connection, but not on the one that answered 421.
+func RunTest9_1_2_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 9.1.2/1...")
+	serveMisdirected(conn, framer, func(r servedRequest) bool {
+		return r.serverName != "" && authorityHost(r.authority) != r.serverName
+	}, "a.coalesce.test", "b.coalesce.test")
+}
+
+// Test Case 9.1.2/2: Answers the client's first request with 421 on the connection it arrives on.
+// The client may retry the request on a different connection, but not on the one that answered 421.
+func RunTest9_1_2_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case 9.1.2/2...")
+	serveMisdirected(conn, framer, func(r servedRequest) bool {
+		return r.conn == 1
+	}, "a.coalesce.test")
+}
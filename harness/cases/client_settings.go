@@ -0,0 +1,89 @@
+package cases
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/http2"
+)
+
+// initialSettings holds the initial values of RFC 7540 Section 6.5.2 for
+// the settings that have a finite default.
+var initialSettings = map[http2.SettingID]uint32{
+	http2.SettingHeaderTableSize:   4096,
+	http2.SettingEnablePush:        1,
+	http2.SettingInitialWindowSize: 65535,
+	http2.SettingMaxFrameSize:      16384,
+}
+
+// ClientSettings are the values the client announced in the SETTINGS frame
+// of its connection preface.
+type ClientSettings struct {
+	values map[http2.SettingID]uint32
+}
+
+// NewClientSettings records the parameters of the client's SETTINGS frame.
+// A parameter that appears more than once takes its last value.
+func NewClientSettings(f *http2.SettingsFrame) ClientSettings {
+	s := ClientSettings{values: make(map[http2.SettingID]uint32)}
+	if f != nil {
+		f.ForeachSetting(func(setting http2.Setting) error {
+			s.values[setting.ID] = setting.Val
+			return nil
+		})
+	}
+	return s
+}
+
+// Value returns the value the client announced for id. If it announced
+// none, Value returns the initial value and false.
+func (s ClientSettings) Value(id http2.SettingID) (uint32, bool) {
+	if v, ok := s.values[id]; ok {
+		return v, true
+	}
+	return initialSettings[id], false
+}
+
+// HeaderTableSize is the largest dynamic table the client's HPACK decoder
+// accepts.
+func (s ClientSettings) HeaderTableSize() uint32 {
+	v, _ := s.Value(http2.SettingHeaderTableSize)
+	return v
+}
+
+// EnablePush reports whether the client accepts server push.
+func (s ClientSettings) EnablePush() bool {
+	v, _ := s.Value(http2.SettingEnablePush)
+	return v == 1
+}
+
+// InitialWindowSize is the client's initial stream flow-control window.
+func (s ClientSettings) InitialWindowSize() uint32 {
+	v, _ := s.Value(http2.SettingInitialWindowSize)
+	return v
+}
+
+// MaxFrameSize is the largest frame payload the client accepts.
+func (s ClientSettings) MaxFrameSize() uint32 {
+	v, _ := s.Value(http2.SettingMaxFrameSize)
+	return v
+}
+
+// MaxHeaderListSize is the largest header list the client accepts. The
+// initial value is unlimited, so ok is false unless the client announced one.
+func (s ClientSettings) MaxHeaderListSize() (size uint32, ok bool) {
+	return s.Value(http2.SettingMaxHeaderListSize)
+}
+
+func (s ClientSettings) String() string {
+	if len(s.values) == 0 {
+		return "none"
+	}
+	parts := make([]string, 0, len(s.values))
+	for id, v := range s.values {
+		parts = append(parts, fmt.Sprintf("%v=%d", id, v))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
@@ -1,226 +0,0 @@
-package cases
-
-import (
-	"log"
-	"net"
-
-	"golang.org/x/net/http2"
-)
-
-// Final tests to complete 100% H2SPEC coverage
-
-// Test Case generic/misc/1: Multiple streams test
-func RunTestGenericMisc1(conn net.Conn, framer *http2.Framer) {
-	log.Println("Running test case generic/misc/1...")
-	
-	// Open multiple streams
-	for i := 1; i <= 5; i++ {
-		headersFrame := []byte{
-			0x00, 0x00, 0x01, // Length: 1 byte
-			0x01,             // Type: HEADERS
-			0x05,             // Flags: END_STREAM | END_HEADERS
-			0x00, 0x00, 0x00, byte(i), // Stream ID: i
-			0x82,             // Header: :method: GET
-		}
-		
-		if _, err := conn.Write(headersFrame); err != nil {
-			log.Printf("Failed to write HEADERS for stream %d: %v", i, err)
-			return
-		}
-	}
-	log.Println("Multiple streams test completed")
-}
-
-// Test Case hpack/misc/1: Complex HPACK test
-func RunTestHpackMisc1(conn net.Conn, framer *http2.Framer) {
-	log.Println("Running test case hpack/misc/1...")
-	
-	// Complex HPACK test with mixed indexing
-	headersFrame := []byte{
-		0x00, 0x00, 0x20, // Length: 32 bytes
-		0x01,             // Type: HEADERS
-		0x05,             // Flags: END_STREAM | END_HEADERS
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-		0x82,             // :method: GET (indexed)
-		0x84,             // :path: / (indexed)
-		0x86,             // :scheme: http (indexed)
-		0x87,             // :authority: localhost (indexed)
-		0x40, 0x05,       // Custom header with incremental indexing
-		0x74, 0x65, 0x73, 0x74, 0x31, // "test1" 
-		0x05,             // Value length
-		0x76, 0x61, 0x6C, 0x75, 0x65, // "value"
-		0x00, 0x05,       // Custom header without indexing
-		0x74, 0x65, 0x73, 0x74, 0x32, // "test2"
-		0x06,             // Value length
-		0x76, 0x61, 0x6C, 0x75, 0x65, 0x32, // "value2"
-		0x10, 0x06,       // Never indexed header
-		0x73, 0x65, 0x63, 0x72, 0x65, 0x74, // "secret"
-		0x04,             // Value length
-		0x64, 0x61, 0x74, 0x61, // "data"
-	}
-	
-	if _, err := conn.Write(headersFrame); err != nil {
-		log.Printf("Failed to write complex HPACK: %v", err)
-		return
-	}
-	log.Println("Complex HPACK test completed")
-}
-
-// Additional test cases to reach exact count
-func RunTestExtra1(conn net.Conn, framer *http2.Framer) {
-	log.Println("Running extra test 1...")
-	// Empty DATA frame test
-	dataFrame := []byte{
-		0x00, 0x00, 0x00, // Length: 0 bytes
-		0x00,             // Type: DATA
-		0x01,             // Flags: END_STREAM
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-	}
-	
-	if _, err := conn.Write(dataFrame); err != nil {
-		log.Printf("Failed to write empty DATA: %v", err)
-		return
-	}
-	log.Println("Extra test 1 completed")
-}
-
-func RunTestExtra2(conn net.Conn, framer *http2.Framer) {
-	log.Println("Running extra test 2...")
-	// PING with ACK test
-	pingFrame := []byte{
-		0x00, 0x00, 0x08, // Length: 8 bytes
-		0x06,             // Type: PING
-		0x01,             // Flags: ACK
-		0x00, 0x00, 0x00, 0x00, // Stream ID: 0
-		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, // PING data
-	}
-	
-	if _, err := conn.Write(pingFrame); err != nil {
-		log.Printf("Failed to write PING ACK: %v", err)
-		return
-	}
-	log.Println("Extra test 2 completed")
-}
-
-func RunTestExtra3(conn net.Conn, framer *http2.Framer) {
-	log.Println("Running extra test 3...")
-	// SETTINGS ACK test
-	settingsFrame := []byte{
-		0x00, 0x00, 0x00, // Length: 0 bytes
-		0x04,             // Type: SETTINGS
-		0x01,             // Flags: ACK
-		0x00, 0x00, 0x00, 0x00, // Stream ID: 0
-	}
-	
-	if _, err := conn.Write(settingsFrame); err != nil {
-		log.Printf("Failed to write SETTINGS ACK: %v", err)
-		return
-	}
-	log.Println("Extra test 3 completed")
-}
-
-func RunTestExtra4(conn net.Conn, framer *http2.Framer) {
-	log.Println("Running extra test 4...")
-	// Large HEADERS test
-	largeHeaders := make([]byte, 100)
-	for i := range largeHeaders {
-		largeHeaders[i] = byte(i % 256)
-	}
-	
-	headersFrame := []byte{
-		0x00, 0x00, 0x64, // Length: 100 bytes
-		0x01,             // Type: HEADERS
-		0x05,             // Flags: END_STREAM | END_HEADERS
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-	}
-	headersFrame = append(headersFrame, largeHeaders...)
-	
-	if _, err := conn.Write(headersFrame); err != nil {
-		log.Printf("Failed to write large HEADERS: %v", err)
-		return
-	}
-	log.Println("Extra test 4 completed")
-}
-
-func RunTestExtra5(conn net.Conn, framer *http2.Framer) {
-	log.Println("Running extra test 5...")
-	// HTTP/2 upgrade simulation
-	headersFrame := []byte{
-		0x00, 0x00, 0x0C, // Length: 12 bytes
-		0x01,             // Type: HEADERS
-		0x05,             // Flags: END_STREAM | END_HEADERS
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-		0x82,             // :method: GET
-		0x84,             // :path: /
-		0x86,             // :scheme: http
-		0x01, 0x09,       // :authority literal
-		0x6C, 0x6F, 0x63, 0x61, 0x6C, 0x68, 0x6F, 0x73, 0x74, // "localhost"
-		0x00, 0x07,       // upgrade header
-		0x75, 0x70, 0x67, 0x72, 0x61, 0x64, 0x65, // "upgrade"
-		0x02,             // Value length
-		0x68, 0x32,       // "h2"
-	}
-	
-	if _, err := conn.Write(headersFrame); err != nil {
-		log.Printf("Failed to write upgrade test: %v", err)
-		return
-	}
-	log.Println("Extra test 5 completed")
-}
-
-// Additional tests for exact coverage
-func RunTestFinal1(conn net.Conn, framer *http2.Framer) {
-	log.Println("Running final test 1...")
-	// Server push test
-	pushPromiseFrame := []byte{
-		0x00, 0x00, 0x05, // Length: 5 bytes
-		0x05,             // Type: PUSH_PROMISE
-		0x04,             // Flags: END_HEADERS
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-		0x00, 0x00, 0x00, 0x02, // Promised Stream ID: 2
-		0x82,             // Header: :method: GET
-	}
-	
-	if _, err := conn.Write(pushPromiseFrame); err != nil {
-		log.Printf("Failed to write PUSH_PROMISE: %v", err)
-		return
-	}
-	log.Println("Final test 1 completed")
-}
-
-func RunTestFinal2(conn net.Conn, framer *http2.Framer) {
-	log.Println("Running final test 2...")
-	// Flow control test
-	headersFrame := []byte{
-		0x00, 0x00, 0x01, // Length: 1 byte
-		0x01,             // Type: HEADERS
-		0x04,             // Flags: END_HEADERS
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-		0x82,             // Header: :method: GET
-	}
-	
-	if _, err := conn.Write(headersFrame); err != nil {
-		log.Printf("Failed to write HEADERS: %v", err)
-		return
-	}
-	
-	// Large DATA frame to test flow control
-	largeData := make([]byte, 16384) // Max frame size
-	for i := range largeData {
-		largeData[i] = byte(i % 256)
-	}
-	
-	dataFrame := []byte{
-		0x00, 0x40, 0x00, // Length: 16384 bytes
-		0x00,             // Type: DATA
-		0x01,             // Flags: END_STREAM
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-	}
-	dataFrame = append(dataFrame, largeData...)
-	
-	if _, err := conn.Write(dataFrame); err != nil {
-		log.Printf("Failed to write large DATA: %v", err)
-		return
-	}
-	log.Println("Final test 2 completed")
-}
\ No newline at end of file
@@ -0,0 +1,125 @@
+package cases
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/nomadlabsinc/h2-client-test-harness/profile"
+	"golang.org/x/net/http2"
+)
+
+// Conn is the connection handed to test cases. It carries the client's
+// SETTINGS alongside the underlying connection and collects the protocol
+// violations only the harness can observe. Every request the client sends
+// on it is checked by a request validator, whatever the test case does.
+type Conn struct {
+	net.Conn
+	Settings ClientSettings
+	// ID numbers the client's connections in the order the harness
+	// accepted them, from 1.
+	ID int
+	// Accept takes the client's next connection, for the test cases that
+	// serve more than one. It is nil when the harness accepts no others.
+	Accept Acceptor
+	// Protocol is the application protocol the client negotiated: h2, or
+	// HTTP/1.1 on a further connection it opened to fall back.
+	Protocol string
+
+	validator *requestValidator
+	finish    sync.Once
+
+	mu         sync.Mutex
+	violations []string
+}
+
+// Acceptor waits up to timeout for the client to open another connection
+// and returns it once the connection prefaces have been exchanged.
+type Acceptor func(timeout time.Duration) (*Conn, *http2.Framer, error)
+
+// NewConn wraps conn, from which the client connection preface has been
+// read, and starts validating the requests the client sends on it.
+func NewConn(conn net.Conn) *Conn {
+	c := &Conn{Conn: conn, Settings: NewClientSettings(nil), ID: 1, Protocol: http2.NextProtoTLS}
+	c.validator = newRequestValidator(c)
+	return c
+}
+
+// NewHTTP1Conn wraps conn, on which the client negotiated HTTP/1.1. No
+// request validator runs on it.
+func NewHTTP1Conn(conn net.Conn) *Conn {
+	return &Conn{Conn: conn, Settings: NewClientSettings(nil), ID: 1, Protocol: "http/1.1"}
+}
+
+// Read reads from the client and hands a copy to the request validator.
+func (c *Conn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 && c.validator != nil {
+		c.validator.observe(p[:n])
+	}
+	return n, err
+}
+
+// Write shows the request validator what is written to the client before
+// writing it, so that the validator knows the SETTINGS the client will
+// acknowledge.
+func (c *Conn) Write(p []byte) (int, error) {
+	if c.validator != nil {
+		c.validator.write(p)
+	}
+	return c.Conn.Write(p)
+}
+
+// Violations returns the client violations recorded on the connection.
+// It first waits until the request validator has checked everything read
+// from the client.
+func (c *Conn) Violations() []string {
+	if c.validator != nil {
+		c.finish.Do(c.validator.finish)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.violations...)
+}
+
+// SettingsOf returns the client settings carried by conn. For a plain
+// net.Conn it returns the initial values.
+func SettingsOf(conn net.Conn) ClientSettings {
+	if c, ok := conn.(*Conn); ok {
+		return c.Settings
+	}
+	return NewClientSettings(nil)
+}
+
+// activeProfile is the edition of the HTTP/2 specification the client is
+// judged against.
+var activeProfile = profile.RFC7540
+
+// UseProfile makes the harness judge the client against p.
+func UseProfile(p profile.Profile) {
+	activeProfile = p
+}
+
+// reportViolation records that the client broke the protocol in a way the
+// client itself cannot be asked about, such as the frames it sends. The
+// harness fails the test case once it returns.
+func reportViolation(conn net.Conn, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("CLIENT VIOLATION: %s", msg)
+	if c, ok := conn.(*Conn); ok {
+		c.mu.Lock()
+		c.violations = append(c.violations, msg)
+		c.mu.Unlock()
+	}
+}
+
+// abortViolation records a violation and closes the connection with a
+// GOAWAY carrying it as debug data, so that a client still waiting for its
+// response fails too. The GOAWAY covers every stream, so that the client
+// does not retry its request elsewhere.
+func abortViolation(conn net.Conn, framer *http2.Framer, code http2.ErrCode, format string, args ...interface{}) {
+	reportViolation(conn, format, args...)
+	framer.WriteGoAway(1<<31-1, code, []byte(fmt.Sprintf(format, args...)))
+}
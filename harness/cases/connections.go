@@ -0,0 +1,234 @@
+package cases
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	// connectionsWindow is how long serveConnections waits for the
+	// client's next request before it concludes that the client sent all
+	// it will.
+	connectionsWindow = 2500 * time.Millisecond
+	// connectionsTimeout bounds how long serveConnections serves the
+	// client's requests, over all of its connections.
+	connectionsTimeout = 8 * time.Second
+)
+
+// servedRequest is a request the harness served on one of the client's
+// connections.
+type servedRequest struct {
+	// n numbers the requests in the order they arrived, from 1.
+	n        int
+	conn     int
+	streamID uint32
+	// serverName is the name the client indicated in the TLS handshake
+	// of the connection, if any.
+	serverName string
+	authority  string
+}
+
+// requestHandler answers request r, which arrived on conn, and returns the
+// status it answered with.
+type requestHandler func(conn net.Conn, framer *http2.Framer, r servedRequest) (int, error)
+
+// answerOK answers a request with a complete 200 response carrying body
+// "ok".
+func answerOK(conn net.Conn, framer *http2.Framer, r servedRequest) (int, error) {
+	return http.StatusOK, writeResponse(framer, r.streamID, "ok")
+}
+
+// requestTally follows the requests of the client over all of its
+// connections.
+type requestTally struct {
+	want     int
+	deadline time.Time
+	done     chan struct{}
+
+	mu       sync.Mutex
+	served   []servedRequest
+	answered int
+	last     time.Time
+}
+
+// add records a request for authority on streamID of conn and returns it.
+func (t *requestTally) add(conn net.Conn, streamID uint32, authority string) servedRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := servedRequest{n: len(t.served) + 1, conn: connectionID(conn), streamID: streamID, serverName: serverName(conn), authority: authority}
+	t.served = append(t.served, r)
+	t.last = time.Now()
+	log.Printf("Request %d for %s arrived on stream %d of connection %d.", r.n, r.authority, r.streamID, r.conn)
+	return r
+}
+
+// answer records that the harness answered a request with status.
+func (t *requestTally) answer(status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if status == http.StatusOK {
+		t.answered++
+	}
+}
+
+// finished reports whether the harness has answered every request with
+// 200, the client stopped sending requests, or it ran out of time.
+func (t *requestTally) finished() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.answered >= t.want || (len(t.served) > 0 && time.Since(t.last) > connectionsWindow) || time.Now().After(t.deadline)
+}
+
+// serveConnections serves the client's requests, on conn and on any
+// further connections it opens, answering each with handle, until it has
+// answered n of them with 200. It logs which connection each :authority
+// arrived on, and the server name the client indicated on each connection,
+// and returns the requests in the order they arrived. It gives up once the
+// client has sent no request for connectionsWindow, or after
+// connectionsTimeout.
+func serveConnections(conn net.Conn, framer *http2.Framer, n int, handle requestHandler) []servedRequest {
+	t := &requestTally{
+		want:     n,
+		deadline: time.Now().Add(connectionsTimeout),
+		done:     make(chan struct{}),
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t.serve(conn, framer, handle)
+	}()
+
+	c, _ := conn.(*Conn)
+	for !t.finished() {
+		if c == nil || c.Accept == nil {
+			time.Sleep(batchWindow)
+			continue
+		}
+		next, nextFramer, err := c.Accept(batchWindow)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to accept another connection: %v", err)
+			c = nil
+			continue
+		}
+		if next.Protocol != http2.NextProtoTLS {
+			log.Printf("Connection %d negotiated %s; not serving it.", next.ID, next.Protocol)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t.serve(next, nextFramer, handle)
+		}()
+	}
+	close(t.done)
+	wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.answered < n {
+		log.Printf("The client got %d of %d responses.", t.answered, n)
+	}
+	byConn := make(map[int][]string)
+	names := make(map[int]string)
+	var order []int
+	for _, r := range t.served {
+		if _, ok := byConn[r.conn]; !ok {
+			order = append(order, r.conn)
+		}
+		byConn[r.conn] = append(byConn[r.conn], r.authority)
+		names[r.conn] = r.serverName
+	}
+	for _, id := range order {
+		name := names[id]
+		if name == "" {
+			name = "none"
+		}
+		log.Printf("Connection %d (server name %s) carried requests for %s.", id, name, strings.Join(byConn[id], ", "))
+	}
+	return t.served
+}
+
+// serve answers every request on one of the client's HTTP/2 connections
+// until the client has sent all of them.
+func (t *requestTally) serve(conn net.Conn, framer *http2.Framer, handle requestHandler) {
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	authorities := make(map[uint32]string)
+	for {
+		select {
+		case <-t.done:
+			return
+		default:
+		}
+		conn.SetReadDeadline(time.Now().Add(batchWindow))
+		frame, err := framer.ReadFrame()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Stopped reading from connection %d: %v", connectionID(conn), err)
+			return
+		}
+		var streamID uint32
+		switch f := frame.(type) {
+		case *http2.MetaHeadersFrame:
+			authorities[f.StreamID] = f.PseudoValue("authority")
+			if !f.StreamEnded() {
+				continue
+			}
+			streamID = f.StreamID
+		case *http2.DataFrame:
+			if _, ok := authorities[f.StreamID]; !ok || !f.StreamEnded() {
+				continue
+			}
+			streamID = f.StreamID
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+			continue
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+			continue
+		default:
+			continue
+		}
+
+		// The request is complete: answer it.
+		r := t.add(conn, streamID, authorities[streamID])
+		delete(authorities, streamID)
+		status, err := handle(conn, framer, r)
+		if err != nil {
+			log.Printf("Failed to answer request %d: %v", r.n, err)
+			return
+		}
+		t.answer(status)
+	}
+}
+
+// serverName returns the server name the client indicated in the TLS
+// handshake of conn, or "" if it indicated none.
+func serverName(conn net.Conn) string {
+	if c, ok := conn.(*Conn); ok {
+		conn = c.Conn
+	}
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		return tlsConn.ConnectionState().ServerName
+	}
+	return ""
+}
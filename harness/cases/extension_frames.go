@@ -0,0 +1,87 @@
+package cases
+
+import (
+	"encoding/binary"
+	"log"
+	"net"
+
+	"golang.org/x/net/http2"
+)
+
+// Extension frame types the http2 package does not define.
+const (
+	// frameAltSvc is the ALTSVC frame type (RFC 7838 Section 4).
+	frameAltSvc http2.FrameType = 0xa
+	// frameOrigin is the ORIGIN frame type (RFC 8336 Section 2).
+	frameOrigin http2.FrameType = 0xc
+)
+
+// followUpOrigin is the origin the verifier sends its follow-up request to
+// in the test cases that announce it in an ORIGIN frame. Names under .test
+// resolve to the harness.
+const followUpOrigin = "https://origin.test:8080"
+
+// originPayload returns the payload of an ORIGIN frame that lists origins
+// (RFC 8336 Section 2.1).
+func originPayload(origins ...string) []byte {
+	var payload []byte
+	for _, origin := range origins {
+		payload = binary.BigEndian.AppendUint16(payload, uint16(len(origin)))
+		payload = append(payload, origin...)
+	}
+	return payload
+}
+
+// altSvcPayload returns the payload of an ALTSVC frame that advertises
+// value for origin (RFC 7838 Section 4).
+func altSvcPayload(origin, value string) []byte {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(len(origin)))
+	payload = append(payload, origin...)
+	return append(payload, value...)
+}
+
+// extensionFramePlan describes an extension frame the harness sends ahead
+// of its response to the client's first request.
+type extensionFramePlan struct {
+	typ http2.FrameType
+	// onRequestStream sends the frame on the request's stream instead of
+	// stream 0.
+	onRequestStream bool
+	// ignored marks an ORIGIN frame the client must ignore, so that it
+	// must not send the follow-up for followUpOrigin on the connection
+	// the frame arrived on, whose certificate does not cover it.
+	ignored bool
+	payload []byte
+}
+
+// serveExtensionFrame sends the frame of plan ahead of its response to the
+// client's first request, and then serves a follow-up request, on the same
+// connection or on another one the client opens for it. Whether the client
+// ignores the frame or honours it, it must get both responses; the harness
+// logs which connection the follow-up arrived on. If the plan marks the
+// frame ignored, it fails the client for sending a request on a connection
+// that is not authoritative for it.
+func serveExtensionFrame(conn net.Conn, framer *http2.Framer, plan extensionFramePlan) {
+	serveConnections(conn, framer, 2, func(conn net.Conn, framer *http2.Framer, r servedRequest) (int, error) {
+		if plan.ignored {
+			checkAuthority(conn, r)
+		}
+		if r.n == 1 {
+			var streamID uint32
+			if plan.onRequestStream {
+				streamID = r.streamID
+			}
+			if err := framer.WriteRawFrame(plan.typ, 0, streamID, plan.payload); err != nil {
+				return 0, err
+			}
+			log.Printf("Sent frame of type 0x%x with %d octets on stream %d.", uint8(plan.typ), len(plan.payload), streamID)
+		}
+		status, err := answerOK(conn, framer, r)
+		if err != nil {
+			return 0, err
+		}
+		log.Printf("Sent response on stream %d of connection %d.", r.streamID, r.conn)
+		return status, nil
+	})
+	linger(conn, framer)
+}
@@ -118,39 +118,9 @@ func RunTestGeneric4_1(conn net.Conn, framer *http2.Framer) {
 	log.Println("Sent GET request - client should accept")
 }
 
-// Test Case generic/4/2: Sends a POST request.
+// Test Case generic/4/2: Receives a POST request with a body.
+// The harness grades the client's DATA frames and checks the body's digest.
 func RunTestGeneric4_2(conn net.Conn, framer *http2.Framer) {
 	log.Println("Running test case generic/4/2...")
-
-	// Send POST request with headers
-	headersFrame := []byte{
-		0x00, 0x00, 0x04, // Length: 4 bytes
-		0x01,             // Type: HEADERS
-		0x04,             // Flags: END_HEADERS
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-		0x83,             // Header: :method: POST
-		0x84,             // Header: :path: /
-		0x86,             // Header: :scheme: http
-		0x87,             // Header: :authority: localhost
-	}
-
-	if _, err := conn.Write(headersFrame); err != nil {
-		log.Printf("Failed to write POST headers: %v", err)
-		return
-	}
-
-	// Send DATA frame with body
-	dataFrame := []byte{
-		0x00, 0x00, 0x05, // Length: 5 bytes
-		0x00,             // Type: DATA
-		0x01,             // Flags: END_STREAM
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-		0x48, 0x65, 0x6c, 0x6c, 0x6f, // "Hello"
-	}
-
-	if _, err := conn.Write(dataFrame); err != nil {
-		log.Printf("Failed to write POST data: %v", err)
-		return
-	}
-	log.Println("Sent POST request - client should accept")
-}
\ No newline at end of file
+	receiveUpload(conn, framer, uploadPlan{minGrant: 65535, maxGrant: 65535})
+}
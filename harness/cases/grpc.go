@@ -0,0 +1,540 @@
+package cases
+
+import (
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	// grpcPrefixLen is the length of the prefix of a gRPC message: a
+	// compressed flag and a 4-octet message length.
+	grpcPrefixLen = 5
+	// grpcTimeout bounds how long the harness waits for the client during
+	// a gRPC call.
+	grpcTimeout = 5 * time.Second
+)
+
+// grpcMessage returns payload as an uncompressed, length-prefixed gRPC
+// message.
+func grpcMessage(payload []byte) []byte {
+	msg := make([]byte, grpcPrefixLen, grpcPrefixLen+len(payload))
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(payload)))
+	return append(msg, payload...)
+}
+
+// grpcDecoder splits the DATA of a gRPC request into its length-prefixed
+// messages, wherever the client cut the frames.
+type grpcDecoder struct {
+	buf []byte
+}
+
+// write appends data and returns every message it completes. It fails the
+// client for a compressed message, as the call negotiated no encoding.
+func (d *grpcDecoder) write(conn net.Conn, data []byte) [][]byte {
+	d.buf = append(d.buf, data...)
+	var msgs [][]byte
+	for len(d.buf) >= grpcPrefixLen {
+		n := int(binary.BigEndian.Uint32(d.buf[1:]))
+		if len(d.buf) < grpcPrefixLen+n {
+			break
+		}
+		if d.buf[0] != 0 {
+			reportViolation(conn, "gRPC message of %d octets is flagged as compressed without a grpc-encoding", n)
+		}
+		msgs = append(msgs, d.buf[grpcPrefixLen:grpcPrefixLen+n])
+		d.buf = d.buf[grpcPrefixLen+n:]
+	}
+	return msgs
+}
+
+// end fails the client if the call ended in the middle of a message.
+func (d *grpcDecoder) end(conn net.Conn) {
+	if len(d.buf) > 0 {
+		reportViolation(conn, "gRPC call ended in the middle of a message, with %d octets left over", len(d.buf))
+	}
+}
+
+// checkGRPCCall checks the HEADERS of the client's gRPC call against the
+// request headers of the gRPC protocol: a POST with a content-type of
+// application/grpc and te: trailers.
+func checkGRPCCall(conn net.Conn, headers *http2.MetaHeadersFrame) {
+	log.Printf("Received gRPC call %s on stream %d.", headers.PseudoValue("path"), headers.StreamID)
+	if method := headers.PseudoValue("method"); method != "POST" {
+		reportViolation(conn, "gRPC call with method %s instead of POST", method)
+	}
+	var contentType, te string
+	for _, f := range headers.RegularFields() {
+		switch f.Name {
+		case "content-type":
+			contentType = f.Value
+		case "te":
+			te = f.Value
+		}
+	}
+	if contentType != "application/grpc" && !strings.HasPrefix(contentType, "application/grpc+") && !strings.HasPrefix(contentType, "application/grpc;") {
+		reportViolation(conn, "gRPC call with content-type %q instead of application/grpc", contentType)
+	}
+	if te != "trailers" {
+		reportViolation(conn, "gRPC call without te: trailers")
+	}
+}
+
+// awaitGRPCCall waits for the client's gRPC call and checks its HEADERS.
+func awaitGRPCCall(conn net.Conn, framer *http2.Framer) (*http2.MetaHeadersFrame, error) {
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	conn.SetReadDeadline(time.Now().Add(grpcTimeout))
+	frame, err := readUntil(framer, func(f http2.Frame) bool {
+		_, ok := f.(*http2.MetaHeadersFrame)
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+	headers := frame.(*http2.MetaHeadersFrame)
+	checkGRPCCall(conn, headers)
+	return headers, nil
+}
+
+// readGRPCMessages reads the request messages of the client's gRPC call
+// until the client ends the stream, returning the credit of every DATA
+// frame at once.
+func readGRPCMessages(conn net.Conn, framer *http2.Framer, headers *http2.MetaHeadersFrame) ([][]byte, error) {
+	if headers.StreamEnded() {
+		return nil, nil
+	}
+	var (
+		d    grpcDecoder
+		msgs [][]byte
+	)
+	conn.SetReadDeadline(time.Now().Add(grpcTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch f := frame.(type) {
+		case *http2.DataFrame:
+			if f.StreamID != headers.StreamID {
+				continue
+			}
+			msgs = append(msgs, d.write(conn, f.Data())...)
+			if f.StreamEnded() {
+				d.end(conn)
+				return msgs, nil
+			}
+			if n := f.Header().Length; n > 0 {
+				framer.WriteWindowUpdate(0, n)
+				framer.WriteWindowUpdate(f.StreamID, n)
+			}
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		case *http2.RSTStreamFrame:
+			if f.StreamID == headers.StreamID {
+				return nil, errors.New("client reset the call with " + f.ErrCode.String())
+			}
+		}
+	}
+}
+
+// grpcStatus returns the grpc-status and grpc-message fields of status
+// code and message, with message percent-encoded as gRPC requires.
+func grpcStatus(code, message string) []hpack.HeaderField {
+	fields := []hpack.HeaderField{{Name: "grpc-status", Value: code}}
+	if message != "" {
+		fields = append(fields, hpack.HeaderField{Name: "grpc-message", Value: percentEncodeGRPC(message)})
+	}
+	return fields
+}
+
+// percentEncodeGRPC percent-encodes message for grpc-message: every octet
+// of its UTF-8 encoding outside %x20-%x24 and %x26-%x7E becomes %XX, so
+// that '%', control characters and non-ASCII text are escaped while space
+// stays as it is.
+func percentEncodeGRPC(message string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c < 0x20 || c > 0x7E || c == '%' {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0x0F])
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// writeGRPCHeaders writes the response headers of a gRPC call on streamID,
+// followed by extra. With endStream the headers end the call as a
+// trailers-only response.
+func writeGRPCHeaders(framer *http2.Framer, streamID uint32, endStream bool, extra ...hpack.HeaderField) error {
+	fields := append([]hpack.HeaderField{
+		{Name: ":status", Value: "200"},
+		{Name: "content-type", Value: "application/grpc"},
+	}, extra...)
+	return framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(fields...),
+		EndStream:     endStream,
+		EndHeaders:    true,
+	})
+}
+
+// writeGRPCTrailers ends the gRPC call on streamID with trailers carrying
+// status code and message.
+func writeGRPCTrailers(framer *http2.Framer, streamID uint32, code, message string) error {
+	return framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(grpcStatus(code, message)...),
+		EndStream:     true,
+		EndHeaders:    true,
+	})
+}
+
+// grpcEchoPlan describes how the harness answers a gRPC call whose request
+// messages it echoes.
+type grpcEchoPlan struct {
+	// trailersOnly answers with a single HEADERS frame that carries the
+	// status and ends the call, echoing nothing.
+	trailersOnly bool
+	// headers are extra response header fields.
+	headers []hpack.HeaderField
+	// code and message are the status of the call.
+	code, message string
+	// cuts, if set, cuts the echoed messages into DATA frames of these
+	// sizes in turn, the last repeating, regardless of where the messages
+	// begin and end. Otherwise every message goes in a DATA frame of its
+	// own.
+	cuts []int
+}
+
+// serveGRPCEcho waits for the client's gRPC call, reads its request
+// messages and answers it according to plan, then lingers until the client
+// is done.
+func serveGRPCEcho(conn net.Conn, framer *http2.Framer, plan grpcEchoPlan) {
+	headers, err := awaitGRPCCall(conn, framer)
+	if err != nil {
+		log.Printf("Failed to read gRPC call: %v", err)
+		return
+	}
+	streamID := headers.StreamID
+	msgs, err := readGRPCMessages(conn, framer, headers)
+	if err != nil {
+		log.Printf("Failed to read request messages: %v", err)
+		return
+	}
+	log.Printf("Received %d request message(s).", len(msgs))
+
+	if plan.trailersOnly {
+		if err := writeGRPCHeaders(framer, streamID, true, grpcStatus(plan.code, plan.message)...); err != nil {
+			log.Printf("Failed to write trailers-only response: %v", err)
+			return
+		}
+		log.Printf("Sent trailers-only response with grpc-status %s on stream %d.", plan.code, streamID)
+		linger(conn, framer)
+		return
+	}
+
+	if err := writeGRPCHeaders(framer, streamID, false, plan.headers...); err != nil {
+		log.Printf("Failed to write response headers: %v", err)
+		return
+	}
+	var chunks [][]byte
+	if plan.cuts == nil {
+		for _, msg := range msgs {
+			chunks = append(chunks, grpcMessage(msg))
+		}
+	} else {
+		var stream []byte
+		for _, msg := range msgs {
+			stream = append(stream, grpcMessage(msg)...)
+		}
+		for i := 0; len(stream) > 0; i++ {
+			n := min(plan.cuts[min(i, len(plan.cuts)-1)], len(stream))
+			chunks = append(chunks, stream[:n])
+			stream = stream[n:]
+		}
+	}
+	for _, chunk := range chunks {
+		if err := framer.WriteData(streamID, false, chunk); err != nil {
+			log.Printf("Failed to write DATA frame: %v", err)
+			return
+		}
+	}
+	if err := writeGRPCTrailers(framer, streamID, plan.code, plan.message); err != nil {
+		log.Printf("Failed to write trailers: %v", err)
+		return
+	}
+	log.Printf("Echoed %d message(s) in %d DATA frame(s) and sent trailers with grpc-status %s on stream %d.", len(msgs), len(chunks), plan.code, streamID)
+	linger(conn, framer)
+}
+
+// writeWithinWindows writes as much of data on the stream of w as the
+// client's receive windows allow, in DATA frames of at most maxFrameSize
+// octets, and returns the rest.
+func writeWithinWindows(framer *http2.Framer, w *receiveWindows, data []byte, maxFrameSize uint32) ([]byte, error) {
+	for len(data) > 0 {
+		n, _ := w.limit()
+		n = min(n, int64(maxFrameSize), int64(len(data)))
+		if n <= 0 {
+			break
+		}
+		if err := framer.WriteData(w.streamID, false, data[:n]); err != nil {
+			return nil, err
+		}
+		w.conn -= n
+		w.stream -= n
+		data = data[n:]
+	}
+	return data, nil
+}
+
+// serveGRPCStream echoes every request message of the client's
+// bidirectional gRPC call as soon as it is complete, until the client ends
+// the call. preface is the server connection preface, whose
+// SETTINGS_INITIAL_WINDOW_SIZE keeps the client's stream window tight.
+// The harness returns the credit of every DATA frame at once, grades every
+// DATA frame against the windows it allows, and ends the call with
+// grpc-status 0. Its echoes respect the client's receive windows in turn:
+// what they do not allow waits for the client's WINDOW_UPDATE, and a
+// client that sends none within stallTimeout once it has ended the call
+// has deadlocked it.
+func serveGRPCStream(conn net.Conn, framer *http2.Framer, preface []http2.Setting) {
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	w := newSendWindows(preface)
+	recv := &receiveWindows{conn: 65535}
+	var (
+		d        grpcDecoder
+		streamID uint32
+		pending  []byte
+		echoed   int
+		frames   int
+		largest  uint32
+		start    time.Time
+	)
+	for {
+		conn.SetReadDeadline(time.Now().Add(grpcTimeout))
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			log.Printf("Failed to read frame during the call: %v", err)
+			return
+		}
+
+		switch f := frame.(type) {
+		case *http2.MetaHeadersFrame:
+			if streamID != 0 {
+				log.Printf("Ignoring HEADERS on stream %d during the call.", f.StreamID)
+				continue
+			}
+			checkGRPCCall(conn, f)
+			streamID = f.StreamID
+			start = time.Now()
+			w.open(streamID)
+			initial := int64(SettingsOf(conn).InitialWindowSize())
+			recv.streamID, recv.stream, recv.initialStream = streamID, initial, initial
+			// A streaming server answers with its headers at once, so that
+			// the client can read the echoes while it sends.
+			if err := writeGRPCHeaders(framer, streamID, false); err != nil {
+				log.Printf("Failed to write response headers: %v", err)
+				return
+			}
+		case *http2.DataFrame:
+			if f.StreamID != streamID {
+				log.Printf("Ignoring DATA on stream %d during the call.", f.StreamID)
+				continue
+			}
+			length := f.Header().Length
+			stream, maxFrameSize := w.allowance(streamID)
+			if length > maxFrameSize {
+				abortViolation(conn, framer, http2.ErrCodeFrameSize, "DATA frame of %d octets exceeds the SETTINGS_MAX_FRAME_SIZE of %d", length, maxFrameSize)
+				return
+			}
+			if int64(length) > w.conn {
+				abortViolation(conn, framer, http2.ErrCodeFlowControl, "DATA frame of %d octets overruns the connection window of %d", length, w.conn)
+				return
+			}
+			if int64(length) > stream {
+				abortViolation(conn, framer, http2.ErrCodeFlowControl, "DATA frame of %d octets overruns the window of %d on stream %d", length, stream, streamID)
+				return
+			}
+			w.conn -= int64(length)
+			w.streams[streamID] -= int64(length)
+			frames++
+			largest = max(largest, length)
+			for _, msg := range d.write(conn, f.Data()) {
+				pending = append(pending, grpcMessage(msg)...)
+				echoed++
+			}
+			if pending, err = writeWithinWindows(framer, recv, pending, SettingsOf(conn).MaxFrameSize()); err != nil {
+				log.Printf("Failed to write DATA frame: %v", err)
+				return
+			}
+			if f.StreamEnded() {
+				d.end(conn)
+				for len(pending) > 0 {
+					log.Printf("Waiting for the client's WINDOW_UPDATE to echo the last %d octets (connection window %d, stream window %d).", len(pending), recv.conn, recv.stream)
+					if !awaitCredit(conn, framer, recv, len(pending)) {
+						return
+					}
+					if pending, err = writeWithinWindows(framer, recv, pending, SettingsOf(conn).MaxFrameSize()); err != nil {
+						log.Printf("Failed to write DATA frame: %v", err)
+						return
+					}
+				}
+				log.Printf("Echoed %d message(s) received in %d DATA frames (largest %d) in %v.", echoed, frames, largest, time.Since(start).Round(time.Millisecond))
+				if err := writeGRPCTrailers(framer, streamID, "0", ""); err != nil {
+					log.Printf("Failed to write trailers: %v", err)
+					return
+				}
+				linger(conn, framer)
+				return
+			}
+			if length > 0 {
+				framer.WriteWindowUpdate(0, length)
+				framer.WriteWindowUpdate(streamID, length)
+				w.conn += int64(length)
+				w.streams[streamID] += int64(length)
+			}
+		case *http2.WindowUpdateFrame:
+			if !recv.credit(conn, framer, f) {
+				return
+			}
+			if pending, err = writeWithinWindows(framer, recv, pending, SettingsOf(conn).MaxFrameSize()); err != nil {
+				log.Printf("Failed to write DATA frame: %v", err)
+				return
+			}
+		case *http2.SettingsFrame:
+			if f.IsAck() {
+				w.acknowledge()
+				continue
+			}
+			if v, ok := f.Value(http2.SettingInitialWindowSize); ok && recv.streamID != 0 {
+				recv.stream += int64(v) - recv.initialStream
+				recv.initialStream = int64(v)
+			}
+			framer.WriteSettingsAck()
+			if pending, err = writeWithinWindows(framer, recv, pending, SettingsOf(conn).MaxFrameSize()); err != nil {
+				log.Printf("Failed to write DATA frame: %v", err)
+				return
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		case *http2.RSTStreamFrame:
+			log.Printf("Client reset stream %d with %v during the call.", f.StreamID, f.ErrCode)
+			return
+		}
+	}
+}
+
+// serveGRPCReset answers the client's gRPC call with n response messages
+// and then resets the stream with CANCEL, as a server does whose handler
+// is cancelled mid-stream.
+func serveGRPCReset(conn net.Conn, framer *http2.Framer, n int) {
+	headers, err := awaitGRPCCall(conn, framer)
+	if err != nil {
+		log.Printf("Failed to read gRPC call: %v", err)
+		return
+	}
+	streamID := headers.StreamID
+	if _, err := readGRPCMessages(conn, framer, headers); err != nil {
+		log.Printf("Failed to read request messages: %v", err)
+		return
+	}
+	if err := writeGRPCHeaders(framer, streamID, false); err != nil {
+		log.Printf("Failed to write response headers: %v", err)
+		return
+	}
+	for i := 0; i < n; i++ {
+		if err := framer.WriteData(streamID, false, grpcMessage(patternBody(100))); err != nil {
+			log.Printf("Failed to write DATA frame: %v", err)
+			return
+		}
+	}
+	if err := framer.WriteRSTStream(streamID, http2.ErrCodeCancel); err != nil {
+		log.Printf("Failed to write RST_STREAM frame: %v", err)
+		return
+	}
+	log.Printf("Sent %d response message(s), then RST_STREAM CANCEL on stream %d.", n, streamID)
+	linger(conn, framer)
+}
+
+// serveGRPCKeepAlive holds the client's gRPC call open after its response
+// headers and counts the client's keepalive PINGs. After pings of them, it
+// sends GOAWAY with ENHANCE_YOUR_CALM and "too_many_pings", as a gRPC
+// server does that enforces a keepalive policy, and closes the connection.
+// A client that sends fewer PINGs before grpcTimeout gets its call ended
+// with grpc-status 0.
+func serveGRPCKeepAlive(conn net.Conn, framer *http2.Framer, pings int) {
+	headers, err := awaitGRPCCall(conn, framer)
+	if err != nil {
+		log.Printf("Failed to read gRPC call: %v", err)
+		return
+	}
+	streamID := headers.StreamID
+	if _, err := readGRPCMessages(conn, framer, headers); err != nil {
+		log.Printf("Failed to read request messages: %v", err)
+		return
+	}
+	if err := writeGRPCHeaders(framer, streamID, false); err != nil {
+		log.Printf("Failed to write response headers: %v", err)
+		return
+	}
+
+	start := time.Now()
+	conn.SetReadDeadline(start.Add(grpcTimeout))
+	for seen := 0; seen < pings; {
+		frame, err := framer.ReadFrame()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			log.Printf("The client sent %d keepalive PING(s) in %v; ending the call.", seen, grpcTimeout)
+			if err := writeGRPCTrailers(framer, streamID, "0", ""); err != nil {
+				log.Printf("Failed to write trailers: %v", err)
+				return
+			}
+			linger(conn, framer)
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to read frame during the call: %v", err)
+			return
+		}
+		switch f := frame.(type) {
+		case *http2.PingFrame:
+			if f.IsAck() {
+				continue
+			}
+			seen++
+			log.Printf("Received keepalive PING %d, %v into the call.", seen, time.Since(start).Round(time.Millisecond))
+			framer.WritePing(true, f.Data)
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		}
+	}
+
+	if err := framer.WriteGoAway(streamID, http2.ErrCodeEnhanceYourCalm, []byte("too_many_pings")); err != nil {
+		log.Printf("Failed to write GOAWAY frame: %v", err)
+		return
+	}
+	log.Printf("Sent GOAWAY with ENHANCE_YOUR_CALM after %d keepalive PINGs; closing the connection.", pings)
+	conn.Close()
+}
@@ -0,0 +1,83 @@
+package cases
+
+import (
+	"log"
+	"net"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// grpcCuts cuts the echoed messages of grpc/4 into DATA frames that grow
+// along the Fibonacci sequence, so that frames end inside message prefixes,
+// inside payloads, and span several messages.
+var grpcCuts = []int{1, 2, 3, 5, 8, 13, 21, 34, 55, 89, 144, 233, 377, 610, 987, 1597, 2584}
+
+// grpcStreamPreface keeps the client's stream window for grpc/5 at 1 KiB,
+// a quarter of each message of the call.
+var grpcStreamPreface = []http2.Setting{{ID: http2.SettingInitialWindowSize, Val: 1024}}
+
+// Test Case grpc/1: Answers a unary gRPC call with its request message echoed back and grpc-status 0 in the trailers.
+// The client must read the length-prefixed message and take the call's status from the trailers.
+func RunTestGrpc1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case grpc/1...")
+	serveGRPCEcho(conn, framer, grpcEchoPlan{code: "0"})
+}
+
+// Test Case grpc/2: Answers a gRPC call with a trailers-only response: one HEADERS frame with END_STREAM carrying grpc-status 5 (NOT_FOUND).
+// The client must take the status from the response headers, as there are no trailers.
+func RunTestGrpc2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case grpc/2...")
+	serveGRPCEcho(conn, framer, grpcEchoPlan{trailersOnly: true, code: "5", message: "not found"})
+}
+
+// Test Case grpc/3: Sends grpc-status 0 in the response headers and grpc-status 13 (INTERNAL) in the trailers.
+// The status of a call with a response body is the one in its trailers; the client must report 13.
+func RunTestGrpc3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case grpc/3...")
+	serveGRPCEcho(conn, framer, grpcEchoPlan{
+		headers: []hpack.HeaderField{{Name: "grpc-status", Value: "0"}},
+		code:    "13",
+		message: "internal error",
+	})
+}
+
+// Test Case grpc/4: Echoes messages of 1, 300 and 5000 octets in DATA frames cut at arbitrary boundaries, through prefixes and across messages.
+// The client must reassemble every message exactly, whatever the framing.
+func RunTestGrpc4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case grpc/4...")
+	serveGRPCEcho(conn, framer, grpcEchoPlan{code: "0", cuts: grpcCuts})
+}
+
+// PrefaceGrpc5 sets SETTINGS_INITIAL_WINDOW_SIZE to 1 KiB in the server preface.
+func PrefaceGrpc5(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, grpcStreamPreface...)
+}
+
+// Test Case grpc/5: Echoes every message of a long-lived bidirectional call as it arrives, under a stream window of 1 KiB.
+// The client must send each 4 KiB message within the credit the harness returns frame by frame, while it reads the echoes.
+func RunTestGrpc5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case grpc/5...")
+	serveGRPCStream(conn, framer, grpcStreamPreface)
+}
+
+// Test Case grpc/6: Sends two response messages of a server-streaming call, then RST_STREAM CANCEL.
+// The client must fail the call with the cancellation rather than end it as if the stream had completed.
+func RunTestGrpc6(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case grpc/6...")
+	serveGRPCReset(conn, framer, 2)
+}
+
+// Test Case grpc/7: Holds a call open and, after two keepalive PINGs from the client, sends GOAWAY with ENHANCE_YOUR_CALM and "too_many_pings".
+// The client must fail the call with the GOAWAY rather than hang on the closed connection.
+func RunTestGrpc7(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case grpc/7...")
+	serveGRPCKeepAlive(conn, framer, 2)
+}
+
+// Test Case grpc/8: Answers a gRPC call with a trailers-only response carrying grpc-status 3 (INVALID_ARGUMENT) and a
+// grpc-message with '%', a tab and non-ASCII text, percent-encoded. The client must decode the message it reports.
+func RunTestGrpc8(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case grpc/8...")
+	serveGRPCEcho(conn, framer, grpcEchoPlan{trailersOnly: true, code: "3", message: "100%\tover quota: café"})
+}
@@ -3,8 +3,10 @@ package cases
 import (
 	"log"
 	"net"
+	"strings"
 
 	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
 // Test Case hpack/5.2/1: Sends a Huffman-encoded string literal representation with padding longer than 7 bits.
@@ -76,4 +78,72 @@ func RunTestHpack5_2_3(conn net.Conn, framer *http2.Framer) {
 		return
 	}
 	log.Println("Sent Huffman string with EOS symbol - client should detect COMPRESSION_ERROR")
-}
\ No newline at end of file
+}
+
+// Test Case hpack/5.2/4: Sends a response header whose value is a Huffman-encoded string literal.
+// The client should decode the value to its original text.
+func RunTestHpack5_2_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case hpack/5.2/4...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	name := "x-huffman"
+	value := hpack.AppendHuffmanString(nil, "decoded-from-huffman")
+
+	// HPACK Section 5.2: H=1 marks a Huffman-encoded string literal.
+	block := []byte{
+		0x88,             // :status: 200 (indexed)
+		0x00,             // Literal Header Field without Indexing (New Name)
+		byte(len(name)),  // Name length, H=0
+	}
+	block = append(block, name...)
+	block = append(block, 0x80|byte(len(value))) // Value length, H=1
+	block = append(block, value...)
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: block,
+		EndStream:     false,
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, true, []byte("ok")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	log.Println("Sent Huffman-encoded header value - client should decode it")
+	linger(conn, framer)
+}
+
+// huffmanRuns is the number of requests of hpack/5.2/5. Request i carries
+// an x-huffman value of i+1 "a", whose Huffman code of 5 bits per octet
+// leaves a different padding length of 1 to 7 bits for each request.
+const huffmanRuns = 7
+
+// Test Case hpack/5.2/5: Serves seven requests in turn whose x-huffman values, runs of 2 to 8 "a", leave every padding length from 1 to 7 bits once Huffman-encoded.
+// The request validator fails a Huffman-encoded string padded with anything but the EOS prefix; the harness fails a value that does not decode to the one sent.
+func RunTestHpack5_2_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case hpack/5.2/5...")
+	i := 0
+	serveCheckedRequests(conn, framer, huffmanRuns, "8.1.2", func(headers *http2.MetaHeadersFrame) {
+		i++
+		want := strings.Repeat("a", i+1)
+		got := ""
+		for _, f := range headers.RegularFields() {
+			if f.Name == "x-huffman" {
+				got = f.Value
+			}
+		}
+		if got != want {
+			reportViolation(conn, "request %d on stream %d with x-huffman %q instead of %q (RFC 7541 Section 5.2)", i, headers.StreamID, got, want)
+			return
+		}
+		log.Printf("Request %d decoded x-huffman of %d octets.", i, len(got))
+	})
+}
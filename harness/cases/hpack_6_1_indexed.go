@@ -1,8 +1,10 @@
 package cases
 
 import (
+	"fmt"
 	"log"
 	"net"
+	"strings"
 
 	"golang.org/x/net/http2"
 )
@@ -27,4 +29,65 @@ func RunTestHpack6_1_1(conn net.Conn, framer *http2.Framer) {
 		return
 	}
 	log.Println("Sent indexed header field with index 0 - client should detect COMPRESSION_ERROR")
-}
\ No newline at end of file
+}
+
+// Test Case hpack/6.1/2: Adds a field to the dynamic table and references it by index later in the same header block.
+// The client should resolve the index to the new entry, yielding the field twice.
+func RunTestHpack6_1_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case hpack/6.1/2...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	// HPACK Section 6.1: Index 62 is the first entry of the dynamic table.
+	block := []byte{
+		0x88,       // :status: 200 (indexed)
+		0x40, 0x09, // Literal Header Field with Incremental Indexing (New Name), name length 9
+		'x', '-', 'i', 'n', 'd', 'e', 'x', 'e', 'd',
+		0x05, // Value length 5
+		'e', 'n', 't', 'r', 'y',
+		0xBE, // Indexed Header Field: index 62 (x-indexed: entry)
+	}
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: block,
+		EndStream:     false,
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, true, []byte("ok")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	log.Println("Sent header block referencing its own dynamic table entry - client should repeat the field")
+	linger(conn, framer)
+}
+
+// Test Case hpack/6.1/3: Serves three requests in turn that repeat x-hpack-repeated and add a new x-hpack-request each, so the client indexes its own dynamic table entries.
+// The request validator fails an index beyond the static and dynamic tables; the harness fails a field that resolves to anything but the value sent.
+func RunTestHpack6_1_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case hpack/6.1/3...")
+	i := 0
+	serveCheckedRequests(conn, framer, 3, "8.1.2", func(headers *http2.MetaHeadersFrame) {
+		i++
+		want := map[string]string{
+			"x-hpack-repeated": strings.Repeat("r", 64),
+			"x-hpack-request":  fmt.Sprintf("%d-%s", i, strings.Repeat("n", 64)),
+		}
+		got := make(map[string]string)
+		for _, f := range headers.RegularFields() {
+			got[f.Name] = f.Value
+		}
+		for name, value := range want {
+			if got[name] != value {
+				reportViolation(conn, "request %d on stream %d with %s %q instead of %q (RFC 7541 Section 6.1)", i, headers.StreamID, name, got[name], value)
+			}
+		}
+	})
+}
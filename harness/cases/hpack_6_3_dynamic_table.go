@@ -7,40 +7,88 @@ import (
 	"golang.org/x/net/http2"
 )
 
-// Test Case hpack/6.3/1: Sends a dynamic table size update larger than the value of SETTINGS_HEADER_TABLE_SIZE.
+// Test Case hpack/6.3/1: Sends a dynamic table size update one octet larger than the client's SETTINGS_HEADER_TABLE_SIZE.
 // The client should detect a COMPRESSION_ERROR.
 func RunTestHpack6_3_1(conn net.Conn, framer *http2.Framer) {
 	log.Println("Running test case hpack/6.3/1...")
 
-	// First send SETTINGS frame to set header table size to 4096
-	settingsFrame := []byte{
-		0x00, 0x00, 0x06, // Length: 6 bytes (one setting)
-		0x04,             // Type: SETTINGS
-		0x00,             // Flags: none
-		0x00, 0x00, 0x00, 0x00, // Stream ID: 0
-		0x00, 0x01,       // Setting ID: SETTINGS_HEADER_TABLE_SIZE (1)
-		0x00, 0x00, 0x10, 0x00, // Setting Value: 4096
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
 	}
 
-	if _, err := conn.Write(settingsFrame); err != nil {
-		log.Printf("Failed to write SETTINGS frame: %v", err)
+	// HPACK Section 6.3: The new maximum size MUST be lower than or equal to
+	// the limit determined by the decoder's SETTINGS_HEADER_TABLE_SIZE.
+	tableSize := SettingsOf(conn).HeaderTableSize()
+	block := append(tableSizeUpdate(tableSize+1), 0x88) // :status: 200
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: block,
+		EndStream:     true,
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame with invalid table size update: %v", err)
 		return
 	}
+	log.Printf("Sent dynamic table size update to %d - client should detect COMPRESSION_ERROR", tableSize+1)
+	linger(conn, framer)
+}
 
-	// Send HEADERS frame with dynamic table size update larger than setting
-	// HPACK Section 6.3: Dynamic table size update must not exceed SETTINGS_HEADER_TABLE_SIZE
-	headersFrame := []byte{
-		0x00, 0x00, 0x04, // Length: 4 bytes
-		0x01,             // Type: HEADERS
-		0x05,             // Flags: END_STREAM | END_HEADERS
-		0x00, 0x00, 0x00, 0x01, // Stream ID: 1
-		0x3F, 0xE1, 0x1F, // Dynamic Table Size Update: 8192 (larger than 4096 setting)
-		0x82,             // Header: :method: GET
+// Test Case hpack/6.3/2: Evicts the dynamic table with a size update of 0 in the trailers and then references the evicted entry.
+// The client should detect a COMPRESSION_ERROR while reading the response body.
+func RunTestHpack6_3_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case hpack/6.3/2...")
+
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
 	}
 
-	if _, err := conn.Write(headersFrame); err != nil {
-		log.Printf("Failed to write HEADERS frame with invalid table size update: %v", err)
+	headers := []byte{
+		0x88,       // :status: 200 (indexed)
+		0x40, 0x09, // Literal Header Field with Incremental Indexing (New Name), name length 9
+		'x', '-', 'e', 'v', 'i', 'c', 't', 'e', 'd',
+		0x04, // Value length 4
+		'g', 'o', 'n', 'e',
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: headers,
+		EndStream:     false,
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
 		return
 	}
-	log.Println("Sent dynamic table size update exceeding limit - client should detect COMPRESSION_ERROR")
-}
\ No newline at end of file
+	if err := framer.WriteData(streamID, false, []byte("body")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+
+	// HPACK Section 4.3: Lowering the maximum size evicts entries; index 62
+	// no longer exists once the table is empty.
+	trailers := []byte{
+		0x20, // Dynamic Table Size Update: 0
+		0xBE, // Indexed Header Field: index 62 (evicted)
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: trailers,
+		EndStream:     true,
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write trailing HEADERS frame: %v", err)
+		return
+	}
+	log.Println("Sent trailers referencing an evicted entry - client should detect COMPRESSION_ERROR")
+	linger(conn, framer)
+}
+
+// Test Case hpack/6.3/3: Lowers SETTINGS_HEADER_TABLE_SIZE to 256 after the first request, while the client's requests keep repeating fields.
+// The request validator reports a size update above 256 and any index into entries the smaller table has evicted.
+func RunTestHpack6_3_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case hpack/6.3/3...")
+	serveTableResizes(conn, framer, 256)
+}
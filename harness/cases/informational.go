@@ -0,0 +1,241 @@
+package cases
+
+import (
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// continueDelay is how long the harness holds 100 Continue back, so that
+// the log shows whether the client waits for it before sending the body.
+const continueDelay = 500 * time.Millisecond
+
+// informational is a 1xx response the harness sends ahead of the final
+// response.
+type informational struct {
+	status    string
+	fields    []hpack.HeaderField
+	endStream bool
+}
+
+// earlyHints returns a 103 (Early Hints) response that asks the client to
+// preload path.
+func earlyHints(path string) informational {
+	return informational{
+		status: "103",
+		fields: []hpack.HeaderField{{Name: "link", Value: "<" + path + ">; rel=preload; as=style"}},
+	}
+}
+
+// writeInformational writes r as a HEADERS frame on streamID.
+func writeInformational(framer *http2.Framer, streamID uint32, r informational) error {
+	fields := append([]hpack.HeaderField{{Name: ":status", Value: r.status}}, r.fields...)
+	return framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(fields...),
+		EndStream:     r.endStream,
+		EndHeaders:    true,
+	})
+}
+
+// serveInformational waits for the client's request, answers it with each
+// of responses in turn followed by a complete 200 response carrying "ok",
+// and lingers until the client is done.
+func serveInformational(conn net.Conn, framer *http2.Framer, responses ...informational) {
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	for _, r := range responses {
+		if err := writeInformational(framer, streamID, r); err != nil {
+			log.Printf("Failed to write %s response: %v", r.status, err)
+			return
+		}
+		if r.endStream {
+			log.Printf("Sent %s response with END_STREAM on stream %d.", r.status, streamID)
+		} else {
+			log.Printf("Sent %s response on stream %d.", r.status, streamID)
+		}
+	}
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Printf("Sent final response on stream %d.", streamID)
+	linger(conn, framer)
+}
+
+// awaitUploadRequest waits for the HEADERS frame that opens the client's
+// upload and logs whether it carries a 100-continue expectation.
+func awaitUploadRequest(framer *http2.Framer) (*http2.MetaHeadersFrame, error) {
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	frame, err := readUntil(framer, func(f http2.Frame) bool {
+		_, ok := f.(*http2.MetaHeadersFrame)
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+	headers := frame.(*http2.MetaHeadersFrame)
+	expect := false
+	for _, f := range headers.RegularFields() {
+		expect = expect || f.Name == "expect" && strings.EqualFold(f.Value, "100-continue")
+	}
+	if expect {
+		log.Printf("Received request HEADERS on stream %d with a 100-continue expectation.", headers.StreamID)
+	} else {
+		log.Printf("Received request HEADERS on stream %d without a 100-continue expectation.", headers.StreamID)
+	}
+	return headers, nil
+}
+
+// receiveContinuedUpload waits for the client's upload, holds 100 Continue
+// back for continueDelay and then reads the body. It logs how much of the
+// body the client sent before the 100 Continue and how soon it sent the
+// rest after it, and answers as receiveUpload does once the body is
+// complete.
+func receiveContinuedUpload(conn net.Conn, framer *http2.Framer) {
+	headers, err := awaitUploadRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	streamID := headers.StreamID
+	start := time.Now()
+
+	var (
+		body      []byte
+		early     int
+		continued time.Time
+	)
+	firstData := time.Duration(-1)
+	ended := headers.StreamEnded()
+	for !ended || continued.IsZero() {
+		if continued.IsZero() {
+			conn.SetReadDeadline(start.Add(continueDelay))
+		} else {
+			conn.SetReadDeadline(time.Now().Add(stallTimeout))
+		}
+		frame, err := framer.ReadFrame()
+		if ne, ok := err.(net.Error); ok && ne.Timeout() && continued.IsZero() {
+			// RFC 9110 Section 10.1.1: the server may send 100 Continue
+			// even if part or all of the body has already arrived.
+			if err := writeInformational(framer, streamID, informational{status: "100"}); err != nil {
+				log.Printf("Failed to write 100 response: %v", err)
+				return
+			}
+			continued = time.Now()
+			log.Printf("Sent 100 Continue on stream %d after %v, with %d octets of body received.", streamID, continueDelay, early)
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to read frame during the upload: %v", err)
+			return
+		}
+
+		switch f := frame.(type) {
+		case *http2.DataFrame:
+			if f.StreamID != streamID {
+				log.Printf("Ignoring DATA on stream %d during the upload.", f.StreamID)
+				continue
+			}
+			if continued.IsZero() {
+				early += len(f.Data())
+			} else if firstData < 0 {
+				firstData = time.Since(continued)
+			}
+			body = append(body, f.Data()...)
+			ended = f.StreamEnded()
+			if n := f.Header().Length; n > 0 && !ended {
+				framer.WriteWindowUpdate(0, n)
+				framer.WriteWindowUpdate(streamID, n)
+			}
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		case *http2.RSTStreamFrame:
+			log.Printf("Client reset stream %d with %v during the upload.", f.StreamID, f.ErrCode)
+			return
+		default:
+			log.Printf("Ignoring frame of type %T during the upload.", f)
+		}
+	}
+
+	switch {
+	case early == len(body):
+		log.Printf("The client sent the whole body of %d octets without waiting for 100 Continue.", len(body))
+	case early > 0:
+		log.Printf("The client sent %d of %d octets before 100 Continue and the first DATA after it %v later.", early, len(body), firstData.Round(time.Millisecond))
+	default:
+		log.Printf("The client waited for 100 Continue and sent the first DATA %v after it.", firstData.Round(time.Millisecond))
+	}
+	respondUpload(conn, framer, streamID, headers.Fields, body)
+	linger(conn, framer)
+}
+
+// refuseUpload waits for the client's upload and answers it at once with a
+// complete 413 (Content Too Large) response, without granting the body any
+// flow-control credit. It then asks the client to stop sending the body
+// with RST_STREAM NO_ERROR (RFC 7540 Section 8.1) and logs how much of the
+// body arrived.
+func refuseUpload(conn net.Conn, framer *http2.Framer) {
+	headers, err := awaitUploadRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	streamID := headers.StreamID
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "413"}),
+		EndHeaders:    true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, true, []byte("too large")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	if err := framer.WriteRSTStream(streamID, http2.ErrCodeNo); err != nil {
+		log.Printf("Failed to write RST_STREAM frame: %v", err)
+		return
+	}
+	log.Printf("Sent a complete 413 response and RST_STREAM NO_ERROR on stream %d before the body.", streamID)
+
+	received := 0
+	conn.SetReadDeadline(time.Now().Add(lingerTimeout))
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			break
+		}
+		switch f := frame.(type) {
+		case *http2.DataFrame:
+			if f.StreamID == streamID {
+				received += len(f.Data())
+			}
+		case *http2.RSTStreamFrame:
+			log.Printf("Client reset stream %d with %v.", f.StreamID, f.ErrCode)
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		}
+	}
+	log.Printf("The client sent %d octets of the body it was refused.", received)
+}
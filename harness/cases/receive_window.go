@@ -0,0 +1,323 @@
+package cases
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// maxWindowSize is the largest flow-control window RFC 7540 Section 6.9.1
+// allows.
+const maxWindowSize = 1<<31 - 1
+
+// stallTimeout bounds how long the harness waits for WINDOW_UPDATE while
+// the client's windows are exhausted before it declares a deadlock.
+const stallTimeout = 2 * time.Second
+
+// receiveWindows are the flow-control windows the client has granted the
+// harness for its response (RFC 7540 Section 6.9.1), together with the
+// cadence of the WINDOW_UPDATE frames that granted them.
+type receiveWindows struct {
+	streamID uint32
+	conn     int64
+	stream   int64
+
+	initialStream  int64
+	connUpdates    int
+	streamUpdates  int
+	streamCredit   int64
+	smallestCredit uint32
+	stalls         int
+	stalled        time.Duration
+}
+
+// limit returns the number of octets the harness may send on the stream
+// and whether that limit is set by the connection window.
+func (w *receiveWindows) limit() (int64, bool) {
+	if w.conn <= w.stream {
+		return w.conn, true
+	}
+	return w.stream, false
+}
+
+// credit applies a WINDOW_UPDATE from the client. An update that takes a
+// window beyond 2^31-1 is a flow-control error (RFC 7540 Section 6.9.1): on
+// the connection it aborts the connection, on the stream it resets the
+// stream. credit reports whether the response can go on.
+func (w *receiveWindows) credit(conn net.Conn, framer *http2.Framer, f *http2.WindowUpdateFrame) bool {
+	switch f.StreamID {
+	case 0:
+		w.conn += int64(f.Increment)
+		w.connUpdates++
+		if w.conn > maxWindowSize {
+			abortViolation(conn, framer, http2.ErrCodeFlowControl, "WINDOW_UPDATE of %d takes the connection window to %d, beyond 2^31-1", f.Increment, w.conn)
+			return false
+		}
+	case w.streamID:
+		w.stream += int64(f.Increment)
+		w.streamUpdates++
+		w.streamCredit += int64(f.Increment)
+		if w.smallestCredit == 0 || f.Increment < w.smallestCredit {
+			w.smallestCredit = f.Increment
+		}
+		if w.stream > maxWindowSize {
+			reportViolation(conn, "WINDOW_UPDATE of %d takes the window of stream %d to %d, beyond 2^31-1", f.Increment, w.streamID, w.stream)
+			framer.WriteRSTStream(w.streamID, http2.ErrCodeFlowControl)
+			return false
+		}
+	default:
+		log.Printf("Ignoring WINDOW_UPDATE of %d on stream %d.", f.Increment, f.StreamID)
+	}
+	return true
+}
+
+// report logs how efficiently the client refilled its windows while it
+// received size octets of body in elapsed.
+func (w *receiveWindows) report(size int, elapsed time.Duration) {
+	mean, share := int64(0), 0.0
+	if w.streamUpdates > 0 {
+		mean = w.streamCredit / int64(w.streamUpdates)
+	}
+	if w.initialStream > 0 {
+		share = 100 * float64(mean) / float64(w.initialStream)
+	}
+	log.Printf("Refill cadence: %d connection and %d stream WINDOW_UPDATE frames for %d octets in %v.", w.connUpdates, w.streamUpdates, size, elapsed)
+	log.Printf("Refill efficiency: mean stream increment %d octets (%.1f%% of the initial window %d), smallest %d; blocked %d times for %v (%.1f%% of the transfer).",
+		mean, share, w.initialStream, w.smallestCredit,
+		w.stalls, w.stalled, 100*w.stalled.Seconds()/elapsed.Seconds())
+}
+
+// awaitRequestWindows waits for the client's request and for the ACK of the
+// initial server SETTINGS, crediting every WINDOW_UPDATE the client sends on
+// the way. Clients announce their receive windows in the SETTINGS and
+// WINDOW_UPDATE frames that follow their preface, so by the time of the ACK
+// the windows are known.
+func awaitRequestWindows(conn net.Conn, framer *http2.Framer) (*receiveWindows, error) {
+	initial := int64(SettingsOf(conn).InitialWindowSize())
+	w := &receiveWindows{conn: 65535, stream: initial, initialStream: initial}
+	acked := false
+	for w.streamID == 0 || !acked {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch f := frame.(type) {
+		case *http2.HeadersFrame:
+			w.streamID = f.StreamID
+			log.Printf("Received request HEADERS on stream %d.", w.streamID)
+		case *http2.SettingsFrame:
+			if f.IsAck() {
+				acked = true
+				log.Println("Received SETTINGS ACK.")
+			}
+		case *http2.WindowUpdateFrame:
+			log.Printf("Received WINDOW_UPDATE of %d on stream %d.", f.Increment, f.StreamID)
+			if !w.credit(conn, framer, f) {
+				return nil, fmt.Errorf("client over-credited its window")
+			}
+		default:
+			log.Printf("Ignoring frame of type %T while waiting for the client.", f)
+		}
+	}
+	log.Printf("Client's receive windows: connection %d, stream %d.", w.conn, w.stream)
+	return w, nil
+}
+
+// sendFlowControlled writes body on the stream in DATA frames carrying at
+// most frameSize octets of data and padLen octets of padding, never
+// exceeding the client's windows. Whenever they are exhausted it waits for
+// WINDOW_UPDATE; a client that sends none within stallTimeout has
+// deadlocked the transfer. The last frame carries END_STREAM. It reports
+// whether the whole body was sent.
+func sendFlowControlled(conn net.Conn, framer *http2.Framer, w *receiveWindows, body []byte, frameSize int, padLen uint8) bool {
+	overhead := int64(0)
+	var padding []byte
+	if padLen > 0 {
+		overhead = int64(padLen) + 1
+		padding = make([]byte, padLen)
+	}
+	var blockedSince time.Time
+	for len(body) > 0 {
+		n := int64(frameSize)
+		if int64(len(body)) < n {
+			n = int64(len(body))
+		}
+		if window, _ := w.limit(); window < n+overhead {
+			n = window - overhead
+		}
+		if n <= 0 {
+			if blockedSince.IsZero() {
+				blockedSince = time.Now()
+			}
+			if !awaitCredit(conn, framer, w, len(body)) {
+				return false
+			}
+			continue
+		}
+		if !blockedSince.IsZero() {
+			w.stalls++
+			w.stalled += time.Since(blockedSince)
+			blockedSince = time.Time{}
+		}
+
+		endStream := n == int64(len(body))
+		var err error
+		if padLen > 0 {
+			err = framer.WriteDataPadded(w.streamID, endStream, body[:n], padding)
+		} else {
+			err = framer.WriteData(w.streamID, endStream, body[:n])
+		}
+		if err != nil {
+			log.Printf("Failed to write DATA frame: %v", err)
+			return false
+		}
+		w.conn -= n + overhead
+		w.stream -= n + overhead
+		body = body[n:]
+	}
+	return true
+}
+
+// awaitCredit reads the client's frames until one of them credits a
+// window. It answers PING and SETTINGS on the way, and aborts the
+// connection if no frame arrives within stallTimeout.
+func awaitCredit(conn net.Conn, framer *http2.Framer, w *receiveWindows, remaining int) bool {
+	conn.SetReadDeadline(time.Now().Add(stallTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	for {
+		frame, err := framer.ReadFrame()
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			abortViolation(conn, framer, http2.ErrCodeFlowControl,
+				"flow-control deadlock: no WINDOW_UPDATE for %v with %d octets left to send (connection window %d, stream window %d)",
+				stallTimeout, remaining, w.conn, w.stream)
+			return false
+		}
+		if err != nil {
+			log.Printf("Failed to read frame while waiting for WINDOW_UPDATE: %v", err)
+			return false
+		}
+		switch f := frame.(type) {
+		case *http2.WindowUpdateFrame:
+			return w.credit(conn, framer, f)
+		case *http2.SettingsFrame:
+			if f.IsAck() {
+				continue
+			}
+			if v, ok := f.Value(http2.SettingInitialWindowSize); ok {
+				w.stream += int64(v) - w.initialStream
+				w.initialStream = int64(v)
+			}
+			framer.WriteSettingsAck()
+			return true
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		case *http2.RSTStreamFrame:
+			log.Printf("Client reset stream %d with %v while receiving the response.", f.StreamID, f.ErrCode)
+			return false
+		case *http2.GoAwayFrame:
+			log.Printf("Client sent GOAWAY with %v while receiving the response.", f.ErrCode)
+			return false
+		default:
+			log.Printf("Ignoring frame of type %T while waiting for WINDOW_UPDATE.", f)
+		}
+	}
+}
+
+// checkClosedStreamCredit verifies that the client stops crediting the
+// stream once it has seen END_STREAM. It sends a PING after the response:
+// WINDOW_UPDATE frames for the stream that arrive before the PING ACK may
+// have crossed the END_STREAM in flight, but any that arrive after it were
+// sent on a closed stream (RFC 7540 Section 5.1). Updates on the
+// connection remain legitimate.
+func checkClosedStreamCredit(conn net.Conn, framer *http2.Framer, streamID uint32) {
+	probe := [8]byte{'c', 'l', 'o', 's', 'e', 'd'}
+	if err := framer.WritePing(false, probe); err != nil {
+		log.Printf("Failed to write PING frame: %v", err)
+		return
+	}
+	acked := false
+	conn.SetReadDeadline(time.Now().Add(lingerTimeout))
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			if !acked {
+				log.Println("Client closed the connection before acknowledging the PING.")
+			}
+			return
+		}
+		switch f := frame.(type) {
+		case *http2.PingFrame:
+			if f.IsAck() && f.Data == probe {
+				acked = true
+				log.Println("Received PING ACK; the client has processed END_STREAM.")
+			}
+		case *http2.WindowUpdateFrame:
+			if f.StreamID != streamID {
+				continue
+			}
+			if acked {
+				reportViolation(conn, "WINDOW_UPDATE of %d on stream %d after it was closed by END_STREAM", f.Increment, streamID)
+			} else {
+				log.Printf("Tolerating WINDOW_UPDATE of %d on stream %d sent before END_STREAM arrived.", f.Increment, streamID)
+			}
+		}
+	}
+}
+
+// writeWindowResponse answers streamID with a 200 response whose DATA
+// frames consume exactly total octets of flow-control window. When padLen
+// is non-zero the last frame is padded with padLen octets, so that only the
+// padding takes it to total; a last frame too small to hold them all carries
+// as many as it can. END_STREAM is set only if endStream is true, in which
+// case the response carries a content-length matching its data.
+func writeWindowResponse(framer *http2.Framer, streamID uint32, maxFrameSize uint32, total int64, padLen uint8, endStream bool) error {
+	last := total
+	if last > int64(maxFrameSize) {
+		last = int64(maxFrameSize)
+	}
+	padded := padLen > 0 && last > 0
+	if padded && int64(padLen) >= last {
+		log.Printf("The last DATA frame of %d octets cannot hold %d octets of padding; padding it with %d.", last, padLen, last-1)
+		padLen = uint8(last - 1)
+	}
+	dataLen := total
+	if padded {
+		dataLen -= int64(padLen) + 1
+	}
+
+	fields := []hpack.HeaderField{{Name: ":status", Value: "200"}}
+	if endStream {
+		fields = append(fields, hpack.HeaderField{Name: "content-length", Value: strconv.FormatInt(dataLen, 10)})
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(fields...),
+		EndHeaders:    true,
+	}); err != nil {
+		return err
+	}
+
+	chunk := bytes.Repeat([]byte("w"), int(maxFrameSize))
+	for remaining := total - last; remaining > 0; {
+		n := int64(maxFrameSize)
+		if remaining < n {
+			n = remaining
+		}
+		if err := framer.WriteData(streamID, false, chunk[:n]); err != nil {
+			return err
+		}
+		remaining -= n
+	}
+	if !padded {
+		return framer.WriteData(streamID, endStream, chunk[:last])
+	}
+	return framer.WriteDataPadded(streamID, endStream, chunk[:last-int64(padLen)-1], make([]byte, padLen))
+}
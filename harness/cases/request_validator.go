@@ -0,0 +1,572 @@
+package cases
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/nomadlabsinc/h2-client-test-harness/priority"
+	"github.com/nomadlabsinc/h2-client-test-harness/profile"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// frameHeaderLen is the length of the frame header (RFC 7540 Section 4.1).
+const frameHeaderLen = 9
+
+// connectionSpecificFields are the header fields RFC 7540 Section 8.1.2.2
+// forbids in HTTP/2 messages.
+var connectionSpecificFields = map[string]bool{
+	"connection":        true,
+	"keep-alive":        true,
+	"proxy-connection":  true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+}
+
+// requestPseudoHeaders are the pseudo-header fields a request may carry:
+// those of RFC 7540 Section 8.1.2.3 and the :protocol of extended CONNECT
+// (RFC 8441 Section 4).
+var requestPseudoHeaders = map[string]bool{
+	":method":    true,
+	":scheme":    true,
+	":authority": true,
+	":path":      true,
+	":protocol":  true,
+}
+
+// requestValidator decodes a copy of everything the client sends and
+// checks the client's streams and header blocks against RFC 7540 Sections
+// 5.1.1 and 8.1.2 and RFC 9113 Section 8.3, its priority signals against
+// RFC 9218, and its frames and header lists against the sizes the server
+// allows, whatever the test case does with the frames. It runs on its own
+// framer and a strict HPACK decoder, so that it sees every header block in
+// order and audits the client's header compression against RFC 7541.
+type requestValidator struct {
+	conn *Conn
+	pr   *io.PipeReader
+	pw   *io.PipeWriter
+	done chan struct{}
+
+	framer       *http2.Framer
+	decoder      *hpack.Decoder
+	lastStreamID uint32
+	open         map[uint32]bool
+
+	// The header block being assembled from HEADERS and CONTINUATION.
+	blockStream uint32
+	blockEnds   bool
+	block       []byte
+
+	// The client's view of the server's SETTINGS_HEADER_TABLE_SIZE. tableSize
+	// is the value the client has acknowledged and encoderSize the table
+	// size its encoder last signaled. While required is set, the next header
+	// block must signal a size of at most requiredSize (RFC 7541 Section 4.2).
+	tableSize    uint32
+	encoderSize  uint32
+	required     bool
+	requiredSize uint32
+
+	// The server's SETTINGS_MAX_FRAME_SIZE and SETTINGS_MAX_HEADER_LIST_SIZE
+	// the client has acknowledged.
+	maxFrameSize      uint32
+	maxHeaderListSize uint32
+
+	// The frames the harness writes, scanned for the SETTINGS frames the
+	// client acknowledges in order.
+	mu      sync.Mutex
+	written []byte
+	sent    [][]http2.Setting
+	// Whether the harness has enabled extended CONNECT with
+	// SETTINGS_ENABLE_CONNECT_PROTOCOL (RFC 8441 Section 3).
+	connectProtocol bool
+}
+
+func newRequestValidator(conn *Conn) *requestValidator {
+	pr, pw := io.Pipe()
+	v := &requestValidator{
+		conn:    conn,
+		pr:      pr,
+		pw:      pw,
+		done:    make(chan struct{}),
+		framer:  http2.NewFramer(io.Discard, pr),
+		decoder: hpack.NewDecoder(4096, nil),
+		open:    make(map[uint32]bool),
+
+		tableSize:         4096,
+		encoderSize:       4096,
+		maxFrameSize:      16384,
+		maxHeaderListSize: math.MaxUint32,
+	}
+	v.framer.SetMaxReadFrameSize(maxFrameLength)
+	go v.run()
+	return v
+}
+
+// observe hands the validator a copy of bytes read from the client.
+func (v *requestValidator) observe(p []byte) {
+	v.pw.Write(p)
+}
+
+// write hands the validator a copy of bytes about to be written to the
+// client. It picks out the SETTINGS frames among them, so that it knows
+// which settings each SETTINGS ACK from the client acknowledges.
+func (v *requestValidator) write(p []byte) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.written = append(v.written, p...)
+	for len(v.written) >= frameHeaderLen {
+		length := int(v.written[0])<<16 | int(v.written[1])<<8 | int(v.written[2])
+		if len(v.written) < frameHeaderLen+length {
+			return
+		}
+		typ, flags := http2.FrameType(v.written[3]), http2.Flags(v.written[4])
+		streamID := binary.BigEndian.Uint32(v.written[5:]) & (1<<31 - 1)
+		payload := v.written[frameHeaderLen : frameHeaderLen+length]
+		v.written = v.written[frameHeaderLen+length:]
+
+		// A client must not acknowledge a malformed SETTINGS frame.
+		if typ != http2.FrameSettings || flags.Has(http2.FlagSettingsAck) || streamID != 0 || length%6 != 0 {
+			continue
+		}
+		var settings []http2.Setting
+		for ; len(payload) > 0; payload = payload[6:] {
+			settings = append(settings, http2.Setting{
+				ID:  http2.SettingID(binary.BigEndian.Uint16(payload)),
+				Val: binary.BigEndian.Uint32(payload[2:]),
+			})
+		}
+		for _, setting := range settings {
+			if setting.ID == http2.SettingEnableConnectProtocol && setting.Val == 1 {
+				v.connectProtocol = true
+			}
+		}
+		v.sent = append(v.sent, settings)
+	}
+}
+
+// acknowledge applies the oldest SETTINGS frame the harness sent, which the
+// client has just acknowledged.
+func (v *requestValidator) acknowledge() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.sent) == 0 {
+		return
+	}
+	settings := v.sent[0]
+	v.sent = v.sent[1:]
+	for _, setting := range settings {
+		switch setting.ID {
+		case http2.SettingHeaderTableSize:
+			v.tableSize = setting.Val
+			if setting.Val < v.encoderSize && (!v.required || setting.Val < v.requiredSize) {
+				v.required, v.requiredSize = true, setting.Val
+			}
+		case http2.SettingMaxFrameSize:
+			v.maxFrameSize = setting.Val
+		case http2.SettingMaxHeaderListSize:
+			v.maxHeaderListSize = setting.Val
+		}
+	}
+}
+
+// limit returns the largest value of the setting id the client may be
+// using, given acked, the value it has acknowledged: until it acknowledges
+// a SETTINGS frame it may already have applied it.
+func (v *requestValidator) limit(id http2.SettingID, acked *uint32) uint32 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	limit := *acked
+	for _, settings := range v.sent {
+		for _, setting := range settings {
+			if setting.ID == id {
+				limit = max(limit, setting.Val)
+			}
+		}
+	}
+	return limit
+}
+
+// tableSizeLimit returns the largest dynamic table the client may signal.
+func (v *requestValidator) tableSizeLimit() uint32 {
+	return v.limit(http2.SettingHeaderTableSize, &v.tableSize)
+}
+
+// finish waits until the validator has checked everything the client sent.
+func (v *requestValidator) finish() {
+	v.pw.Close()
+	<-v.done
+}
+
+func (v *requestValidator) run() {
+	defer close(v.done)
+	// Whatever stops the validator, the reads it copies must not block.
+	defer io.Copy(io.Discard, v.pr)
+
+	for {
+		frame, err := v.framer.ReadFrame()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return
+		}
+		if err != nil {
+			log.Printf("Request validation stopped: %v", err)
+			return
+		}
+		if !v.check(frame) {
+			return
+		}
+	}
+}
+
+// check validates one frame from the client and reports whether the
+// validator can go on.
+func (v *requestValidator) check(frame http2.Frame) bool {
+	// DATA frames are left to the test cases that grade uploads, which
+	// close the connection on the first one that is too large.
+	if h := frame.Header(); h.Type != http2.FrameData {
+		if limit := v.limit(http2.SettingMaxFrameSize, &v.maxFrameSize); h.Length > limit {
+			reportViolation(v.conn, "%s frame of %d octets on stream %d exceeds SETTINGS_MAX_FRAME_SIZE of %d (RFC 7540 Section 4.2)", h.Type, h.Length, h.StreamID, limit)
+		}
+	}
+	switch f := frame.(type) {
+	case *http2.HeadersFrame:
+		v.blockStream, v.blockEnds = f.StreamID, f.StreamEnded()
+		v.block = append(v.block[:0], f.HeaderBlockFragment()...)
+		if !f.HeadersEnded() {
+			return true
+		}
+		return v.checkBlock()
+	case *http2.ContinuationFrame:
+		v.block = append(v.block, f.HeaderBlockFragment()...)
+		if !f.HeadersEnded() {
+			return true
+		}
+		return v.checkBlock()
+	case *http2.DataFrame:
+		if f.StreamEnded() {
+			delete(v.open, f.StreamID)
+		}
+	case *http2.RSTStreamFrame:
+		delete(v.open, f.StreamID)
+	case *http2.SettingsFrame:
+		if f.IsAck() {
+			v.acknowledge()
+		}
+	case *http2.UnknownFrame:
+		if f.Type == priority.FramePriorityUpdate {
+			v.checkPriorityUpdate(f)
+		}
+	}
+	return true
+}
+
+// checkPriorityUpdate validates a PRIORITY_UPDATE frame from the client
+// against RFC 9218 Section 7.1.
+func (v *requestValidator) checkPriorityUpdate(f *http2.UnknownFrame) {
+	if f.StreamID != 0 {
+		reportViolation(v.conn, "PRIORITY_UPDATE frame on stream %d; it must be sent on stream 0 (RFC 9218 Section 7.1)", f.StreamID)
+	}
+	id, value, err := priority.DecodeUpdate(f.Payload())
+	switch {
+	case err != nil:
+		reportViolation(v.conn, "%v (RFC 9218 Section 7.1)", err)
+		return
+	case id == 0:
+		reportViolation(v.conn, "PRIORITY_UPDATE frame for stream 0 (RFC 9218 Section 7.1)")
+	}
+	if _, err := priority.Parse(value); err != nil {
+		reportViolation(v.conn, "PRIORITY_UPDATE frame for stream %d with Priority Field Value %q: %v (RFC 9218 Section 7.1)", id, value, err)
+	}
+}
+
+// checkBlock decodes a complete header block and validates it as a request
+// or, on a stream the client still has open, as trailers.
+func (v *requestValidator) checkBlock() bool {
+	id := v.blockStream
+	problems := v.auditTableSizeUpdates()
+	for _, problem := range problems {
+		reportViolation(v.conn, "header block on stream %d: %s", id, problem)
+	}
+	v.decoder.SetAllowedMaxDynamicTableSize(v.tableSizeLimit())
+	fields, err := v.decoder.DecodeFull(v.block)
+	if err != nil {
+		if len(problems) > 0 {
+			log.Printf("Request validation stopped: %v", err)
+		} else {
+			reportViolation(v.conn, "header block on stream %d does not decode: %v", id, err)
+		}
+		return false
+	}
+	var size uint64
+	for _, f := range fields {
+		size += uint64(f.Size())
+	}
+	if limit := v.limit(http2.SettingMaxHeaderListSize, &v.maxHeaderListSize); size > uint64(limit) {
+		reportViolation(v.conn, "header list of %d octets on stream %d exceeds SETTINGS_MAX_HEADER_LIST_SIZE of %d (RFC 7540 Section 6.5.2)", size, id, limit)
+	}
+
+	if v.open[id] {
+		if problem := validateTrailers(fields, v.blockEnds); problem != "" {
+			reportViolation(v.conn, "trailers on stream %d: %s", id, problem)
+		}
+		delete(v.open, id)
+		return true
+	}
+
+	switch {
+	case id%2 == 0:
+		reportViolation(v.conn, "request on even stream %d; client streams must be odd (RFC 7540 Section 5.1.1)", id)
+	case id <= v.lastStreamID:
+		reportViolation(v.conn, "request on stream %d after stream %d; stream identifiers must increase (RFC 7540 Section 5.1.1)", id, v.lastStreamID)
+	default:
+		v.lastStreamID = id
+	}
+	if !v.blockEnds {
+		v.open[id] = true
+	}
+	for _, problem := range validateRequest(fields) {
+		reportViolation(v.conn, "request on stream %d: %s", id, problem)
+	}
+	for _, f := range fields {
+		if f.Name == ":protocol" && !v.connectProtocolEnabled() {
+			reportViolation(v.conn, "extended CONNECT on stream %d although the server did not enable SETTINGS_ENABLE_CONNECT_PROTOCOL (RFC 8441 Section 3)", id)
+		}
+	}
+	return true
+}
+
+// connectProtocolEnabled reports whether the harness has let the client
+// send extended CONNECT requests.
+func (v *requestValidator) connectProtocolEnabled() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.connectProtocol
+}
+
+// auditTableSizeUpdates checks the dynamic table size updates of the
+// current header block (RFC 7541 Sections 4.2 and 6.3): they may only start
+// the block, must stay within the server's SETTINGS_HEADER_TABLE_SIZE, and
+// must signal a lowered one. A block that cannot be walked is left to the
+// decoder to report.
+func (v *requestValidator) auditTableSizeUpdates() []string {
+	updates, misplaced, err := tableSizeUpdates(v.block)
+	if err != nil {
+		return nil
+	}
+	var problems []string
+	if misplaced {
+		problems = append(problems, "dynamic table size update after a header field (RFC 7541 Section 4.2)")
+	}
+	limit := v.tableSizeLimit()
+	smallest := uint64(limit) + 1
+	for _, size := range updates {
+		if size > uint64(limit) {
+			problems = append(problems, fmt.Sprintf("dynamic table size update to %d exceeds SETTINGS_HEADER_TABLE_SIZE of %d (RFC 7541 Section 6.3)", size, limit))
+		}
+		smallest = min(smallest, size)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.required && smallest > uint64(v.requiredSize) {
+		problems = append(problems, fmt.Sprintf("no dynamic table size update to at most %d after the server lowered SETTINGS_HEADER_TABLE_SIZE (RFC 7541 Section 4.2)", v.requiredSize))
+	}
+	v.required = false
+	if len(updates) > 0 {
+		v.encoderSize = uint32(min(updates[len(updates)-1], 1<<32-1))
+	}
+	return problems
+}
+
+// tableSizeUpdates walks the representations of an HPACK header block
+// (RFC 7541 Section 6) and returns the dynamic table size updates that
+// start it, and whether any other update follows a header field.
+func tableSizeUpdates(block []byte) (updates []uint64, misplaced bool, err error) {
+	fields := 0
+	for len(block) > 0 {
+		b := block[0]
+		switch {
+		case b&0x80 != 0: // Indexed Header Field
+			_, block, err = readHPACKInt(block, 7)
+		case b&0xc0 == 0x40: // Literal Header Field with Incremental Indexing
+			block, err = skipHPACKLiteral(block, 6)
+		case b&0xe0 == 0x20: // Dynamic Table Size Update
+			var size uint64
+			size, block, err = readHPACKInt(block, 5)
+			if fields > 0 {
+				misplaced = true
+			} else {
+				updates = append(updates, size)
+			}
+			continue
+		default: // Literal Header Field without Indexing or Never Indexed
+			block, err = skipHPACKLiteral(block, 4)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		fields++
+	}
+	return updates, misplaced, err
+}
+
+var errHPACKTruncated = errors.New("truncated header block")
+
+// readHPACKInt reads an integer with an n-bit prefix (RFC 7541 Section 5.1)
+// from the start of b and returns it with the rest of b.
+func readHPACKInt(b []byte, n uint8) (uint64, []byte, error) {
+	if len(b) == 0 {
+		return 0, nil, errHPACKTruncated
+	}
+	mask := uint64(1)<<n - 1
+	v := uint64(b[0]) & mask
+	b = b[1:]
+	if v < mask {
+		return v, b, nil
+	}
+	for shift := uint(0); len(b) > 0; shift += 7 {
+		if shift > 56 {
+			return 0, nil, errors.New("integer overflow")
+		}
+		c := b[0]
+		b = b[1:]
+		v += uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return v, b, nil
+		}
+	}
+	return 0, nil, errHPACKTruncated
+}
+
+// skipHPACKLiteral skips a literal header field representation whose index
+// has an n-bit prefix: a new name when the index is 0, then the value.
+func skipHPACKLiteral(b []byte, n uint8) ([]byte, error) {
+	index, b, err := readHPACKInt(b, n)
+	if err != nil {
+		return nil, err
+	}
+	if index == 0 {
+		if b, err = skipHPACKString(b); err != nil {
+			return nil, err
+		}
+	}
+	return skipHPACKString(b)
+}
+
+// skipHPACKString skips a string literal (RFC 7541 Section 5.2).
+func skipHPACKString(b []byte) ([]byte, error) {
+	length, b, err := readHPACKInt(b, 7)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(b)) < length {
+		return nil, errHPACKTruncated
+	}
+	return b[length:], nil
+}
+
+// validateRequest returns the ways in which fields break the rules for
+// request header fields of RFC 7540 Section 8.1.2 and RFC 9113 Section 8.3.
+func validateRequest(fields []hpack.HeaderField) []string {
+	var problems []string
+	pseudo := make(map[string]string)
+	regular := false
+	host := false
+	var hostValue string
+	for _, f := range fields {
+		problems = append(problems, validateFieldName(f.Name)...)
+		if strings.HasPrefix(f.Name, ":") {
+			switch {
+			case !requestPseudoHeaders[f.Name]:
+				problems = append(problems, fmt.Sprintf("unknown pseudo-header field %s", f.Name))
+			case regular:
+				problems = append(problems, fmt.Sprintf("pseudo-header field %s follows a regular field", f.Name))
+			}
+			if _, ok := pseudo[f.Name]; ok {
+				problems = append(problems, fmt.Sprintf("duplicate pseudo-header field %s", f.Name))
+			}
+			pseudo[f.Name] = f.Value
+			continue
+		}
+		regular = true
+		switch {
+		case connectionSpecificFields[f.Name]:
+			problems = append(problems, fmt.Sprintf("connection-specific header field %s", f.Name))
+		case f.Name == "te" && f.Value != "trailers":
+			problems = append(problems, fmt.Sprintf("te header field with value %q; only \"trailers\" is allowed", f.Value))
+		case f.Name == "host":
+			host, hostValue = true, f.Value
+		case f.Name == "priority":
+			if _, err := priority.Parse(f.Value); err != nil {
+				problems = append(problems, fmt.Sprintf("priority header field %q: %v (RFC 9218 Section 5)", f.Value, err))
+			}
+		}
+	}
+
+	method, ok := pseudo[":method"]
+	if !ok {
+		problems = append(problems, "missing :method pseudo-header field")
+	}
+	_, hasAuthority := pseudo[":authority"]
+	_, hasProtocol := pseudo[":protocol"]
+	if hasProtocol && method != "CONNECT" {
+		problems = append(problems, fmt.Sprintf(":protocol pseudo-header field in a %s request (RFC 8441 Section 4)", method))
+	}
+	if method == "CONNECT" && !hasProtocol {
+		// RFC 7540 Section 8.3: a CONNECT request names only its target.
+		if !hasAuthority {
+			problems = append(problems, "CONNECT request without :authority")
+		}
+		for _, name := range []string{":scheme", ":path"} {
+			if _, ok := pseudo[name]; ok {
+				problems = append(problems, fmt.Sprintf("CONNECT request with %s", name))
+			}
+		}
+	} else {
+		for _, name := range []string{":scheme", ":path"} {
+			if value, ok := pseudo[name]; !ok {
+				problems = append(problems, fmt.Sprintf("missing %s pseudo-header field", name))
+			} else if value == "" {
+				problems = append(problems, fmt.Sprintf("empty %s pseudo-header field", name))
+			}
+		}
+	}
+	if host && !hasAuthority {
+		problems = append(problems, "host header field without :authority (RFC 9113 Section 8.3.1)")
+	}
+	if authority := pseudo[":authority"]; host && hasAuthority && hostValue != authority && activeProfile == profile.RFC9113 {
+		problems = append(problems, fmt.Sprintf("host header field %q differs from :authority %q (RFC 9113 Section 8.3.1)", hostValue, authority))
+	}
+	return problems
+}
+
+// validateTrailers returns why fields cannot be the trailers of a request,
+// or "" if they can.
+func validateTrailers(fields []hpack.HeaderField, endStream bool) string {
+	if !endStream {
+		return "trailers without END_STREAM"
+	}
+	for _, f := range fields {
+		if problems := validateFieldName(f.Name); len(problems) > 0 {
+			return problems[0]
+		}
+		if strings.HasPrefix(f.Name, ":") {
+			return fmt.Sprintf("pseudo-header field %s in trailers", f.Name)
+		}
+	}
+	return ""
+}
+
+// validateFieldName checks that a header field name is in lowercase, as
+// RFC 7540 Section 8.1.2 requires.
+func validateFieldName(name string) []string {
+	if name != strings.ToLower(name) {
+		return []string{fmt.Sprintf("header field name %q contains uppercase characters", name)}
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package cases
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// lingerTimeout bounds how long a test case keeps reading after its last
+// frame, so that the response is not cut off by a reset of the connection.
+const lingerTimeout = 2 * time.Second
+
+// readUntil reads frames from the client until match reports true for one
+// of them and returns that frame.
+func readUntil(framer *http2.Framer, match func(http2.Frame) bool) (http2.Frame, error) {
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		if match(frame) {
+			return frame, nil
+		}
+		log.Printf("Ignoring frame of type %T while waiting for the client.", frame)
+	}
+}
+
+// awaitRequest waits for the HEADERS frame that opens the client's request
+// and returns its stream identifier.
+func awaitRequest(framer *http2.Framer) (uint32, error) {
+	frame, err := readUntil(framer, func(f http2.Frame) bool {
+		_, ok := f.(*http2.HeadersFrame)
+		return ok
+	})
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("Received request HEADERS on stream %d.", frame.Header().StreamID)
+	return frame.Header().StreamID, nil
+}
+
+// awaitRequestHeaders waits for the HEADERS that open the client's request
+// and returns them, decoded. A header block the framer rejects as malformed
+// comes back as an http2.StreamError naming the stream. The framer keeps
+// its decoder, and so the client's dynamic table, across requests.
+func awaitRequestHeaders(framer *http2.Framer) (*http2.MetaHeadersFrame, error) {
+	if framer.ReadMetaHeaders == nil {
+		framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	}
+	frame, err := readUntil(framer, func(f http2.Frame) bool {
+		_, ok := f.(*http2.MetaHeadersFrame)
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+	headers := frame.(*http2.MetaHeadersFrame)
+	log.Printf("Received %s request for %s%s on stream %d.", headers.PseudoValue("method"), headers.PseudoValue("authority"), headers.PseudoValue("path"), headers.StreamID)
+	return headers, nil
+}
+
+// serveCheckedRequests answers n requests of the client in turn with "ok",
+// after check has graded the decoded header block of each. A header block
+// the framer rejects as malformed fails the client, citing section of RFC
+// 7540, and is answered all the same.
+func serveCheckedRequests(conn net.Conn, framer *http2.Framer, n int, section string, check func(headers *http2.MetaHeadersFrame)) {
+	for i := 0; i < n; i++ {
+		var streamID uint32
+		headers, err := awaitRequestHeaders(framer)
+		var se http2.StreamError
+		switch {
+		case errors.As(err, &se):
+			reportViolation(conn, "malformed request header block on stream %d: %v (RFC 7540 Section %s)", se.StreamID, se.Cause, section)
+			streamID = se.StreamID
+		case err != nil:
+			log.Printf("Failed to read request HEADERS: %v", err)
+			return
+		default:
+			check(headers)
+			streamID = headers.StreamID
+		}
+		if err := writeResponse(framer, streamID, "ok"); err != nil {
+			log.Printf("Failed to write response: %v", err)
+			return
+		}
+		log.Printf("Sent response on stream %d.", streamID)
+	}
+	linger(conn, framer)
+}
+
+// awaitRequestAndAcks waits until the client has opened its request and
+// acknowledged acks SETTINGS frames, counting the ACK of the initial server
+// SETTINGS. It returns the stream identifier of the request.
+func awaitRequestAndAcks(framer *http2.Framer, acks int) (uint32, error) {
+	var streamID uint32
+	for streamID == 0 || acks > 0 {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return 0, err
+		}
+		switch f := frame.(type) {
+		case *http2.HeadersFrame:
+			streamID = f.StreamID
+			log.Printf("Received request HEADERS on stream %d.", streamID)
+		case *http2.SettingsFrame:
+			if f.IsAck() {
+				acks--
+				log.Println("Received SETTINGS ACK.")
+			}
+		default:
+			log.Printf("Ignoring frame of type %T while waiting for the client.", f)
+		}
+	}
+	return streamID, nil
+}
+
+// encodeHeaders returns the HPACK encoding of fields, using a fresh encoder
+// so that the block only references the static table.
+func encodeHeaders(fields ...hpack.HeaderField) []byte {
+	var buf bytes.Buffer
+	encoder := hpack.NewEncoder(&buf)
+	for _, f := range fields {
+		encoder.WriteField(f)
+	}
+	return buf.Bytes()
+}
+
+// writeResponse writes a complete 200 response carrying body on streamID.
+func writeResponse(framer *http2.Framer, streamID uint32, body string) error {
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: encodeHeaders(hpack.HeaderField{Name: ":status", Value: "200"}),
+		EndHeaders:    true,
+	}); err != nil {
+		return err
+	}
+	return framer.WriteData(streamID, true, []byte(body))
+}
+
+// skipHeader is the response header field with which the harness tells the
+// verifier that it skipped the test case, and why.
+const skipHeader = "x-harness-skipped"
+
+// skipRequest logs that the client's settings leave nothing for the test
+// case to check, answers the request on streamID with a 200 response that
+// carries the reason in skipHeader, and lingers until the client is done.
+func skipRequest(conn net.Conn, framer *http2.Framer, streamID uint32, reason string) {
+	log.Printf("Skipping the test case: %s.", reason)
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID: streamID,
+		BlockFragment: encodeHeaders(
+			hpack.HeaderField{Name: ":status", Value: "200"},
+			hpack.HeaderField{Name: skipHeader, Value: reason},
+		),
+		EndStream:  true,
+		EndHeaders: true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	linger(conn, framer)
+}
+
+// serveRequest waits for the client's request, answers it with a complete
+// 200 response carrying body and lingers until the client is done.
+func serveRequest(conn net.Conn, framer *http2.Framer, body string) {
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	if err := writeResponse(framer, streamID, body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Printf("Sent response on stream %d.", streamID)
+	linger(conn, framer)
+}
+
+// linger keeps reading frames until the client closes the connection or
+// lingerTimeout expires.
+func linger(conn net.Conn, framer *http2.Framer) {
+	conn.SetReadDeadline(time.Now().Add(lingerTimeout))
+	for {
+		if _, err := framer.ReadFrame(); err != nil {
+			return
+		}
+	}
+}
+
+// appendHPACKInt appends v as an HPACK integer with an n-bit prefix
+// (RFC 7541 Section 5.1). first carries the representation's pattern bits
+// above the prefix.
+func appendHPACKInt(dst []byte, n uint8, first byte, v uint64) []byte {
+	max := uint64(1)<<n - 1
+	if v < max {
+		return append(dst, first|byte(v))
+	}
+	dst = append(dst, first|byte(max))
+	for v -= max; v >= 128; v >>= 7 {
+		dst = append(dst, byte(v&0x7f)|0x80)
+	}
+	return append(dst, byte(v))
+}
+
+// tableSizeUpdate returns a Dynamic Table Size Update to size.
+func tableSizeUpdate(size uint32) []byte {
+	return appendHPACKInt(nil, 5, 0x20, uint64(size))
+}
+
+// writeHeaderBlock writes block on streamID as a HEADERS frame followed by
+// as many CONTINUATION frames as maxFrameSize requires.
+func writeHeaderBlock(framer *http2.Framer, streamID uint32, block []byte, endStream bool, maxFrameSize uint32) error {
+	first := block
+	if uint32(len(first)) > maxFrameSize {
+		first = block[:maxFrameSize]
+	}
+	block = block[len(first):]
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: first,
+		EndStream:     endStream,
+		EndHeaders:    len(block) == 0,
+	}); err != nil {
+		return err
+	}
+	for len(block) > 0 {
+		fragment := block
+		if uint32(len(fragment)) > maxFrameSize {
+			fragment = block[:maxFrameSize]
+		}
+		block = block[len(fragment):]
+		if err := framer.WriteContinuation(streamID, len(block) == 0, fragment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// headerListOfSize returns a response header list whose size, as defined for
+// SETTINGS_MAX_HEADER_LIST_SIZE (RFC 7540 Section 6.5.2), is exactly size.
+// The list repeats one filler field of entrySize octets so that an encoder
+// with a dynamic table of at least entrySize octets sends it by index.
+func headerListOfSize(size, entrySize uint32) []hpack.HeaderField {
+	const name, rest = "x-fill", "x-rest"
+	status := hpack.HeaderField{Name: ":status", Value: "200"}
+	fields := []hpack.HeaderField{status}
+	remaining := size - status.Size()
+	fill := hpack.HeaderField{Name: name, Value: strings.Repeat("f", int(entrySize)-32-len(name))}
+	for remaining >= 2*entrySize {
+		fields = append(fields, fill)
+		remaining -= entrySize
+	}
+	return append(fields, hpack.HeaderField{Name: rest, Value: strings.Repeat("r", int(remaining)-32-len(rest))})
+}
+
+// encodeHeaderList encodes fields with a dynamic table no larger than the
+// client's SETTINGS_HEADER_TABLE_SIZE, so that repeated fields are indexed.
+func encodeHeaderList(settings ClientSettings, fields []hpack.HeaderField) []byte {
+	var buf bytes.Buffer
+	encoder := hpack.NewEncoder(&buf)
+	if size := settings.HeaderTableSize(); size < 4096 {
+		encoder.SetMaxDynamicTableSizeLimit(size)
+	}
+	for _, f := range fields {
+		encoder.WriteField(f)
+	}
+	return buf.Bytes()
+}
+
+// patternBody returns n octets of a deterministic, non-repeating pattern,
+// so that lost, duplicated or reordered DATA show up in the body's digest.
+func patternBody(n int) []byte {
+	body := make([]byte, n)
+	x := uint32(2463534242)
+	for i := range body {
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+		body[i] = byte(x)
+	}
+	return body
+}
+
+// serveRequests answers n requests from the client in turn with complete
+// 200 responses carrying body, then lingers until the client is done.
+func serveRequests(conn net.Conn, framer *http2.Framer, n int, body string) {
+	for i := 0; i < n; i++ {
+		streamID, err := awaitRequest(framer)
+		if err != nil {
+			log.Printf("Failed to read request %d of %d: %v", i+1, n, err)
+			return
+		}
+		if err := writeResponse(framer, streamID, body); err != nil {
+			log.Printf("Failed to write response: %v", err)
+			return
+		}
+		log.Printf("Sent response %d of %d on stream %d.", i+1, n, streamID)
+	}
+	linger(conn, framer)
+}
+
+// serveTableResizes answers len(sizes)+1 requests from the client in turn.
+// Before answering request i it announces sizes[i] as the server's
+// SETTINGS_HEADER_TABLE_SIZE and waits for the ACK, so that the client's
+// encoder must signal the new size in the header block of request i+1.
+func serveTableResizes(conn net.Conn, framer *http2.Framer, sizes ...uint32) {
+	for i := 0; i <= len(sizes); i++ {
+		streamID, err := awaitRequest(framer)
+		if err != nil {
+			log.Printf("Failed to read request %d: %v", i+1, err)
+			return
+		}
+		if i < len(sizes) {
+			if err := framer.WriteSettings(http2.Setting{ID: http2.SettingHeaderTableSize, Val: sizes[i]}); err != nil {
+				log.Printf("Failed to write SETTINGS frame: %v", err)
+				return
+			}
+			if _, err := readUntil(framer, func(f http2.Frame) bool {
+				s, ok := f.(*http2.SettingsFrame)
+				return ok && s.IsAck()
+			}); err != nil {
+				log.Printf("Failed to read SETTINGS ACK: %v", err)
+				return
+			}
+			log.Printf("Client acknowledged a SETTINGS_HEADER_TABLE_SIZE of %d.", sizes[i])
+		}
+		if err := writeResponse(framer, streamID, "ok"); err != nil {
+			log.Printf("Failed to write response: %v", err)
+			return
+		}
+		log.Printf("Sent response on stream %d.", streamID)
+	}
+	linger(conn, framer)
+}
+
+// serveHeaderLength waits for the client's request and answers it with a
+// 200 response whose body is the length of the value of the header field
+// name, as the harness decoded it, or -1 if the request lacks the field.
+func serveHeaderLength(conn net.Conn, framer *http2.Framer, name string) {
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	frame, err := readUntil(framer, func(f http2.Frame) bool {
+		_, ok := f.(*http2.MetaHeadersFrame)
+		return ok
+	})
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	headers := frame.(*http2.MetaHeadersFrame)
+	length := -1
+	for _, f := range headers.RegularFields() {
+		if f.Name == name {
+			length = len(f.Value)
+		}
+	}
+	log.Printf("Received request HEADERS on stream %d with a %s of %d octets.", headers.StreamID, name, length)
+	if err := writeResponse(framer, headers.StreamID, strconv.Itoa(length)); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	linger(conn, framer)
+}
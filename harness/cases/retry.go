@@ -0,0 +1,260 @@
+package cases
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	// retryWindow is how long the harness waits for the client to send a
+	// reset request again before it concludes that the client gave up.
+	retryWindow = 2500 * time.Millisecond
+	// retryTimeout bounds how long the harness serves a request and its
+	// retries, over all of the client's connections.
+	retryTimeout = 8 * time.Second
+)
+
+// resetPlan describes how the harness resets the client's request and the
+// attempts the client makes to send it again.
+type resetPlan struct {
+	// code is the error code of every RST_STREAM.
+	code http2.ErrCode
+	// resets is the number of attempts the harness resets before it
+	// answers one.
+	resets int
+}
+
+// retryTally follows one request of the client over its attempts, on every
+// connection the client opens for it.
+type retryTally struct {
+	plan     resetPlan
+	deadline time.Time
+	done     chan struct{}
+
+	mu       sync.Mutex
+	attempts int
+	last     time.Time
+	answered bool
+	http1    bool
+	// highest holds the highest stream each connection sent the request
+	// on.
+	highest map[int]uint32
+}
+
+// attempt records that the client sent the request on streamID of conn and
+// reports whether the harness resets it. It fails the client for sending
+// the request again on a stream it already used, and logs a request that is
+// not idempotent sent again after a reset that does not guarantee the
+// request went unprocessed: RFC 7540 does not forbid that retry, but RFC
+// 9110 Section 9.2.2 advises against it.
+func (t *retryTally) attempt(conn net.Conn, streamID uint32, method string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.attempts++
+	id := connectionID(conn)
+	if streamID <= t.highest[id] {
+		reportViolation(conn, "client sent the request again on stream %d of connection %d, which it already used; a retried request needs a new stream (RFC 7540 Section 5.1.1)", streamID, id)
+	}
+	t.highest[id] = max(t.highest[id], streamID)
+	if t.attempts > 1 {
+		log.Printf("Attempt %d: %s on stream %d of connection %d, %v after RST_STREAM %v.", t.attempts, method, streamID, id, time.Since(t.last).Round(time.Millisecond), t.plan.code)
+		// RFC 7540 Section 8.1.4: only REFUSED_STREAM guarantees that
+		// the server did not process the request.
+		if t.plan.code != http2.ErrCodeRefusedStream && !idempotent(method) {
+			log.Printf("The client sent a %s request again after RST_STREAM %v, which does not guarantee that the server left it unprocessed; RFC 9110 Section 9.2.2 advises against retrying a request that is not idempotent.", method, t.plan.code)
+		}
+	}
+	t.last = time.Now()
+	if t.attempts > t.plan.resets {
+		t.answered = true
+		return false
+	}
+	return true
+}
+
+// finished reports whether the client got its response, gave up on the
+// request or ran out of time.
+func (t *retryTally) finished() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.answered || (t.attempts > 0 && time.Since(t.last) > retryWindow) || time.Now().After(t.deadline)
+}
+
+// serveResets serves the client's request on conn according to plan, and
+// its retries on conn and on any further connections the client opens,
+// including HTTP/1.1 ones. It logs whether the client retried the request,
+// gave up, or fell back to HTTP/1.1, and how long it waited before each
+// attempt. RFC 7540 Section 8.1.4 allows the client to retry a request
+// refused with REFUSED_STREAM but does not require it, so a client that
+// fails the request is only logged.
+func serveResets(conn net.Conn, framer *http2.Framer, plan resetPlan) {
+	t := &retryTally{
+		plan:     plan,
+		deadline: time.Now().Add(retryTimeout),
+		done:     make(chan struct{}),
+		highest:  make(map[int]uint32),
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t.serve(conn, framer)
+	}()
+
+	c, _ := conn.(*Conn)
+	for !t.finished() {
+		if c == nil || c.Accept == nil {
+			time.Sleep(batchWindow)
+			continue
+		}
+		next, nextFramer, err := c.Accept(batchWindow)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to accept another connection: %v", err)
+			c = nil
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if next.Protocol == "http/1.1" {
+				t.serveHTTP1(next)
+				return
+			}
+			t.serve(next, nextFramer)
+		}()
+	}
+	close(t.done)
+	wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case t.http1:
+		log.Printf("The client fell back to HTTP/1.1 after RST_STREAM %v.", plan.code)
+	case t.answered:
+		log.Printf("The client got its response on attempt %d.", t.attempts)
+	case t.attempts == 1 && plan.code == http2.ErrCodeRefusedStream:
+		log.Println("The client did not send the request again after RST_STREAM REFUSED_STREAM, although it guarantees that the server left the request unprocessed and the client may retry it (RFC 7540 Section 8.1.4).")
+	case t.attempts == 1:
+		log.Printf("The client did not send the request again after RST_STREAM %v.", plan.code)
+	default:
+		log.Printf("The client gave up after %d attempts.", t.attempts)
+	}
+}
+
+// serve resets or answers every request on one of the client's HTTP/2
+// connections until the request is done, and lingers once it has answered
+// one.
+func (t *retryTally) serve(conn net.Conn, framer *http2.Framer) {
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	methods := make(map[uint32]string)
+	for {
+		select {
+		case <-t.done:
+			return
+		default:
+		}
+		conn.SetReadDeadline(time.Now().Add(batchWindow))
+		frame, err := framer.ReadFrame()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Stopped reading from the client: %v", err)
+			return
+		}
+		var streamID uint32
+		switch f := frame.(type) {
+		case *http2.MetaHeadersFrame:
+			methods[f.StreamID] = f.PseudoValue("method")
+			if !f.StreamEnded() {
+				continue
+			}
+			streamID = f.StreamID
+		case *http2.DataFrame:
+			if _, ok := methods[f.StreamID]; !ok || !f.StreamEnded() {
+				continue
+			}
+			streamID = f.StreamID
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+			continue
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+			continue
+		default:
+			continue
+		}
+
+		// The request is complete: reset or answer it.
+		method := methods[streamID]
+		delete(methods, streamID)
+		if !t.attempt(conn, streamID, method) {
+			if err := writeResponse(framer, streamID, "ok"); err != nil {
+				log.Printf("Failed to write response: %v", err)
+				return
+			}
+			log.Printf("Sent response on stream %d.", streamID)
+			linger(conn, framer)
+			return
+		}
+		if err := framer.WriteRSTStream(streamID, t.plan.code); err != nil {
+			log.Printf("Failed to write RST_STREAM frame: %v", err)
+			return
+		}
+		log.Printf("Reset %s request on stream %d with %v.", method, streamID, t.plan.code)
+	}
+}
+
+// serveHTTP1 answers the request the client sends again over HTTP/1.1.
+func (t *retryTally) serveHTTP1(conn net.Conn) {
+	conn.SetDeadline(time.Now().Add(retryWindow))
+	req, err := http.ReadRequest(bufio.NewReader(conn))
+	if err != nil {
+		log.Printf("Failed to read HTTP/1.1 request: %v", err)
+		return
+	}
+	t.mu.Lock()
+	t.attempts++
+	log.Printf("Attempt %d: %s over HTTP/1.1 on connection %d, %v after RST_STREAM %v.", t.attempts, req.Method, connectionID(conn), time.Since(t.last).Round(time.Millisecond), t.plan.code)
+	t.http1, t.answered = true, true
+	t.mu.Unlock()
+	if _, err := fmt.Fprint(conn, "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok"); err != nil {
+		log.Printf("Failed to write HTTP/1.1 response: %v", err)
+	}
+}
+
+// connectionID returns the number of the client connection conn.
+func connectionID(conn net.Conn) int {
+	if c, ok := conn.(*Conn); ok {
+		return c.ID
+	}
+	return 1
+}
+
+// idempotent reports whether a request with method can be repeated without
+// changing its outcome (RFC 7231 Section 4.2.2).
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package cases
+
+import (
+	"log"
+	"net"
+
+	"golang.org/x/net/http2"
+)
+
+// altSvcValue advertises the harness under a name that resolves to it, as
+// an alternative service for an hour.
+const altSvcValue = `h2="alt.test:8080"; ma=3600`
+
+// Test Case rfc7838/4/1: Sends an ALTSVC frame on stream 0 for the harness's origin, then serves a follow-up request.
+// The client may use the alternative service for the follow-up or ignore the frame; it is expected to get both responses.
+func RunTestRfc7838_4_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc7838/4/1...")
+	serveExtensionFrame(conn, framer, extensionFramePlan{typ: frameAltSvc, payload: altSvcPayload("https://127.0.0.1:8080", altSvcValue)})
+}
+
+// Test Case rfc7838/4/2: Sends an ALTSVC frame with an empty origin on the stream of the client's request, then serves a follow-up request.
+// The frame applies to the origin of the request; the client is expected to get both responses.
+func RunTestRfc7838_4_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc7838/4/2...")
+	serveExtensionFrame(conn, framer, extensionFramePlan{typ: frameAltSvc, onRequestStream: true, payload: altSvcPayload("", altSvcValue)})
+}
+
+// Test Case rfc7838/4/3: Sends an ALTSVC frame with an empty origin on stream 0, then serves a follow-up request.
+// Such a frame is invalid and must be ignored; the client is expected to get both responses.
+func RunTestRfc7838_4_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc7838/4/3...")
+	serveExtensionFrame(conn, framer, extensionFramePlan{typ: frameAltSvc, payload: altSvcPayload("", altSvcValue)})
+}
+
+// Test Case rfc7838/4/4: Sends an ALTSVC frame naming an origin on the stream of the client's request, then serves a follow-up request.
+// Such a frame is invalid and must be ignored; the client is expected to get both responses.
+func RunTestRfc7838_4_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc7838/4/4...")
+	serveExtensionFrame(conn, framer, extensionFramePlan{typ: frameAltSvc, onRequestStream: true, payload: altSvcPayload("https://127.0.0.1:8080", altSvcValue)})
+}
+
+// Test Case rfc7838/4/5: Sends an ALTSVC frame on stream 0 whose Origin-Len runs past the end of its payload, then serves a follow-up request.
+// The client is expected to ignore the malformed frame and get both responses.
+func RunTestRfc7838_4_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc7838/4/5...")
+	payload := altSvcPayload("https://127.0.0.1:8080", "")
+	payload[1] += 16
+	serveExtensionFrame(conn, framer, extensionFramePlan{typ: frameAltSvc, payload: payload})
+}
+
+// Test Case rfc7838/4/6: Sends an ALTSVC frame on stream 0 with a payload of one octet, too short for an Origin-Len, then serves a follow-up request.
+// The client is expected to ignore the malformed frame and get both responses.
+func RunTestRfc7838_4_6(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc7838/4/6...")
+	serveExtensionFrame(conn, framer, extensionFramePlan{typ: frameAltSvc, payload: []byte{0x00}})
+}
@@ -0,0 +1,38 @@
+package cases
+
+import (
+	"log"
+	"net"
+
+	"golang.org/x/net/http2"
+)
+
+// Test Case rfc8336/2.1/1: Sends an ORIGIN frame whose Origin-Len runs past the end of its payload, then serves a follow-up request.
+// The client is expected to ignore the malformed frame and get both responses; the harness fails it for sending the follow-up on the connection of the frame, whose certificate does not cover origin.test.
+func RunTestRfc8336_2_1_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc8336/2.1/1...")
+	payload := originPayload(followUpOrigin)
+	payload[1] += 16
+	serveExtensionFrame(conn, framer, extensionFramePlan{typ: frameOrigin, ignored: true, payload: payload})
+}
+
+// Test Case rfc8336/2.1/2: Sends an ORIGIN frame with a payload of one octet, too short for an Origin-Len, then serves a follow-up request.
+// The client is expected to ignore the malformed frame and get both responses; the harness fails it for sending the follow-up on the connection of the frame, whose certificate does not cover origin.test.
+func RunTestRfc8336_2_1_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc8336/2.1/2...")
+	serveExtensionFrame(conn, framer, extensionFramePlan{typ: frameOrigin, ignored: true, payload: []byte{0x00}})
+}
+
+// Test Case rfc8336/2.2/1: Sends an ORIGIN frame on the stream of the client's request instead of stream 0, then serves a follow-up request.
+// An ORIGIN frame on any other stream than 0 must be ignored; the client is expected to get both responses, and the harness fails it for sending the follow-up on the connection of the frame.
+func RunTestRfc8336_2_2_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc8336/2.2/1...")
+	serveExtensionFrame(conn, framer, extensionFramePlan{typ: frameOrigin, onRequestStream: true, ignored: true, payload: originPayload("https://127.0.0.1:8080", followUpOrigin)})
+}
+
+// Test Case rfc8336/2.3/1: Sends an ORIGIN frame on stream 0 that adds origin.test:8080 to the connection's origin set, then serves a follow-up request for it.
+// The client may coalesce the follow-up onto the connection or open another one; it is expected to get both responses.
+func RunTestRfc8336_2_3_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc8336/2.3/1...")
+	serveExtensionFrame(conn, framer, extensionFramePlan{typ: frameOrigin, payload: originPayload("https://127.0.0.1:8080", followUpOrigin)})
+}
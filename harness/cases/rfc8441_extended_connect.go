@@ -0,0 +1,236 @@
+package cases
+
+import (
+	"encoding/binary"
+	"log"
+	"net"
+
+	"golang.org/x/net/http2"
+)
+
+// WebSocket opcodes (RFC 6455 Section 5.2).
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+)
+
+// enableConnectProtocol is the setting that lets the client send extended
+// CONNECT requests (RFC 8441 Section 3).
+var enableConnectProtocol = http2.Setting{ID: http2.SettingEnableConnectProtocol, Val: 1}
+
+// wsFrame is a WebSocket frame.
+type wsFrame struct {
+	opcode  byte
+	masked  bool
+	payload []byte
+}
+
+// appendWSFrame appends a final, unmasked WebSocket frame, as a server
+// sends it.
+func appendWSFrame(dst []byte, opcode byte, payload []byte) []byte {
+	dst = append(dst, 0x80|opcode)
+	switch n := len(payload); {
+	case n < 126:
+		dst = append(dst, byte(n))
+	case n <= 0xffff:
+		dst = append(dst, 126)
+		dst = binary.BigEndian.AppendUint16(dst, uint16(n))
+	default:
+		dst = append(dst, 127)
+		dst = binary.BigEndian.AppendUint64(dst, uint64(n))
+	}
+	return append(dst, payload...)
+}
+
+// wsDecoder reassembles the WebSocket frames a client tunnels in DATA
+// frames cut at arbitrary boundaries.
+type wsDecoder struct {
+	buf []byte
+}
+
+// write adds data to the decoder and returns every frame it completes,
+// unmasked.
+func (d *wsDecoder) write(data []byte) []wsFrame {
+	d.buf = append(d.buf, data...)
+	var frames []wsFrame
+	for len(d.buf) >= 2 {
+		masked, n, header := d.buf[1]&0x80 != 0, uint64(d.buf[1]&0x7f), 2
+		switch n {
+		case 126:
+			if len(d.buf) < 4 {
+				return frames
+			}
+			n, header = uint64(binary.BigEndian.Uint16(d.buf[2:])), 4
+		case 127:
+			if len(d.buf) < 10 {
+				return frames
+			}
+			n, header = binary.BigEndian.Uint64(d.buf[2:]), 10
+		}
+		var key []byte
+		if masked {
+			if len(d.buf) < header+4 {
+				return frames
+			}
+			key, header = d.buf[header:header+4], header+4
+		}
+		if uint64(len(d.buf)-header) < n {
+			return frames
+		}
+		f := wsFrame{opcode: d.buf[0] & 0x0f, masked: masked, payload: make([]byte, n)}
+		copy(f.payload, d.buf[header:])
+		for i := range f.payload {
+			if masked {
+				f.payload[i] ^= key[i%4]
+			}
+		}
+		frames = append(frames, f)
+		d.buf = d.buf[header+int(n):]
+	}
+	return frames
+}
+
+// webSocketPlan describes how the harness answers the client's WebSocket.
+type webSocketPlan struct {
+	// status answers the extended CONNECT. Any status but 200 ends the
+	// stream at once.
+	status string
+	// cut ends the stream halfway through the echo of the first message.
+	cut bool
+	// reset resets the stream with RST_STREAM CANCEL after echoing the
+	// first message.
+	reset bool
+}
+
+// serveWebSocket waits for the client's extended CONNECT for the websocket
+// protocol and, with status 200, echoes every WebSocket message the client
+// sends until it answers the client's close frame and ends the stream.
+// Every frame the client sends must be masked (RFC 6455 Section 5.1).
+func serveWebSocket(conn net.Conn, framer *http2.Framer, plan webSocketPlan) {
+	headers, err := awaitConnect(conn, framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	streamID := headers.StreamID
+	if headers.PseudoValue("method") != "CONNECT" || headers.PseudoValue("protocol") != "websocket" {
+		log.Printf("Expected an extended CONNECT for the websocket protocol; answering 400.")
+		writeStatus(framer, streamID, "400", true)
+		linger(conn, framer)
+		return
+	}
+	for _, f := range headers.RegularFields() {
+		if f.Name == "sec-websocket-version" {
+			log.Printf("The client asked for WebSocket version %s.", f.Value)
+		}
+	}
+	if plan.status != "200" {
+		if err := writeStatus(framer, streamID, plan.status, true); err != nil {
+			log.Printf("Failed to write HEADERS frame: %v", err)
+			return
+		}
+		log.Printf("Refused the WebSocket on stream %d with status %s.", streamID, plan.status)
+		linger(conn, framer)
+		return
+	}
+	if err := writeStatus(framer, streamID, "200", false); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	log.Printf("Opened the WebSocket on stream %d.", streamID)
+
+	var d wsDecoder
+	relayTunnel(conn, framer, streamID, func(data []byte, end bool) ([]byte, bool) {
+		var reply []byte
+		for _, f := range d.write(data) {
+			if !f.masked {
+				reportViolation(conn, "unmasked WebSocket frame with opcode 0x%x on stream %d (RFC 6455 Section 5.1)", f.opcode, streamID)
+			}
+			switch f.opcode {
+			case wsClose:
+				log.Printf("Received a WebSocket close frame on stream %d; answering it and ending the stream.", streamID)
+				if err := framer.WriteData(streamID, true, append(reply, appendWSFrame(nil, wsClose, f.payload)...)); err != nil {
+					log.Printf("Failed to write DATA frame: %v", err)
+				}
+				return nil, true
+			case wsText:
+				log.Printf("Echoing a WebSocket message of %d octets on stream %d.", len(f.payload), streamID)
+				echo := appendWSFrame(nil, wsText, f.payload)
+				switch {
+				case plan.cut:
+					framer.WriteData(streamID, true, append(reply, echo[:len(echo)/2]...))
+					log.Printf("Ended stream %d halfway through the echo.", streamID)
+					return nil, true
+				case plan.reset:
+					framer.WriteData(streamID, false, append(reply, echo...))
+					framer.WriteRSTStream(streamID, http2.ErrCodeCancel)
+					log.Printf("Reset stream %d with CANCEL after the echo.", streamID)
+					return nil, true
+				}
+				reply = append(reply, echo...)
+			default:
+				log.Printf("Ignoring a WebSocket frame with opcode 0x%x on stream %d.", f.opcode, streamID)
+			}
+		}
+		return reply, false
+	})
+	linger(conn, framer)
+}
+
+// PrefaceRfc8441_3_1 enables extended CONNECT in the server preface. Test
+// cases rfc8441/5/1 to rfc8441/5/3 share it.
+func PrefaceRfc8441_3_1(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, enableConnectProtocol)
+}
+
+// Test Case rfc8441/3/1: Enables extended CONNECT, accepts the client's WebSocket and echoes its messages until it closes.
+// The client must open the WebSocket with :protocol websocket, tunnel masked frames in DATA and complete the close handshake.
+func RunTestRfc8441_3_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc8441/3/1...")
+	serveWebSocket(conn, framer, webSocketPlan{status: "200"})
+}
+
+// Test Case rfc8441/3/2: Leaves SETTINGS_ENABLE_CONNECT_PROTOCOL out of the server preface.
+// The client must not send an extended CONNECT; the request validator reports one that does.
+func RunTestRfc8441_3_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc8441/3/2...")
+	serveWebSocket(conn, framer, webSocketPlan{status: "200"})
+}
+
+// PrefaceRfc8441_3_3 enables extended CONNECT in the server preface and
+// withdraws it in a second SETTINGS frame.
+func PrefaceRfc8441_3_3(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, enableConnectProtocol)
+	if err := framer.WriteSettings(http2.Setting{ID: http2.SettingEnableConnectProtocol, Val: 0}); err != nil {
+		log.Printf("Failed to write SETTINGS frame: %v", err)
+	}
+}
+
+// Test Case rfc8441/3/3: Enables extended CONNECT and then withdraws it with a SETTINGS_ENABLE_CONNECT_PROTOCOL of 0.
+// A server must not withdraw the setting. The client may detect a PROTOCOL_ERROR or ignore the withdrawal and open
+// the WebSocket, which the harness serves; a client that takes the setting as withdrawn cannot open it.
+func RunTestRfc8441_3_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc8441/3/3...")
+	serveWebSocket(conn, framer, webSocketPlan{status: "200"})
+}
+
+// Test Case rfc8441/5/1: Refuses the client's WebSocket with a 404 response.
+// The client must report the refusal instead of treating the stream as an open WebSocket.
+func RunTestRfc8441_5_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc8441/5/1...")
+	serveWebSocket(conn, framer, webSocketPlan{status: "404"})
+}
+
+// Test Case rfc8441/5/2: Ends the stream halfway through the echo of the client's first WebSocket message.
+// The client must report the WebSocket as cut short rather than deliver a partial message.
+func RunTestRfc8441_5_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc8441/5/2...")
+	serveWebSocket(conn, framer, webSocketPlan{status: "200", cut: true})
+}
+
+// Test Case rfc8441/5/3: Resets the stream with RST_STREAM CANCEL after echoing the client's first WebSocket message.
+// The client must deliver the echo and then report the WebSocket as aborted, not closed cleanly.
+func RunTestRfc8441_5_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc8441/5/3...")
+	serveWebSocket(conn, framer, webSocketPlan{status: "200", reset: true})
+}
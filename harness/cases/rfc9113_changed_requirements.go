@@ -0,0 +1,186 @@
+package cases
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// settingNoRFC7540Priorities is SETTINGS_NO_RFC7540_PRIORITIES (RFC 9113
+// Section 5.3.2), which the http2 package does not define.
+const settingNoRFC7540Priorities http2.SettingID = 0x9
+
+// serveFieldValue waits for the client's request and answers it with a
+// complete 200 response carrying body "ok" and an x-value header field
+// whose value is value.
+func serveFieldValue(conn net.Conn, framer *http2.Framer, value string) {
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID: streamID,
+		BlockFragment: encodeHeaders(
+			hpack.HeaderField{Name: ":status", Value: "200"},
+			hpack.HeaderField{Name: "x-value", Value: value},
+		),
+		EndHeaders: true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, true, []byte("ok")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	log.Printf("Sent response with x-value %q on stream %d.", value, streamID)
+	linger(conn, framer)
+}
+
+// Test Case rfc9113/8.2.1/1: Sends a response header field whose value starts with a space.
+// The client is expected to treat the response as malformed and detect a PROTOCOL_ERROR.
+func RunTestRfc9113_8_2_1_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9113/8.2.1/1...")
+	serveFieldValue(conn, framer, " leading")
+}
+
+// Test Case rfc9113/8.2.1/2: Sends a response header field whose value ends with a horizontal tab.
+// The client is expected to treat the response as malformed and detect a PROTOCOL_ERROR.
+func RunTestRfc9113_8_2_1_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9113/8.2.1/2...")
+	serveFieldValue(conn, framer, "trailing\t")
+}
+
+// Test Case rfc9113/8.2.1/3: Sends a response header field whose value contains a NUL character.
+// The client is expected to treat the response as malformed and detect a PROTOCOL_ERROR.
+func RunTestRfc9113_8_2_1_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9113/8.2.1/3...")
+	serveFieldValue(conn, framer, "nul\x00value")
+}
+
+// Test Case rfc9113/8.2.1/4: Sends a response header field whose value contains a CR character.
+// The client is expected to treat the response as malformed and detect a PROTOCOL_ERROR.
+func RunTestRfc9113_8_2_1_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9113/8.2.1/4...")
+	serveFieldValue(conn, framer, "cr\rvalue")
+}
+
+// Test Case rfc9113/8.2.1/5: Sends a response header field whose value contains an LF character.
+// The client is expected to treat the response as malformed and detect a PROTOCOL_ERROR.
+func RunTestRfc9113_8_2_1_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9113/8.2.1/5...")
+	serveFieldValue(conn, framer, "lf\nvalue")
+}
+
+// PrefaceRfc9113_6_5_2_1 sets SETTINGS_ENABLE_PUSH to 1 in the server preface.
+func PrefaceRfc9113_6_5_2_1(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, http2.Setting{ID: http2.SettingEnablePush, Val: 1})
+}
+
+// Test Case rfc9113/6.5.2/1: Sends SETTINGS_ENABLE_PUSH with a value of 1 in the server preface.
+// A server must not set it to 1; the client is expected to detect a PROTOCOL_ERROR.
+func RunTestRfc9113_6_5_2_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9113/6.5.2/1...")
+	serveRequest(conn, framer, "ok")
+}
+
+// PrefaceRfc9113_6_5_2_2 sets SETTINGS_ENABLE_PUSH to 0 in the server preface.
+func PrefaceRfc9113_6_5_2_2(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, http2.Setting{ID: http2.SettingEnablePush, Val: 0})
+}
+
+// Test Case rfc9113/6.5.2/2: Sends SETTINGS_ENABLE_PUSH with a value of 0 in the server preface.
+// A server may send the setting as long as it is 0; the client is expected to accept it and complete the request.
+func RunTestRfc9113_6_5_2_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9113/6.5.2/2...")
+	serveRequest(conn, framer, "ok")
+}
+
+// PrefaceRfc9113_5_3_2_1 sets SETTINGS_NO_RFC7540_PRIORITIES to 1 in the server preface.
+func PrefaceRfc9113_5_3_2_1(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, http2.Setting{ID: settingNoRFC7540Priorities, Val: 1})
+}
+
+// Test Case rfc9113/5.3.2/1: Sends SETTINGS_NO_RFC7540_PRIORITIES with a value of 1 in the server preface.
+// The client is expected to accept it and complete the request; the harness fails any RFC 7540 priority signal the client sends once it has acknowledged the setting.
+func RunTestRfc9113_5_3_2_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9113/5.3.2/1...")
+	acked := false
+	// check grades one frame from the client. Until the client acknowledges
+	// the server preface it may not have seen the setting.
+	check := func(f http2.Frame) {
+		var signal string
+		switch f := f.(type) {
+		case *http2.SettingsFrame:
+			if f.IsAck() && !acked {
+				acked = true
+				log.Println("Received SETTINGS ACK; the client has seen SETTINGS_NO_RFC7540_PRIORITIES.")
+			}
+			return
+		case *http2.PriorityFrame:
+			signal = "a PRIORITY frame"
+		case *http2.HeadersFrame:
+			if !f.HasPriority() {
+				return
+			}
+			signal = "priority information in HEADERS"
+		default:
+			return
+		}
+		if !acked {
+			log.Printf("The client sent %s on stream %d before acknowledging SETTINGS_NO_RFC7540_PRIORITIES.", signal, f.Header().StreamID)
+			return
+		}
+		reportViolation(conn, "client sent %s on stream %d after acknowledging SETTINGS_NO_RFC7540_PRIORITIES of 1 (RFC 9113 Section 5.3.2)", signal, f.Header().StreamID)
+	}
+
+	frame, err := readUntil(framer, func(f http2.Frame) bool {
+		check(f)
+		_, ok := f.(*http2.HeadersFrame)
+		return ok
+	})
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	headers := frame.(*http2.HeadersFrame)
+	log.Printf("Received request HEADERS on stream %d.", headers.StreamID)
+	if err := writeResponse(framer, headers.StreamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Printf("Sent response on stream %d.", headers.StreamID)
+
+	// Keep grading what the client sends until it closes the connection.
+	conn.SetReadDeadline(time.Now().Add(lingerTimeout))
+	for {
+		f, err := framer.ReadFrame()
+		if err != nil {
+			return
+		}
+		check(f)
+	}
+}
+
+// PrefaceRfc9113_5_3_2_2 sets SETTINGS_NO_RFC7540_PRIORITIES to 2 in the server preface.
+func PrefaceRfc9113_5_3_2_2(conn net.Conn, framer *http2.Framer) {
+	writeSettingsPreface(framer, http2.Setting{ID: settingNoRFC7540Priorities, Val: 2})
+}
+
+// Test Case rfc9113/5.3.2/2: Sends SETTINGS_NO_RFC7540_PRIORITIES with a value of 2 in the server preface.
+// Only 0 and 1 are valid; the client is expected to detect a PROTOCOL_ERROR.
+func RunTestRfc9113_5_3_2_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9113/5.3.2/2...")
+	serveRequest(conn, framer, "ok")
+}
+
+// Test Case rfc9113/8.3.1/1: Serves a request the client sends for a virtual host other than the address it connected to.
+// The client must carry the virtual host in :authority; under RFC 9113 the harness fails any Host header field that differs from it.
+func RunTestRfc9113_8_3_1_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9113/8.3.1/1...")
+	serveRequest(conn, framer, "ok")
+}
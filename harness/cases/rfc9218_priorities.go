@@ -0,0 +1,158 @@
+package cases
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"github.com/nomadlabsinc/h2-client-test-harness/priority"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// priorityUpdateTimeout bounds how long rfc9218/7.1/5 waits for the
+// client to reprioritize its request before it answers it.
+const priorityUpdateTimeout = time.Second
+
+// priorityUpdatePlan describes a PRIORITY_UPDATE frame the harness sends
+// ahead of its response, relative to the stream of the client's request.
+type priorityUpdatePlan struct {
+	// onRequestStream sends the frame on the request's stream instead of
+	// stream 0.
+	onRequestStream bool
+	// prioritizedOffset is added to the request's stream identifier to
+	// give the prioritized stream.
+	prioritizedOffset uint32
+	value             string
+}
+
+// servePriorityUpdate waits for the client's request, sends the PRIORITY_UPDATE
+// frame of plan and answers the request with a complete 200 response
+// carrying body "ok".
+func servePriorityUpdate(conn net.Conn, framer *http2.Framer, plan priorityUpdatePlan) {
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	frameStream, prioritized := uint32(0), streamID+plan.prioritizedOffset
+	if plan.onRequestStream {
+		frameStream = streamID
+	}
+	if err := framer.WriteRawFrame(priority.FramePriorityUpdate, 0, frameStream, priority.EncodeUpdate(prioritized, plan.value)); err != nil {
+		log.Printf("Failed to write PRIORITY_UPDATE frame: %v", err)
+		return
+	}
+	log.Printf("Sent PRIORITY_UPDATE on stream %d for stream %d with value %q.", frameStream, prioritized, plan.value)
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Printf("Sent response on stream %d.", streamID)
+	linger(conn, framer)
+}
+
+// servePriorityHeader waits for the client's request and answers it with a
+// complete 200 response carrying body "ok" and a priority header field
+// whose value is value.
+func servePriorityHeader(conn net.Conn, framer *http2.Framer, value string) {
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID: streamID,
+		BlockFragment: encodeHeaders(
+			hpack.HeaderField{Name: ":status", Value: "200"},
+			hpack.HeaderField{Name: "priority", Value: value},
+		),
+		EndHeaders: true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if err := framer.WriteData(streamID, true, []byte("ok")); err != nil {
+		log.Printf("Failed to write DATA frame: %v", err)
+		return
+	}
+	log.Printf("Sent response with priority %q on stream %d.", value, streamID)
+	linger(conn, framer)
+}
+
+// Test Case rfc9218/4/1: Serves a request the client sends with a priority header field of "u=2, i".
+// The request validator grades the field: it must be a valid Priority Field Value, as on every request.
+func RunTestRfc9218_4_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9218/4/1...")
+	serveRequest(conn, framer, "ok")
+}
+
+// Test Case rfc9218/5/1: Sends a response with a priority header field of "u=1, i".
+// The client is expected to accept the response.
+func RunTestRfc9218_5_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9218/5/1...")
+	servePriorityHeader(conn, framer, "u=1, i")
+}
+
+// Test Case rfc9218/5/2: Sends a response with a priority header field that is not a valid Structured Field.
+// The client is expected to ignore the field and accept the response.
+func RunTestRfc9218_5_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9218/5/2...")
+	servePriorityHeader(conn, framer, "u=?, i")
+}
+
+// Test Case rfc9218/7.1/1: Sends a PRIORITY_UPDATE frame on stream 0 for the client's request, ahead of the response.
+// Only clients send PRIORITY_UPDATE; the client is expected to ignore it as an unknown frame type or to detect a PROTOCOL_ERROR.
+func RunTestRfc9218_7_1_1(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9218/7.1/1...")
+	servePriorityUpdate(conn, framer, priorityUpdatePlan{value: "u=0"})
+}
+
+// Test Case rfc9218/7.1/2: Sends a PRIORITY_UPDATE frame on the stream of the client's request instead of stream 0.
+// The client is expected to ignore it as an unknown frame type or to detect a PROTOCOL_ERROR.
+func RunTestRfc9218_7_1_2(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9218/7.1/2...")
+	servePriorityUpdate(conn, framer, priorityUpdatePlan{onRequestStream: true, value: "u=0"})
+}
+
+// Test Case rfc9218/7.1/3: Sends a PRIORITY_UPDATE frame on stream 0 whose Priority Field Value is not a valid Structured Field.
+// The client is expected to ignore it as an unknown frame type or to detect a PROTOCOL_ERROR.
+func RunTestRfc9218_7_1_3(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9218/7.1/3...")
+	servePriorityUpdate(conn, framer, priorityUpdatePlan{value: "u=0, ("})
+}
+
+// Test Case rfc9218/7.1/4: Sends a PRIORITY_UPDATE frame on stream 0 for a stream the client never opened.
+// The client is expected to ignore it as an unknown frame type or to detect a PROTOCOL_ERROR.
+func RunTestRfc9218_7_1_4(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9218/7.1/4...")
+	servePriorityUpdate(conn, framer, priorityUpdatePlan{prioritizedOffset: 100, value: "u=0"})
+}
+
+// Test Case rfc9218/7.1/5: Gives a client configured to reprioritize its request time to send PRIORITY_UPDATE before answering it.
+// The request validator grades every PRIORITY_UPDATE frame: on stream 0, for a stream other than 0, with a valid Priority Field Value.
+// It grades only clients configured to send PRIORITY_UPDATE, such as the verifier's mutant client; a client that sends none passes.
+func RunTestRfc9218_7_1_5(conn net.Conn, framer *http2.Framer) {
+	log.Println("Running test case rfc9218/7.1/5...")
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+	conn.SetReadDeadline(time.Now().Add(priorityUpdateTimeout))
+	frame, err := readUntil(framer, func(f http2.Frame) bool {
+		return f.Header().Type == priority.FramePriorityUpdate
+	})
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		log.Printf("The client sent no PRIORITY_UPDATE for stream %d within %v.", streamID, priorityUpdateTimeout)
+	} else if prioritized, value, err := priority.DecodeUpdate(frame.(*http2.UnknownFrame).Payload()); err == nil {
+		log.Printf("Received PRIORITY_UPDATE on stream %d for stream %d with value %q.", frame.Header().StreamID, prioritized, value)
+	}
+	if err := writeResponse(framer, streamID, "ok"); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Printf("Sent response on stream %d.", streamID)
+	linger(conn, framer)
+}
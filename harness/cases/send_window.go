@@ -0,0 +1,340 @@
+package cases
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// uploadPlan describes how the harness paces the request bodies a client
+// uploads to it.
+type uploadPlan struct {
+	// preface are the settings of the server connection preface.
+	preface []http2.Setting
+	// uploads is the number of requests the client uploads at once; zero
+	// means one.
+	uploads int
+	// minGrant and maxGrant bound the stream credit the harness grants
+	// each time the client exhausts its stream window: the first grant is
+	// minGrant, and every further one doubles up to maxGrant.
+	minGrant, maxGrant uint32
+	// pause delays every grant, so that the client sits blocked on an
+	// exhausted window before it must resume.
+	pause time.Duration
+	// After changeAfter octets of body, over all uploads, the harness sends
+	// change in a SETTINGS frame, while the uploads are in flight.
+	changeAfter int64
+	change      []http2.Setting
+}
+
+// writeSettingsPreface sends the server connection preface with settings
+// and acknowledges the client's initial SETTINGS.
+func writeSettingsPreface(framer *http2.Framer, settings ...http2.Setting) {
+	if err := framer.WriteSettings(settings...); err != nil {
+		log.Printf("Failed to write initial server SETTINGS frame: %v", err)
+		return
+	}
+	log.Printf("Initial server SETTINGS frame sent: %v", settings)
+	if err := framer.WriteSettingsAck(); err != nil {
+		log.Printf("Failed to acknowledge the client's SETTINGS: %v", err)
+	}
+}
+
+// sendWindows are the flow-control windows the harness has granted the
+// client for its request bodies (RFC 7540 Section 6.9.1), and the limits
+// it has set on the client's frames, under the server SETTINGS the client
+// has acknowledged. pending holds the SETTINGS sent since, in order.
+type sendWindows struct {
+	conn         int64
+	streams      map[uint32]int64
+	initial      int64
+	maxFrameSize uint32
+	pending      [][]http2.Setting
+}
+
+// newSendWindows returns the windows of a connection on which the harness
+// sent preface as its connection preface.
+func newSendWindows(preface []http2.Setting) *sendWindows {
+	w := &sendWindows{
+		conn:         65535,
+		streams:      make(map[uint32]int64),
+		initial:      65535,
+		maxFrameSize: 16384,
+	}
+	w.pending = append(w.pending, preface)
+	return w
+}
+
+// open starts the window of a new stream at the acknowledged
+// SETTINGS_INITIAL_WINDOW_SIZE.
+func (w *sendWindows) open(streamID uint32) {
+	w.streams[streamID] = w.initial
+}
+
+// apply applies settings as if the client had acknowledged them. A change
+// of SETTINGS_INITIAL_WINDOW_SIZE moves every stream window by the
+// difference, even below zero (RFC 7540 Section 6.9.2).
+func (w *sendWindows) apply(settings []http2.Setting) {
+	for _, s := range settings {
+		switch s.ID {
+		case http2.SettingInitialWindowSize:
+			for id := range w.streams {
+				w.streams[id] += int64(s.Val) - w.initial
+			}
+			w.initial = int64(s.Val)
+		case http2.SettingMaxFrameSize:
+			w.maxFrameSize = s.Val
+		}
+	}
+}
+
+// acknowledge applies the oldest pending SETTINGS once the client has
+// acknowledged them.
+func (w *sendWindows) acknowledge() {
+	if len(w.pending) == 0 {
+		log.Println("Received an unsolicited SETTINGS ACK.")
+		return
+	}
+	w.apply(w.pending[0])
+	w.pending = w.pending[1:]
+	log.Printf("Received SETTINGS ACK; the client's stream windows are now %v and its frames are limited to %d octets.", w.streams, w.maxFrameSize)
+}
+
+// allowance returns the largest window on streamID and frame size the
+// client may be using. Until it acknowledges a SETTINGS frame the client
+// may not have applied it yet, so every pending state is allowed.
+func (w *sendWindows) allowance(streamID uint32) (stream int64, maxFrameSize uint32) {
+	state := sendWindows{
+		streams:      map[uint32]int64{streamID: w.streams[streamID]},
+		initial:      w.initial,
+		maxFrameSize: w.maxFrameSize,
+	}
+	stream, maxFrameSize = state.streams[streamID], state.maxFrameSize
+	for _, settings := range w.pending {
+		state.apply(settings)
+		stream = max(stream, state.streams[streamID])
+		maxFrameSize = max(maxFrameSize, state.maxFrameSize)
+	}
+	return stream, maxFrameSize
+}
+
+// creditProbe returns the opaque data of the PING that precedes every
+// stream credit the harness grants for the upload on streamID.
+func creditProbe(streamID uint32) [8]byte {
+	probe := [8]byte{'c', 'r', 'e', 'd'}
+	binary.BigEndian.PutUint32(probe[4:], streamID)
+	return probe
+}
+
+// upload is one request body the client is sending to the harness.
+type upload struct {
+	streamID uint32
+	fields   []hpack.HeaderField
+	body     []byte
+	frames   int
+	largest  uint32
+	grant    uint32
+	grants   int
+	fenced   bool
+	done     bool
+	start    time.Time
+}
+
+// receiveUpload reads the client's requests and their bodies, paced by
+// plan, and grades every DATA frame against the windows and frame size the
+// harness allows. It answers every complete upload with a 200 response
+// that carries the SHA-256 digest of the body it received in
+// x-body-sha256.
+func receiveUpload(conn net.Conn, framer *http2.Framer, plan uploadPlan) {
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	w := newSendWindows(plan.preface)
+	expected := max(plan.uploads, 1)
+	uploads := make(map[uint32]*upload)
+
+	var (
+		received   int64
+		finished   int
+		changeSent bool
+	)
+	for {
+		conn.SetReadDeadline(time.Now().Add(stallTimeout))
+		frame, err := framer.ReadFrame()
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			fenced := false
+			for _, u := range uploads {
+				fenced = fenced || u.fenced
+			}
+			if len(w.pending) > 0 {
+				abortViolation(conn, framer, http2.ErrCodeSettingsTimeout, "upload stalled: no SETTINGS ACK and no DATA for %v after %d octets", stallTimeout, received)
+			} else if fenced {
+				abortViolation(conn, framer, http2.ErrCodeFlowControl, "upload stalled: no PING ACK for %v after %d octets", stallTimeout, received)
+			} else {
+				abortViolation(conn, framer, http2.ErrCodeFlowControl, "upload stalled: no DATA for %v after %d octets, with stream windows of %v and %d octets of connection window granted", stallTimeout, received, w.streams, w.conn)
+			}
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to read frame during the upload: %v", err)
+			return
+		}
+
+		switch f := frame.(type) {
+		case *http2.MetaHeadersFrame:
+			if uploads[f.StreamID] != nil || len(uploads) == expected {
+				log.Printf("Ignoring HEADERS on stream %d during the upload.", f.StreamID)
+				continue
+			}
+			u := &upload{streamID: f.StreamID, fields: f.Fields, grant: plan.minGrant, start: time.Now()}
+			uploads[f.StreamID] = u
+			w.open(f.StreamID)
+			log.Printf("Received request HEADERS on stream %d: %v", u.streamID, u.fields)
+			if f.StreamEnded() {
+				u.done = true
+				finished++
+				respondUpload(conn, framer, u.streamID, u.fields, u.body)
+				if finished == expected {
+					linger(conn, framer)
+					return
+				}
+			}
+		case *http2.DataFrame:
+			u := uploads[f.StreamID]
+			if u == nil || u.done {
+				log.Printf("Ignoring DATA on stream %d during the upload.", f.StreamID)
+				continue
+			}
+			length := f.Header().Length
+			stream, maxFrameSize := w.allowance(u.streamID)
+			if length > maxFrameSize {
+				abortViolation(conn, framer, http2.ErrCodeFrameSize, "DATA frame of %d octets exceeds the SETTINGS_MAX_FRAME_SIZE of %d", length, maxFrameSize)
+				return
+			}
+			if int64(length) > w.conn {
+				abortViolation(conn, framer, http2.ErrCodeFlowControl, "DATA frame of %d octets overruns the connection window of %d", length, w.conn)
+				return
+			}
+			if int64(length) > stream {
+				abortViolation(conn, framer, http2.ErrCodeFlowControl, "DATA frame of %d octets overruns the window of %d on stream %d", length, stream, u.streamID)
+				return
+			}
+			w.conn -= int64(length)
+			w.streams[u.streamID] -= int64(length)
+			u.body = append(u.body, f.Data()...)
+			u.frames++
+			u.largest = max(u.largest, length)
+			received += int64(length)
+
+			if f.StreamEnded() {
+				log.Printf("Upload complete on stream %d: %d octets in %d DATA frames (largest %d) with %d stream grants in %v.", u.streamID, len(u.body), u.frames, u.largest, u.grants, time.Since(u.start))
+				u.done = true
+				finished++
+				respondUpload(conn, framer, u.streamID, u.fields, u.body)
+				if finished == expected {
+					linger(conn, framer)
+					return
+				}
+			}
+			if length > 0 {
+				framer.WriteWindowUpdate(0, length)
+				w.conn += int64(length)
+			}
+			if plan.change != nil && !changeSent && received >= plan.changeAfter {
+				if err := framer.WriteSettings(plan.change...); err != nil {
+					log.Printf("Failed to write SETTINGS frame: %v", err)
+					return
+				}
+				w.pending = append(w.pending, plan.change)
+				changeSent = true
+				log.Printf("Sent SETTINGS %v after %d octets of body, with stream windows of %v.", plan.change, received, w.streams)
+			}
+		case *http2.SettingsFrame:
+			if f.IsAck() {
+				w.acknowledge()
+			} else {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+				continue
+			}
+			for _, u := range uploads {
+				if !u.fenced || f.Data != creditProbe(u.streamID) {
+					continue
+				}
+				increment := uint32(int64(u.grant) - w.streams[u.streamID])
+				framer.WriteWindowUpdate(u.streamID, increment)
+				w.streams[u.streamID] += int64(increment)
+				u.grants++
+				u.grant = min(2*u.grant, plan.maxGrant)
+				u.fenced = false
+			}
+		case *http2.RSTStreamFrame:
+			log.Printf("Client reset stream %d with %v during the upload.", f.StreamID, f.ErrCode)
+			return
+		case *http2.GoAwayFrame:
+			log.Printf("Client sent GOAWAY with %v during the upload.", f.ErrCode)
+			return
+		default:
+			log.Printf("Ignoring frame of type %T during the upload.", f)
+		}
+
+		// Once a stream window is exhausted under acknowledged settings,
+		// the harness fences the grant with a PING: any DATA the client
+		// sends on that stream before the PING ACK was sent without credit.
+		if len(w.pending) > 0 {
+			continue
+		}
+		for _, u := range uploads {
+			if u.fenced || u.done || w.streams[u.streamID] > 0 {
+				continue
+			}
+			if plan.pause > 0 {
+				time.Sleep(plan.pause)
+			}
+			framer.WritePing(false, creditProbe(u.streamID))
+			u.fenced = true
+		}
+	}
+}
+
+// respondUpload checks the received body against the request's
+// content-length and x-body-sha256 fields and answers the request with the
+// digest of the body as received.
+func respondUpload(conn net.Conn, framer *http2.Framer, streamID uint32, fields []hpack.HeaderField, body []byte) {
+	digest := sha256.Sum256(body)
+	received := hex.EncodeToString(digest[:])
+	for _, f := range fields {
+		switch f.Name {
+		case "content-length":
+			if n, err := strconv.Atoi(f.Value); err == nil && n != len(body) {
+				reportViolation(conn, "request body of %d octets does not match its content-length of %d", len(body), n)
+			}
+		case "x-body-sha256":
+			if f.Value != received {
+				reportViolation(conn, "request body arrived corrupted: SHA-256 %s, but the client sent %s", received, f.Value)
+			}
+		}
+	}
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID: streamID,
+		BlockFragment: encodeHeaders(
+			hpack.HeaderField{Name: ":status", Value: "200"},
+			hpack.HeaderField{Name: "x-body-sha256", Value: received},
+		),
+		EndStream:  true,
+		EndHeaders: true,
+	}); err != nil {
+		log.Printf("Failed to write response: %v", err)
+		return
+	}
+	log.Printf("Sent response on stream %d with the digest of the %d octets received.", streamID, len(body))
+}
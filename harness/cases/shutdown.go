@@ -0,0 +1,300 @@
+package cases
+
+import (
+	"errors"
+	"log"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// allStreams makes a GOAWAY of a goAwayPlan announce the largest stream
+// identifier instead of covering a number of requests.
+const allStreams = -1
+
+// goAwayPlan describes a parallel workload under which the harness shuts
+// the client's first connection down with one GOAWAY or more.
+type goAwayPlan struct {
+	// requests is the size of the parallel workload on the first
+	// connection.
+	requests int
+	// covered lists, for each GOAWAY in turn, how many of the requests its
+	// last-stream-id covers, or allStreams.
+	covered []int
+	// later is the number of requests the client sends once the workload
+	// is done.
+	later int
+}
+
+// lastStreamID returns the last-stream-id of a GOAWAY that covers the
+// first covered of streams.
+func lastStreamID(streams []uint32, covered int) uint32 {
+	switch covered {
+	case allStreams:
+		return 1<<31 - 1
+	case 0:
+		return 0
+	}
+	return streams[covered-1]
+}
+
+// goAwayTally counts the requests the harness answered over every
+// connection of a goAwayPlan.
+type goAwayTally struct {
+	total     int
+	deadline  time.Time
+	done      chan struct{}
+	closeDone sync.Once
+
+	mu          sync.Mutex
+	unprocessed int
+	answered    int
+	retried     int
+	later       int
+	conns       int
+}
+
+// answer counts n requests as answered, on a further connection unless
+// first. The workload's requests precede the plan's later ones, so the
+// first requests on further connections, up to the number left
+// unprocessed, count as retried and the rest as later. A client may send
+// more requests than the plan, so done closes once the count first reaches
+// the total.
+func (t *goAwayTally) answer(n int, first bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.answered += n
+	if !first {
+		retried := min(n, t.unprocessed-t.retried)
+		t.retried += retried
+		t.later += n - retried
+	}
+	if t.answered >= t.total {
+		t.closeDone.Do(func() { close(t.done) })
+	}
+}
+
+// serveGoAwayWorkload waits for plan.requests requests on conn and shuts
+// the connection down under them with the GOAWAY frames of the plan. It
+// answers the requests every GOAWAY covers on conn, and any other request
+// on the further connections the client opens. It fails the client if it
+// opens a stream on conn after the first GOAWAY. Sending the requests above
+// the last-stream-id again is allowed but not required, so it only logs how
+// many of them the client retried.
+func serveGoAwayWorkload(conn net.Conn, framer *http2.Framer, plan goAwayPlan) {
+	t := &goAwayTally{
+		total:    plan.requests + plan.later,
+		deadline: time.Now().Add(workloadTimeout),
+		done:     make(chan struct{}),
+	}
+	streams, err := awaitRequests(conn, framer, plan.requests, t.deadline)
+	if err != nil {
+		log.Printf("Failed to read the workload: %v", err)
+		return
+	}
+	slices.Sort(streams)
+
+	limit := lastStreamID(streams, allStreams)
+	for _, covered := range plan.covered {
+		limit = min(limit, lastStreamID(streams, covered))
+	}
+	for i, covered := range plan.covered {
+		last := lastStreamID(streams, covered)
+		if err := framer.WriteGoAway(last, http2.ErrCodeNo, []byte("graceful shutdown")); err != nil {
+			log.Printf("Failed to write GOAWAY frame: %v", err)
+			return
+		}
+		log.Printf("Sent GOAWAY with last-stream-id %d under the workload on streams %v.", last, streams)
+		if i > 0 {
+			continue
+		}
+
+		// RFC 7540 Section 6.8: only the streams that every GOAWAY covers
+		// are processed; the client must retry the others elsewhere.
+		n := 0
+		for _, id := range streams {
+			if id > limit {
+				continue
+			}
+			if err := writeResponse(framer, id, "ok"); err != nil {
+				log.Printf("Failed to write response: %v", err)
+				return
+			}
+			n++
+		}
+		log.Printf("Sent responses on the %d stream(s) up to %d.", n, limit)
+		t.mu.Lock()
+		t.unprocessed = len(streams) - n
+		t.mu.Unlock()
+		t.answer(n, true)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t.watch(conn, framer, streams[len(streams)-1], limit)
+	}()
+	if c, ok := conn.(*Conn); ok && c.Accept != nil {
+	accept:
+		for time.Now().Before(t.deadline) {
+			select {
+			case <-t.done:
+				break accept
+			default:
+			}
+			next, nextFramer, err := c.Accept(batchWindow)
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Failed to accept another connection: %v", err)
+				break
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				t.serve(next, nextFramer)
+			}()
+		}
+	}
+	wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// RFC 7540 Section 8.1.4: the requests above the last-stream-id were
+	// not processed, so the client MAY retry them; it need not.
+	log.Printf("The client sent %d of the %d request(s) above last-stream-id %d again and %d later request(s) on %d new connection(s).", t.retried, t.unprocessed, limit, t.later, t.conns)
+	if t.answered < t.total {
+		log.Printf("Only %d of %d requests were answered before the workload timed out.", t.answered, t.total)
+	}
+}
+
+// awaitRequests waits until the client has opened n streams, answering its
+// SETTINGS and PINGs, and returns their identifiers.
+func awaitRequests(conn net.Conn, framer *http2.Framer, n int, deadline time.Time) ([]uint32, error) {
+	conn.SetReadDeadline(deadline)
+	defer conn.SetReadDeadline(time.Time{})
+	var streams []uint32
+	for len(streams) < n {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch f := frame.(type) {
+		case *http2.HeadersFrame:
+			streams = append(streams, f.StreamID)
+			log.Printf("Received request HEADERS on stream %d; %d stream(s) open.", f.StreamID, len(streams))
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		default:
+			log.Printf("Ignoring frame of type %T while waiting for the client.", f)
+		}
+	}
+	return streams, nil
+}
+
+// watch reads the first connection after its GOAWAY frames until the
+// workload is done, and fails the client for every stream it opens above
+// highest, the last of the workload. limit is the lowest last-stream-id
+// the harness sent.
+func (t *goAwayTally) watch(conn net.Conn, framer *http2.Framer, highest, limit uint32) {
+	for {
+		select {
+		case <-t.done:
+			return
+		default:
+		}
+		if time.Now().After(t.deadline) {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(batchWindow))
+		frame, err := framer.ReadFrame()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			continue
+		}
+		if err != nil {
+			log.Printf("The client closed the connection: %v", err)
+			return
+		}
+		switch f := frame.(type) {
+		case *http2.HeadersFrame:
+			if f.StreamID <= highest {
+				continue
+			}
+			// RFC 7540 Section 6.8: the client MUST NOT open additional
+			// streams on a connection it received GOAWAY on. The stream
+			// was not processed, so the client may retry it elsewhere.
+			reportViolation(conn, "client opened stream %d after a GOAWAY with last-stream-id %d (RFC 7540 Section 6.8)", f.StreamID, limit)
+			if err := framer.WriteRSTStream(f.StreamID, http2.ErrCodeRefusedStream); err != nil {
+				log.Printf("Failed to write RST_STREAM frame: %v", err)
+				return
+			}
+		case *http2.GoAwayFrame:
+			log.Printf("The client sent GOAWAY with %v.", f.ErrCode)
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		}
+	}
+}
+
+// serve answers every request on one of the client's further connections
+// until the workload is done or has timed out.
+func (t *goAwayTally) serve(conn net.Conn, framer *http2.Framer) {
+	t.mu.Lock()
+	t.conns++
+	t.mu.Unlock()
+	for {
+		select {
+		case <-t.done:
+			linger(conn, framer)
+			return
+		default:
+		}
+		if time.Now().After(t.deadline) {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(batchWindow))
+		frame, err := framer.ReadFrame()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Stopped reading from the client: %v", err)
+			return
+		}
+		switch f := frame.(type) {
+		case *http2.HeadersFrame:
+			if err := writeResponse(framer, f.StreamID, "ok"); err != nil {
+				log.Printf("Failed to write response: %v", err)
+				return
+			}
+			log.Printf("Sent response on stream %d.", f.StreamID)
+			t.answer(1, false)
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package cases
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	// batchWindow is how long the harness waits for another request before
+	// it answers the streams the client has open. It leaves a client time to
+	// open every stream it is going to, beyond the limit or not.
+	batchWindow = 200 * time.Millisecond
+	// workloadTimeout bounds how long the harness serves a parallel
+	// workload, over all of the client's connections.
+	workloadTimeout = 4 * time.Second
+)
+
+// concurrencyPlan describes the parallel workload a client runs against the
+// harness and how the harness moves SETTINGS_MAX_CONCURRENT_STREAMS under
+// it.
+type concurrencyPlan struct {
+	// limit is the SETTINGS_MAX_CONCURRENT_STREAMS of the server
+	// connection preface.
+	limit uint32
+	// requests is the size of the workload, over all connections.
+	requests int
+	// changes are announced in turn as SETTINGS_MAX_CONCURRENT_STREAMS on
+	// every connection, one each time the client has filled the limit.
+	changes []uint32
+}
+
+// streamLimits tracks the client streams open on one connection against
+// the SETTINGS_MAX_CONCURRENT_STREAMS the client has acknowledged. pending
+// holds the limits sent since, in order.
+type streamLimits struct {
+	acked   uint32
+	pending []uint32
+	open    []uint32
+	peak    int
+	served  int
+	refused int
+}
+
+// newStreamLimits returns the limits of a connection on which the harness
+// announced limit in its connection preface.
+func newStreamLimits(limit uint32) *streamLimits {
+	return &streamLimits{acked: math.MaxUint32, pending: []uint32{limit}}
+}
+
+// acknowledge applies the oldest pending limit once the client has
+// acknowledged it.
+func (l *streamLimits) acknowledge() {
+	if len(l.pending) == 0 {
+		log.Println("Received an unsolicited SETTINGS ACK.")
+		return
+	}
+	l.acked = l.pending[0]
+	l.pending = l.pending[1:]
+	log.Printf("Received SETTINGS ACK; the client may now open %d concurrent streams.", l.acked)
+}
+
+// allowance returns the most streams the client may have open. Until it
+// acknowledges a SETTINGS frame the client may not have applied it yet, so
+// every pending limit is allowed.
+func (l *streamLimits) allowance() uint32 {
+	limit := l.acked
+	for _, p := range l.pending {
+		limit = max(limit, p)
+	}
+	return limit
+}
+
+// concurrencyTally collects what the harness observed of a workload on
+// every connection the client opened for it.
+type concurrencyTally struct {
+	plan      concurrencyPlan
+	deadline  time.Time
+	done      chan struct{}
+	closeDone sync.Once
+
+	mu       sync.Mutex
+	finished int
+	conns    []*streamLimits
+}
+
+// finish counts n requests of the workload as answered or refused. A client
+// that retries refused requests may finish more than the workload, so done
+// closes once the count first reaches it.
+func (t *concurrencyTally) finish(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.finished += n
+	if t.finished >= t.plan.requests {
+		t.closeDone.Do(func() { close(t.done) })
+	}
+}
+
+// serveParallelRequests serves a parallel workload of plan.requests
+// requests on conn and on any further connections the client opens for it.
+// On every connection it holds responses until the client stops opening
+// streams, and refuses with REFUSED_STREAM any stream beyond
+// SETTINGS_MAX_CONCURRENT_STREAMS. It
+// then reports the peak number of concurrent streams per connection and
+// whether the client queued its requests, spread them over new connections
+// or broke the limit.
+func serveParallelRequests(conn net.Conn, framer *http2.Framer, plan concurrencyPlan) {
+	t := &concurrencyTally{
+		plan:     plan,
+		deadline: time.Now().Add(workloadTimeout),
+		done:     make(chan struct{}),
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t.serve(conn, framer)
+	}()
+
+	accepted := make(chan struct{})
+	go func() {
+		defer close(accepted)
+		c, ok := conn.(*Conn)
+		if !ok || c.Accept == nil {
+			return
+		}
+		for time.Now().Before(t.deadline) {
+			select {
+			case <-t.done:
+				return
+			default:
+			}
+			next, nextFramer, err := c.Accept(batchWindow)
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Failed to accept another connection: %v", err)
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				t.serve(next, nextFramer)
+			}()
+		}
+	}()
+	<-accepted
+	wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	refused := 0
+	for i, l := range t.conns {
+		log.Printf("Connection %d: %d response(s), %d refused stream(s), peak of %d concurrent streams.", i+1, l.served, l.refused, l.peak)
+		refused += l.refused
+	}
+	switch {
+	case t.finished < plan.requests:
+		log.Printf("Only %d of %d requests arrived before the workload timed out.", t.finished, plan.requests)
+	case refused > 0:
+		log.Printf("The client broke SETTINGS_MAX_CONCURRENT_STREAMS %d time(s).", refused)
+	case len(t.conns) > 1:
+		log.Printf("The client spread %d requests over %d connections.", plan.requests, len(t.conns))
+	default:
+		log.Printf("The client queued %d requests on one connection.", plan.requests)
+	}
+}
+
+// serve runs the workload on one of the client's connections until the
+// whole workload is done or has timed out.
+func (t *concurrencyTally) serve(conn net.Conn, framer *http2.Framer) {
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	l := newStreamLimits(t.plan.limit)
+	t.mu.Lock()
+	t.conns = append(t.conns, l)
+	t.mu.Unlock()
+
+	changes := t.plan.changes
+	holding := false
+	respond := func() error {
+		for _, id := range l.open {
+			if err := writeResponse(framer, id, "ok"); err != nil {
+				return err
+			}
+		}
+		log.Printf("Sent responses on streams %v.", l.open)
+		t.mu.Lock()
+		l.served += len(l.open)
+		t.mu.Unlock()
+		t.finish(len(l.open))
+		l.open = nil
+		return nil
+	}
+	// release answers the open streams, unless the client has filled the
+	// limit and a change is due: then the responses wait for the client to
+	// acknowledge the new limit, so that it applies to the streams opened
+	// next.
+	release := func() error {
+		if holding {
+			return nil
+		}
+		if len(changes) == 0 || uint32(len(l.open)) < l.acked {
+			return respond()
+		}
+		if err := framer.WriteSettings(http2.Setting{ID: http2.SettingMaxConcurrentStreams, Val: changes[0]}); err != nil {
+			return err
+		}
+		log.Printf("Sent SETTINGS_MAX_CONCURRENT_STREAMS of %d with %d stream(s) open.", changes[0], len(l.open))
+		l.pending = append(l.pending, changes[0])
+		changes = changes[1:]
+		holding = true
+		return nil
+	}
+
+	for {
+		select {
+		case <-t.done:
+			linger(conn, framer)
+			return
+		default:
+		}
+		if time.Now().After(t.deadline) {
+			log.Printf("Workload timed out with %d stream(s) open.", len(l.open))
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(batchWindow))
+		frame, err := framer.ReadFrame()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			if len(l.open) > 0 {
+				if err := release(); err != nil {
+					log.Printf("Failed to answer streams: %v", err)
+					return
+				}
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("Stopped reading from the client: %v", err)
+			return
+		}
+		switch f := frame.(type) {
+		case *http2.MetaHeadersFrame:
+			if limit := l.allowance(); uint32(len(l.open)) >= limit {
+				reportViolation(conn, "client opened stream %d with %d streams open, beyond a SETTINGS_MAX_CONCURRENT_STREAMS of %d", f.StreamID, len(l.open), limit)
+				if err := framer.WriteRSTStream(f.StreamID, http2.ErrCodeRefusedStream); err != nil {
+					log.Printf("Failed to write RST_STREAM frame: %v", err)
+					return
+				}
+				t.mu.Lock()
+				l.refused++
+				t.mu.Unlock()
+				t.finish(1)
+				continue
+			}
+			l.open = append(l.open, f.StreamID)
+			t.mu.Lock()
+			l.peak = max(l.peak, len(l.open))
+			t.mu.Unlock()
+			log.Printf("Received request HEADERS on stream %d; %d stream(s) open.", f.StreamID, len(l.open))
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+				continue
+			}
+			l.acknowledge()
+			if holding && len(l.pending) == 0 {
+				holding = false
+				if err := respond(); err != nil {
+					log.Printf("Failed to answer streams: %v", err)
+					return
+				}
+			}
+		case *http2.PingFrame:
+			if !f.IsAck() {
+				framer.WritePing(true, f.Data)
+			}
+		case *http2.RSTStreamFrame:
+			for i, id := range l.open {
+				if id == f.StreamID {
+					l.open = append(l.open[:i], l.open[i+1:]...)
+					t.finish(1)
+					break
+				}
+			}
+		}
+	}
+}
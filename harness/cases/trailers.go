@@ -0,0 +1,82 @@
+package cases
+
+import (
+	"log"
+	"net"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// trailerPlan describes a 200 response that ends with a trailer section.
+type trailerPlan struct {
+	// body is sent in one DATA frame ahead of the trailers; an empty body
+	// sends no DATA at all.
+	body string
+	// trailers are the fields of the trailer section.
+	trailers []hpack.HeaderField
+	// open leaves END_STREAM off the trailers, which makes the response
+	// malformed; an empty DATA frame then ends the stream, so that a
+	// client accepting the trailers still completes the response.
+	open bool
+	// fragment, if not zero, splits the trailer section into a HEADERS
+	// frame and CONTINUATION frames of at most fragment octets.
+	fragment uint32
+}
+
+// serveTrailers waits for the client's request and answers it according to
+// plan. The response announces the names of its trailer fields in a
+// trailer header field, and the harness lingers until the client is done.
+func serveTrailers(conn net.Conn, framer *http2.Framer, plan trailerPlan) {
+	streamID, err := awaitRequest(framer)
+	if err != nil {
+		log.Printf("Failed to read request HEADERS: %v", err)
+		return
+	}
+
+	var names []string
+	for _, f := range plan.trailers {
+		names = append(names, f.Name)
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID: streamID,
+		BlockFragment: encodeHeaders(
+			hpack.HeaderField{Name: ":status", Value: "200"},
+			hpack.HeaderField{Name: "trailer", Value: strings.Join(names, ", ")},
+		),
+		EndHeaders: true,
+	}); err != nil {
+		log.Printf("Failed to write HEADERS frame: %v", err)
+		return
+	}
+	if plan.body != "" {
+		if err := framer.WriteData(streamID, false, []byte(plan.body)); err != nil {
+			log.Printf("Failed to write DATA frame: %v", err)
+			return
+		}
+		log.Printf("Sent response HEADERS and %d octets of DATA on stream %d.", len(plan.body), streamID)
+	} else {
+		log.Printf("Sent response HEADERS on stream %d without DATA.", streamID)
+	}
+
+	block := encodeHeaders(plan.trailers...)
+	fragment := plan.fragment
+	if fragment == 0 {
+		fragment = uint32(len(block))
+	}
+	if err := writeHeaderBlock(framer, streamID, block, !plan.open, fragment); err != nil {
+		log.Printf("Failed to write trailers: %v", err)
+		return
+	}
+	frames := (uint32(len(block)) + fragment - 1) / fragment
+	log.Printf("Sent trailers %s in %d frame(s) of at most %d octets.", strings.Join(names, ", "), frames, fragment)
+	if plan.open {
+		if err := framer.WriteData(streamID, true, nil); err != nil {
+			log.Printf("Failed to write DATA frame: %v", err)
+			return
+		}
+		log.Println("Sent trailers without END_STREAM, then an empty DATA frame with END_STREAM.")
+	}
+	linger(conn, framer)
+}
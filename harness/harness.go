@@ -6,6 +6,7 @@ import (
 	"sort"
 
 	"github.com/nomadlabsinc/h2-client-test-harness/harness/cases"
+	"github.com/nomadlabsinc/h2-client-test-harness/profile"
 	"golang.org/x/net/http2"
 )
 
@@ -13,6 +14,16 @@ type TestFunc func(conn net.Conn, framer *http2.Framer)
 
 var testRegistry = make(map[string]TestFunc)
 
+// prefaceRegistry holds the test cases that replace the server connection
+// preface. Their function runs in place of the initial SETTINGS frame the
+// harness otherwise sends, before the test case itself.
+var prefaceRegistry = make(map[string]TestFunc)
+
+// earlyRegistry holds the test cases that write to a connection as soon as
+// it is accepted. Their function runs before the harness reads the client
+// connection preface, on the bare connection.
+var earlyRegistry = make(map[string]TestFunc)
+
 func init() {
 	// Generic tests
 	testRegistry["generic/3.1/1"] = cases.RunTestGeneric3_1_1
@@ -35,28 +46,27 @@ func init() {
 	testRegistry["generic/4/1"] = cases.RunTestGeneric4_1
 	testRegistry["generic/4/2"] = cases.RunTestGeneric4_2
 
-	// Additional tests for 100% coverage
-	testRegistry["generic/1/1"] = cases.RunTestGeneric1_1
-	testRegistry["generic/2/1"] = cases.RunTestGeneric2_1
-	testRegistry["generic/5/1"] = cases.RunTestGeneric5_1
-	testRegistry["generic/misc/1"] = cases.RunTestGenericMisc1
-	testRegistry["hpack/misc/1"] = cases.RunTestHpackMisc1
+	// Extended HTTP/2 protocol tests
 	testRegistry["http2/5.5/1"] = cases.RunTestHttp2_5_5_1
-	testRegistry["http2/7/1"] = cases.RunTestHttp2_7_1
-	testRegistry["http2/4.3/1"] = cases.RunTestHttp2_4_3_1
 	testRegistry["http2/8.1.2.4/1"] = cases.RunTestHttp2_8_1_2_4_1
 	testRegistry["http2/8.1.2.5/1"] = cases.RunTestHttp2_8_1_2_5_1
-	testRegistry["extra/1"] = cases.RunTestExtra1
-	testRegistry["extra/2"] = cases.RunTestExtra2
-	testRegistry["extra/3"] = cases.RunTestExtra3
-	testRegistry["extra/4"] = cases.RunTestExtra4
-	testRegistry["extra/5"] = cases.RunTestExtra5
-	testRegistry["final/1"] = cases.RunTestFinal1
-	testRegistry["final/2"] = cases.RunTestFinal2
 
 	// 3.5 Connection Preface
 	testRegistry["3.5/1"] = cases.RunTest3_5_1
 	testRegistry["3.5/2"] = cases.RunTest3_5_2
+	testRegistry["3.5/3"] = cases.RunTest3_5_3
+	testRegistry["3.5/4"] = cases.RunTest3_5_4
+	testRegistry["3.5/5"] = cases.RunTest3_5_5
+	testRegistry["3.5/6"] = cases.RunTest3_5_6
+	testRegistry["3.5/7"] = cases.RunTest3_5_7
+	testRegistry["3.5/8"] = cases.RunTest3_5_8
+	prefaceRegistry["3.5/2"] = cases.Preface3_5_2
+	prefaceRegistry["3.5/3"] = cases.Preface3_5_3
+	prefaceRegistry["3.5/4"] = cases.Preface3_5_4
+	prefaceRegistry["3.5/5"] = cases.Preface3_5_5
+	prefaceRegistry["3.5/6"] = cases.Preface3_5_6
+	prefaceRegistry["3.5/7"] = cases.Preface3_5_7
+	prefaceRegistry["3.5/8"] = cases.Preface3_5_8
 
 	// 4.1 Frame Format
 	testRegistry["4.1/1"] = cases.RunTest4_1_1
@@ -67,6 +77,10 @@ func init() {
 	testRegistry["4.2/1"] = cases.RunTest4_2_1
 	testRegistry["4.2/2"] = cases.RunTest4_2_2
 	testRegistry["4.2/3"] = cases.RunTest4_2_3
+	testRegistry["4.2/4"] = cases.RunTest4_2_4
+	prefaceRegistry["4.2/4"] = cases.Preface4_2_4
+	testRegistry["4.2/5"] = cases.RunTest4_2_5
+	prefaceRegistry["4.2/5"] = cases.Preface4_2_5
 
 	// 5.1 Stream States
 	testRegistry["5.1/1"] = cases.RunTest5_1_1
@@ -86,9 +100,14 @@ func init() {
 	// 5.1.1 Stream Identifiers
 	testRegistry["5.1.1/1"] = cases.RunTest5_1_1_1
 	testRegistry["5.1.1/2"] = cases.RunTest5_1_1_2
+	testRegistry["5.1.1/3"] = cases.RunTest5_1_1_3
 
 	// 5.1.2 Stream Concurrency
 	testRegistry["5.1.2/1"] = cases.RunTest5_1_2_1
+	testRegistry["5.1.2/2"] = cases.RunTest5_1_2_2
+	testRegistry["5.1.2/3"] = cases.RunTest5_1_2_3
+	prefaceRegistry["5.1.2/2"] = cases.Preface5_1_2_2
+	prefaceRegistry["5.1.2/3"] = cases.Preface5_1_2_3
 
 	// 5.3.1 Stream Dependencies
 	testRegistry["5.3.1/1"] = cases.RunTest5_3_1_1
@@ -102,12 +121,15 @@ func init() {
 	testRegistry["6.1/1"] = cases.RunTest6_1_1
 	testRegistry["6.1/2"] = cases.RunTest6_1_2
 	testRegistry["6.1/3"] = cases.RunTest6_1_3
+	testRegistry["6.1/4"] = cases.RunTest6_1_4
+	testRegistry["6.1/5"] = cases.RunTest6_1_5
 
 	// 6.2 HEADERS
 	testRegistry["6.2/1"] = cases.RunTest6_2_1
 	testRegistry["6.2/2"] = cases.RunTest6_2_2
 	testRegistry["6.2/3"] = cases.RunTest6_2_3
 	testRegistry["6.2/4"] = cases.RunTest6_2_4
+	testRegistry["6.2/5"] = cases.RunTest6_2_5
 
 	// 6.3 PRIORITY
 	testRegistry["6.3/1"] = cases.RunTest6_3_1
@@ -117,11 +139,14 @@ func init() {
 	testRegistry["6.4/1"] = cases.RunTest6_4_1
 	testRegistry["6.4/2"] = cases.RunTest6_4_2
 	testRegistry["6.4/3"] = cases.RunTest6_4_3
+	testRegistry["6.4/4"] = cases.RunTest6_4_4
+	testRegistry["6.4/5"] = cases.RunTest6_4_5
 
 	// 6.5 SETTINGS
 	testRegistry["6.5/1"] = cases.RunTest6_5_1
 	testRegistry["6.5/2"] = cases.RunTest6_5_2
 	testRegistry["6.5/3"] = cases.RunTest6_5_3
+	testRegistry["6.5/4"] = cases.RunTest6_5_4
 
 	// 6.5.2 Defined SETTINGS Parameters
 	testRegistry["6.5.2/1"] = cases.RunTest6_5_2_1
@@ -129,29 +154,63 @@ func init() {
 	testRegistry["6.5.2/3"] = cases.RunTest6_5_2_3
 	testRegistry["6.5.2/4"] = cases.RunTest6_5_2_4
 	testRegistry["6.5.2/5"] = cases.RunTest6_5_2_5
+	testRegistry["6.5.2/6"] = cases.RunTest6_5_2_6
+	testRegistry["6.5.2/7"] = cases.RunTest6_5_2_7
+	testRegistry["6.5.2/8"] = cases.RunTest6_5_2_8
+	prefaceRegistry["6.5.2/8"] = cases.Preface6_5_2_8
 
 	// 6.5.3 Settings Synchronization
 	testRegistry["6.5.3/2"] = cases.RunTest6_5_3_2
+	testRegistry["6.5.3/3"] = cases.RunTest6_5_3_3
+	testRegistry["6.5.3/4"] = cases.RunTest6_5_3_4
+	testRegistry["6.5.3/5"] = cases.RunTest6_5_3_5
+	testRegistry["6.5.3/6"] = cases.RunTest6_5_3_6
+	testRegistry["6.5.3/7"] = cases.RunTest6_5_3_7
+	prefaceRegistry["6.5.3/4"] = cases.Preface6_5_3_4
+	prefaceRegistry["6.5.3/5"] = cases.Preface6_5_3_4
+	prefaceRegistry["6.5.3/6"] = cases.Preface6_5_3_4
+	prefaceRegistry["6.5.3/7"] = cases.Preface6_5_3_7
+	earlyRegistry["6.5.3/7"] = cases.Early6_5_3_7
 
 	// 6.7 PING
 	testRegistry["6.7/1"] = cases.RunTest6_7_1
 	testRegistry["6.7/2"] = cases.RunTest6_7_2
 	testRegistry["6.7/3"] = cases.RunTest6_7_3
 	testRegistry["6.7/4"] = cases.RunTest6_7_4
+	testRegistry["6.7/5"] = cases.RunTest6_7_5
 
 	// 6.8 GOAWAY
 	testRegistry["6.8/1"] = cases.RunTest6_8_1
+	testRegistry["6.8/2"] = cases.RunTest6_8_2
+	testRegistry["6.8/3"] = cases.RunTest6_8_3
+	testRegistry["6.8/4"] = cases.RunTest6_8_4
+	testRegistry["6.8/5"] = cases.RunTest6_8_5
 
 	// 6.9 WINDOW_UPDATE
 	testRegistry["6.9/1"] = cases.RunTest6_9_1
 	testRegistry["6.9/2"] = cases.RunTest6_9_2
 	testRegistry["6.9/3"] = cases.RunTest6_9_3
+	testRegistry["6.9/4"] = cases.RunTest6_9_4
+	testRegistry["6.9.2/1"] = cases.RunTest6_9_2_1
+	testRegistry["6.9.2/2"] = cases.RunTest6_9_2_2
 	testRegistry["6.9.2/3"] = cases.RunTest6_9_2_3
+	testRegistry["6.9.2/4"] = cases.RunTest6_9_2_4
+	prefaceRegistry["6.9.2/1"] = cases.Preface6_9_2_1
+	prefaceRegistry["6.9.2/2"] = cases.Preface6_9_2_2
+	prefaceRegistry["6.9.2/4"] = cases.Preface6_9_2_4
 
 	// 6.9.1 Flow Control Window
 	testRegistry["6.9.1/1"] = cases.RunTest6_9_1_1
 	testRegistry["6.9.1/2"] = cases.RunTest6_9_1_2
 	testRegistry["6.9.1/3"] = cases.RunTest6_9_1_3
+	testRegistry["6.9.1/4"] = cases.RunTest6_9_1_4
+	testRegistry["6.9.1/5"] = cases.RunTest6_9_1_5
+	testRegistry["6.9.1/6"] = cases.RunTest6_9_1_6
+	testRegistry["6.9.1/7"] = cases.RunTest6_9_1_7
+	testRegistry["6.9.1/8"] = cases.RunTest6_9_1_8
+	testRegistry["6.9.1/9"] = cases.RunTest6_9_1_9
+	testRegistry["6.9.1/10"] = cases.RunTest6_9_1_10
+	prefaceRegistry["6.9.1/1"] = cases.Preface6_9_1_1
 
 	// 6.10 CONTINUATION
 	testRegistry["6.10/2"] = cases.RunTest6_10_2
@@ -159,22 +218,43 @@ func init() {
 	testRegistry["6.10/4"] = cases.RunTest6_10_4
 	testRegistry["6.10/5"] = cases.RunTest6_10_5
 	testRegistry["6.10/6"] = cases.RunTest6_10_6
+	testRegistry["6.10/7"] = cases.RunTest6_10_7
+	testRegistry["6.10/8"] = cases.RunTest6_10_8
+	prefaceRegistry["6.10/8"] = cases.Preface6_10_8
 
 	// 8.1 HTTP Request/Response Exchange
 	testRegistry["8.1/1"] = cases.RunTest8_1_1
+	testRegistry["8.1/2"] = cases.RunTest8_1_2
+	testRegistry["8.1/3"] = cases.RunTest8_1_3
+	testRegistry["8.1/4"] = cases.RunTest8_1_4
+	testRegistry["8.1/5"] = cases.RunTest8_1_5
+	testRegistry["8.1/6"] = cases.RunTest8_1_6
+	testRegistry["8.1/7"] = cases.RunTest8_1_7
+	testRegistry["8.1/8"] = cases.RunTest8_1_8
+	testRegistry["8.1/9"] = cases.RunTest8_1_9
+	testRegistry["8.1/10"] = cases.RunTest8_1_10
+	testRegistry["8.1/11"] = cases.RunTest8_1_11
+	testRegistry["8.1/12"] = cases.RunTest8_1_12
+	testRegistry["8.1/13"] = cases.RunTest8_1_13
+	testRegistry["8.1/14"] = cases.RunTest8_1_14
+	testRegistry["8.1/15"] = cases.RunTest8_1_15
 
 	// 8.1.2 HTTP Header Fields
 	testRegistry["8.1.2/1"] = cases.RunTest8_1_2_1
+	testRegistry["8.1.2/2"] = cases.RunTest8_1_2_2
 
 	// 8.1.2.1 Pseudo-Header Fields
 	testRegistry["8.1.2.1/1"] = cases.RunTest8_1_2_1_1
 	testRegistry["8.1.2.1/2"] = cases.RunTest8_1_2_1_2
 	testRegistry["8.1.2.1/3"] = cases.RunTest8_1_2_1_3
 	testRegistry["8.1.2.1/4"] = cases.RunTest8_1_2_1_4
+	testRegistry["8.1.2.1/5"] = cases.RunTest8_1_2_1_5
 
 	// 8.1.2.2 Connection-Specific Header Fields
 	testRegistry["8.1.2.2/1"] = cases.RunTest8_1_2_2_1
 	testRegistry["8.1.2.2/2"] = cases.RunTest8_1_2_2_2
+	testRegistry["8.1.2.2/3"] = cases.RunTest8_1_2_2_3
+	testRegistry["8.1.2.2/4"] = cases.RunTest8_1_2_2_4
 
 	// 8.1.2.3 Request Pseudo-Header Fields
 	testRegistry["8.1.2.3/1"] = cases.RunTest8_1_2_3_1
@@ -184,13 +264,40 @@ func init() {
 	testRegistry["8.1.2.3/5"] = cases.RunTest8_1_2_3_5
 	testRegistry["8.1.2.3/6"] = cases.RunTest8_1_2_3_6
 	testRegistry["8.1.2.3/7"] = cases.RunTest8_1_2_3_7
+	testRegistry["8.1.2.3/8"] = cases.RunTest8_1_2_3_8
+	testRegistry["8.1.2.3/9"] = cases.RunTest8_1_2_3_9
+	testRegistry["8.1.2.3/10"] = cases.RunTest8_1_2_3_10
 
 	// 8.1.2.6 Malformed Requests and Responses
 	testRegistry["8.1.2.6/1"] = cases.RunTest8_1_2_6_1
 	testRegistry["8.1.2.6/2"] = cases.RunTest8_1_2_6_2
+	testRegistry["8.1.2.6/3"] = cases.RunTest8_1_2_6_3
+
+	// 8.1.4 Request Reliability Mechanisms
+	for id, run := range cases.ResetTests() {
+		testRegistry[id] = run
+	}
 
 	// 8.2 Server Push
 	testRegistry["8.2/1"] = cases.RunTest8_2_1
+	testRegistry["8.2/2"] = cases.RunTest8_2_2
+	testRegistry["8.2/3"] = cases.RunTest8_2_3
+	testRegistry["8.2/4"] = cases.RunTest8_2_4
+	testRegistry["8.2/5"] = cases.RunTest8_2_5
+	testRegistry["8.2/6"] = cases.RunTest8_2_6
+	testRegistry["8.2/7"] = cases.RunTest8_2_7
+	testRegistry["8.2/8"] = cases.RunTest8_2_8
+	testRegistry["8.2/9"] = cases.RunTest8_2_9
+
+	// 8.3 The CONNECT Method
+	testRegistry["8.3/1"] = cases.RunTest8_3_1
+	testRegistry["8.3/2"] = cases.RunTest8_3_2
+
+	// 9.1 Connection Management
+	testRegistry["9.1.1/1"] = cases.RunTest9_1_1_1
+	testRegistry["9.1.1/2"] = cases.RunTest9_1_1_2
+	testRegistry["9.1.2/1"] = cases.RunTest9_1_2_1
+	testRegistry["9.1.2/2"] = cases.RunTest9_1_2_2
 
 	// HPACK
 	testRegistry["hpack/2.3/1"] = cases.RunTestHpack2_3_1
@@ -198,29 +305,86 @@ func init() {
 	testRegistry["hpack/2.3.3/2"] = cases.RunTestHpack2_3_3_2
 	testRegistry["hpack/4.1/1"] = cases.RunTestHpack4_1_1
 	testRegistry["hpack/4.2/1"] = cases.RunTestHpack4_2_1
+	testRegistry["hpack/4.2/2"] = cases.RunTestHpack4_2_2
+	testRegistry["hpack/4.2/3"] = cases.RunTestHpack4_2_3
+	prefaceRegistry["hpack/4.2/3"] = cases.PrefaceHpack4_2_3
+	testRegistry["hpack/4.2/4"] = cases.RunTestHpack4_2_4
 	testRegistry["hpack/5.2/1"] = cases.RunTestHpack5_2_1
 	testRegistry["hpack/5.2/2"] = cases.RunTestHpack5_2_2
 	testRegistry["hpack/5.2/3"] = cases.RunTestHpack5_2_3
+	testRegistry["hpack/5.2/4"] = cases.RunTestHpack5_2_4
+	testRegistry["hpack/5.2/5"] = cases.RunTestHpack5_2_5
 	testRegistry["hpack/6.1/1"] = cases.RunTestHpack6_1_1
+	testRegistry["hpack/6.1/2"] = cases.RunTestHpack6_1_2
+	testRegistry["hpack/6.1/3"] = cases.RunTestHpack6_1_3
 	testRegistry["hpack/6.2/1"] = cases.RunTestHpack6_2_1
 	testRegistry["hpack/6.2.2/1"] = cases.RunTestHpack6_2_2_1
 	testRegistry["hpack/6.2.3/1"] = cases.RunTestHpack6_2_3_1
 	testRegistry["hpack/6.3/1"] = cases.RunTestHpack6_3_1
-
-	// Final 13 completion tests to reach exactly 146 total
-	testRegistry["complete/1"] = cases.RunTestComplete1
-	testRegistry["complete/2"] = cases.RunTestComplete2
-	testRegistry["complete/3"] = cases.RunTestComplete3
-	testRegistry["complete/4"] = cases.RunTestComplete4
-	testRegistry["complete/5"] = cases.RunTestComplete5
-	testRegistry["complete/6"] = cases.RunTestComplete6
-	testRegistry["complete/7"] = cases.RunTestComplete7
-	testRegistry["complete/8"] = cases.RunTestComplete8
-	testRegistry["complete/9"] = cases.RunTestComplete9
-	testRegistry["complete/10"] = cases.RunTestComplete10
-	testRegistry["complete/11"] = cases.RunTestComplete11
-	testRegistry["complete/12"] = cases.RunTestComplete12
-	testRegistry["complete/13"] = cases.RunTestComplete13
+	testRegistry["hpack/6.3/2"] = cases.RunTestHpack6_3_2
+	testRegistry["hpack/6.3/3"] = cases.RunTestHpack6_3_3
+
+	// gRPC
+	testRegistry["grpc/1"] = cases.RunTestGrpc1
+	testRegistry["grpc/2"] = cases.RunTestGrpc2
+	testRegistry["grpc/3"] = cases.RunTestGrpc3
+	testRegistry["grpc/4"] = cases.RunTestGrpc4
+	testRegistry["grpc/5"] = cases.RunTestGrpc5
+	prefaceRegistry["grpc/5"] = cases.PrefaceGrpc5
+	testRegistry["grpc/6"] = cases.RunTestGrpc6
+	testRegistry["grpc/7"] = cases.RunTestGrpc7
+	testRegistry["grpc/8"] = cases.RunTestGrpc8
+
+	// RFC 9113 profile
+	testRegistry["rfc9113/5.3.2/1"] = cases.RunTestRfc9113_5_3_2_1
+	prefaceRegistry["rfc9113/5.3.2/1"] = cases.PrefaceRfc9113_5_3_2_1
+	testRegistry["rfc9113/5.3.2/2"] = cases.RunTestRfc9113_5_3_2_2
+	prefaceRegistry["rfc9113/5.3.2/2"] = cases.PrefaceRfc9113_5_3_2_2
+	testRegistry["rfc9113/6.5.2/1"] = cases.RunTestRfc9113_6_5_2_1
+	prefaceRegistry["rfc9113/6.5.2/1"] = cases.PrefaceRfc9113_6_5_2_1
+	testRegistry["rfc9113/6.5.2/2"] = cases.RunTestRfc9113_6_5_2_2
+	prefaceRegistry["rfc9113/6.5.2/2"] = cases.PrefaceRfc9113_6_5_2_2
+	testRegistry["rfc9113/8.2.1/1"] = cases.RunTestRfc9113_8_2_1_1
+	testRegistry["rfc9113/8.2.1/2"] = cases.RunTestRfc9113_8_2_1_2
+	testRegistry["rfc9113/8.2.1/3"] = cases.RunTestRfc9113_8_2_1_3
+	testRegistry["rfc9113/8.2.1/4"] = cases.RunTestRfc9113_8_2_1_4
+	testRegistry["rfc9113/8.2.1/5"] = cases.RunTestRfc9113_8_2_1_5
+	testRegistry["rfc9113/8.3.1/1"] = cases.RunTestRfc9113_8_3_1_1
+
+	// RFC 7838 ALTSVC and RFC 8336 ORIGIN frames
+	testRegistry["rfc7838/4/1"] = cases.RunTestRfc7838_4_1
+	testRegistry["rfc7838/4/2"] = cases.RunTestRfc7838_4_2
+	testRegistry["rfc7838/4/3"] = cases.RunTestRfc7838_4_3
+	testRegistry["rfc7838/4/4"] = cases.RunTestRfc7838_4_4
+	testRegistry["rfc7838/4/5"] = cases.RunTestRfc7838_4_5
+	testRegistry["rfc7838/4/6"] = cases.RunTestRfc7838_4_6
+	testRegistry["rfc8336/2.1/1"] = cases.RunTestRfc8336_2_1_1
+	testRegistry["rfc8336/2.1/2"] = cases.RunTestRfc8336_2_1_2
+	testRegistry["rfc8336/2.2/1"] = cases.RunTestRfc8336_2_2_1
+	testRegistry["rfc8336/2.3/1"] = cases.RunTestRfc8336_2_3_1
+
+	// RFC 8441 extended CONNECT
+	testRegistry["rfc8441/3/1"] = cases.RunTestRfc8441_3_1
+	prefaceRegistry["rfc8441/3/1"] = cases.PrefaceRfc8441_3_1
+	testRegistry["rfc8441/3/2"] = cases.RunTestRfc8441_3_2
+	testRegistry["rfc8441/3/3"] = cases.RunTestRfc8441_3_3
+	prefaceRegistry["rfc8441/3/3"] = cases.PrefaceRfc8441_3_3
+	testRegistry["rfc8441/5/1"] = cases.RunTestRfc8441_5_1
+	prefaceRegistry["rfc8441/5/1"] = cases.PrefaceRfc8441_3_1
+	testRegistry["rfc8441/5/2"] = cases.RunTestRfc8441_5_2
+	prefaceRegistry["rfc8441/5/2"] = cases.PrefaceRfc8441_3_1
+	testRegistry["rfc8441/5/3"] = cases.RunTestRfc8441_5_3
+	prefaceRegistry["rfc8441/5/3"] = cases.PrefaceRfc8441_3_1
+
+	// RFC 9218 extensible priorities
+	testRegistry["rfc9218/4/1"] = cases.RunTestRfc9218_4_1
+	testRegistry["rfc9218/5/1"] = cases.RunTestRfc9218_5_1
+	testRegistry["rfc9218/5/2"] = cases.RunTestRfc9218_5_2
+	testRegistry["rfc9218/7.1/1"] = cases.RunTestRfc9218_7_1_1
+	testRegistry["rfc9218/7.1/2"] = cases.RunTestRfc9218_7_1_2
+	testRegistry["rfc9218/7.1/3"] = cases.RunTestRfc9218_7_1_3
+	testRegistry["rfc9218/7.1/4"] = cases.RunTestRfc9218_7_1_4
+	testRegistry["rfc9218/7.1/5"] = cases.RunTestRfc9218_7_1_5
 }
 
 func GetTest(id string) (TestFunc, bool) {
@@ -228,14 +392,32 @@ func GetTest(id string) (TestFunc, bool) {
 	return test, ok
 }
 
-func PrintAllTests() {
-	fmt.Println("Available test cases:")
+// GetPreface returns the server preface replacement of test case id, if any.
+func GetPreface(id string) (TestFunc, bool) {
+	preface, ok := prefaceRegistry[id]
+	return preface, ok
+}
+
+// GetEarly returns the function test case id runs on a connection before
+// the client connection preface, if any.
+func GetEarly(id string) (TestFunc, bool) {
+	early, ok := earlyRegistry[id]
+	return early, ok
+}
+
+// PrintAllTests lists the test cases that apply under profile p, each with
+// the clause it checks under p.
+func PrintAllTests(p profile.Profile) {
+	fmt.Printf("Available test cases under profile %s:\n", p)
 	keys := make([]string, 0, len(testRegistry))
 	for k := range testRegistry {
-		keys = append(keys, k)
+		if _, ok := profile.Clause(p, k); ok {
+			keys = append(keys, k)
+		}
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
-		fmt.Printf("  - %s\n", k)
+		clause, _ := profile.Clause(p, k)
+		fmt.Printf("  - %-20s %s\n", k, clause)
 	}
 }
@@ -2,24 +2,46 @@ package main
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
 	"os/exec"
+	"sync"
+	"time"
 
 	"github.com/nomadlabsinc/h2-client-test-harness/harness"
+	"github.com/nomadlabsinc/h2-client-test-harness/harness/cases"
+	"github.com/nomadlabsinc/h2-client-test-harness/profile"
 	"golang.org/x/net/http2"
 )
 
+// exitClientViolation is the exit status of the harness when a test case
+// recorded protocol violations by the client.
+const exitClientViolation = 3
+
+// openTimeout bounds how long the harness waits for the connection preface
+// of a further connection the client opens.
+const openTimeout = 2 * time.Second
+
 func main() {
 	testCaseID := flag.String("test", "", "The ID of the test case to run (e.g., '6.5/1')")
+	profileName := flag.String("profile", string(profile.RFC7540), "The edition of the HTTP/2 specification to judge the client against: rfc7540 or rfc9113")
 	flag.Parse()
 
+	p, err := profile.Parse(*profileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cases.UseProfile(p)
+
 	if *testCaseID == "" {
-		fmt.Println("Usage: go run . --test=<test_case_id>")
-		harness.PrintAllTests()
+		fmt.Println("Usage: go run . --test=<test_case_id> [--profile=<rfc7540|rfc9113>]")
+		harness.PrintAllTests(p)
 		os.Exit(1)
 	}
 
@@ -27,6 +49,10 @@ func main() {
 	if !ok {
 		log.Fatalf("Test case '%s' not found.", *testCaseID)
 	}
+	clause, ok := profile.Clause(p, *testCaseID)
+	if !ok {
+		log.Fatalf("Test case '%s' does not apply under profile %s.", *testCaseID, p)
+	}
 
 	if err := ensureCerts(); err != nil {
 		log.Fatalf("Failed to create or find certificates: %v", err)
@@ -36,74 +62,232 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load certificates: %v", err)
 	}
+	cases.UseCertificate(cert.Leaf)
 
 	tlsConfig := &tls.Config{
 		Certificates: []tls.Certificate{cert},
-		NextProtos:   []string{http2.NextProtoTLS},
+		// HTTP/1.1 serves the clients that fall back to it after
+		// HTTP_1_1_REQUIRED; any client that offers h2 gets h2.
+		NextProtos: []string{http2.NextProtoTLS, "http/1.1"},
 	}
 
-	listener, err := tls.Listen("tcp", "127.0.0.1:8080", tlsConfig)
+	tcpListener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080})
 	if err != nil {
 		log.Fatalf("Failed to listen: %v", err)
 	}
+	listener := tls.NewListener(tcpListener, tlsConfig)
 	defer listener.Close()
 
-	log.Printf("Test harness server listening on %s for test case '%s'", listener.Addr().String(), *testCaseID)
+	log.Printf("Test harness server listening on %s for test case '%s' (%s)", listener.Addr().String(), *testCaseID, clause)
 
 	conn, err := listener.Accept()
 	if err != nil {
 		log.Fatalf("Failed to accept connection: %v", err)
 	}
-	
-	handleConnection(conn, testFunc)
+
+	serverPreface, ok := harness.GetPreface(*testCaseID)
+	if !ok {
+		serverPreface = writeServerPreface
+	}
+
+	s := &session{listener: tcpListener, tls: listener, serverPreface: serverPreface}
+	s.early, _ = harness.GetEarly(*testCaseID)
+	defer s.close()
+	clientConn, framer, err := s.open(conn)
+	if err != nil {
+		log.Printf("Failed to open connection: %v", err)
+		conn.Close()
+		return
+	}
+	if clientConn.Protocol != http2.NextProtoTLS {
+		log.Println("The client negotiated HTTP/1.1 instead of h2.")
+		return
+	}
+	testFunc(clientConn, framer)
+
+	if violations := s.violations(); len(violations) > 0 {
+		log.Printf("Test case '%s' FAILED: the client committed %d protocol violation(s):", *testCaseID, len(violations))
+		for _, v := range violations {
+			log.Printf("  - %s", v)
+		}
+		s.close()
+		os.Exit(exitClientViolation)
+	}
 }
 
-func handleConnection(conn net.Conn, testFunc harness.TestFunc) {
-	defer conn.Close()
-	log.Printf("Accepted connection from %s", conn.RemoteAddr())
+// session holds every connection the client opens during one test case.
+// The test case runs on the first; it takes any further ones through
+// cases.Conn.Accept.
+type session struct {
+	listener      *net.TCPListener
+	tls           net.Listener
+	serverPreface harness.TestFunc
+	early         harness.TestFunc
 
+	mu    sync.Mutex
+	conns []*cases.Conn
+}
+
+// accept waits up to timeout for the client's next connection and opens it.
+func (s *session) accept(timeout time.Duration) (*cases.Conn, *http2.Framer, error) {
+	s.listener.SetDeadline(time.Now().Add(timeout))
+	conn, err := s.tls.Accept()
+	if err != nil {
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Now().Add(openTimeout))
+	clientConn, framer, err := s.open(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return clientConn, framer, nil
+}
+
+// open reads the client connection preface and initial SETTINGS from conn
+// and answers with the server connection preface. A connection on which
+// the client negotiated HTTP/1.1 is returned as it is, for the test case to
+// serve.
+func (s *session) open(conn net.Conn) (*cases.Conn, *http2.Framer, error) {
+	s.mu.Lock()
+	id := len(s.conns) + 1
+	s.mu.Unlock()
+	log.Printf("Accepted connection %d from %s", id, conn.RemoteAddr())
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, nil, fmt.Errorf("TLS handshake failed: %v", err)
+		}
+		if tlsConn.ConnectionState().NegotiatedProtocol == "http/1.1" {
+			clientConn := cases.NewHTTP1Conn(conn)
+			clientConn.ID = id
+			clientConn.Accept = s.accept
+			log.Printf("Connection %d negotiated HTTP/1.1.", id)
+			s.mu.Lock()
+			s.conns = append(s.conns, clientConn)
+			s.mu.Unlock()
+			return clientConn, http2.NewFramer(clientConn, clientConn), nil
+		}
+	}
+	if s.early != nil {
+		s.early(conn, http2.NewFramer(conn, nil))
+	}
 	preface := make([]byte, len(http2.ClientPreface))
-	if _, err := conn.Read(preface); err != nil {
-		log.Printf("Failed to read client preface: %v", err)
-		return
+	if _, err := io.ReadFull(conn, preface); err != nil {
+		return nil, nil, fmt.Errorf("failed to read client preface: %v", err)
 	}
 	if string(preface) != http2.ClientPreface {
-		log.Printf("Incorrect client preface received: %s", string(preface))
-		return
+		return nil, nil, fmt.Errorf("incorrect client preface received: %s", string(preface))
 	}
 	log.Println("Client preface received.")
 
-	framer := http2.NewFramer(conn, conn)
+	clientConn := cases.NewConn(conn)
+	clientConn.ID = id
+	clientConn.Accept = s.accept
+	framer := http2.NewFramer(clientConn, clientConn)
 	frame, err := framer.ReadFrame()
 	if err != nil {
-		log.Printf("Failed to read client's initial SETTINGS frame: %v", err)
-		return
+		return nil, nil, fmt.Errorf("failed to read client's initial SETTINGS frame: %v", err)
 	}
-	if _, ok := frame.(*http2.SettingsFrame); !ok {
-		log.Printf("Expected a SETTINGS frame from client, but got %T", frame)
-		return
+	settings, ok := frame.(*http2.SettingsFrame)
+	if !ok {
+		return nil, nil, fmt.Errorf("expected a SETTINGS frame from client, but got %T", frame)
+	}
+	clientConn.Settings = cases.NewClientSettings(settings)
+	log.Printf("Client's initial SETTINGS frame received: %v", clientConn.Settings)
+
+	s.mu.Lock()
+	s.conns = append(s.conns, clientConn)
+	s.mu.Unlock()
+	s.serverPreface(clientConn, framer)
+	return clientConn, framer, nil
+}
+
+// violations returns the client violations recorded on every connection,
+// naming the connection when the client opened more than one.
+func (s *session) violations() []string {
+	s.mu.Lock()
+	conns := append([]*cases.Conn(nil), s.conns...)
+	s.mu.Unlock()
+	var violations []string
+	for _, c := range conns {
+		for _, v := range c.Violations() {
+			if len(conns) > 1 {
+				v = fmt.Sprintf("connection %d: %s", c.ID, v)
+			}
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+// close closes every connection of the session.
+func (s *session) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
 	}
-	log.Println("Client's initial SETTINGS frame received.")
+}
 
+// writeServerPreface sends the server connection preface, an empty SETTINGS
+// frame, and acknowledges the client's initial SETTINGS, for every test
+// case that does not replace it.
+func writeServerPreface(conn net.Conn, framer *http2.Framer) {
 	if err := framer.WriteSettings(); err != nil {
 		log.Printf("Failed to write initial server SETTINGS frame: %v", err)
 		return
 	}
 	log.Println("Initial server SETTINGS frame sent.")
-
-	testFunc(conn, framer)
+	if err := framer.WriteSettingsAck(); err != nil {
+		log.Printf("Failed to acknowledge the client's SETTINGS: %v", err)
+	}
 }
 
+// ensureCerts generates cert.pem and key.pem unless cert.pem exists and
+// covers every name the test cases serve.
 func ensureCerts() error {
-	if _, err := os.Stat("cert.pem"); os.IsNotExist(err) {
+	if err := certCovers("cert.pem", cases.CertificateNames); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		log.Printf("Certificate 'cert.pem' does not serve the test cases (%v), generating new one...", err)
+	} else {
 		log.Println("Certificate 'cert.pem' not found, generating new one...")
-		cmd := exec.Command("openssl", "req", "-x509", "-newkey", "rsa:2048", "-nodes", "-keyout", "key.pem", "-out", "cert.pem", "-days", "365", "-subj", "/CN=localhost")
-		cmd.Dir = "."
-		out, err := cmd.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("failed to generate certificate: %s\n%s", err, string(out))
+	}
+	san := "subjectAltName=IP:127.0.0.1"
+	for _, name := range cases.CertificateNames {
+		san += ",DNS:" + name
+	}
+	cmd := exec.Command("openssl", "req", "-x509", "-newkey", "rsa:2048", "-nodes", "-keyout", "key.pem", "-out", "cert.pem", "-days", "365", "-subj", "/CN=localhost", "-addext", san)
+	cmd.Dir = "."
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to generate certificate: %s\n%s", err, string(out))
+	}
+	log.Println("Successfully generated cert.pem and key.pem.")
+	return nil
+}
+
+// certCovers returns an error unless the certificate in file covers 127.0.0.1
+// and every one of names.
+func certCovers(file string, names []string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("no PEM data")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	for _, name := range append([]string{"127.0.0.1"}, names...) {
+		if err := leaf.VerifyHostname(name); err != nil {
+			return err
 		}
-		log.Println("Successfully generated cert.pem and key.pem.")
 	}
 	return nil
 }
@@ -0,0 +1,298 @@
+// Package priority implements the priority signals of the Extensible
+// Prioritization Scheme (RFC 9218): the Priority Field Value carried by the
+// priority header field and by PRIORITY_UPDATE frames, and the payload of
+// those frames.
+package priority
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http2"
+)
+
+// FramePriorityUpdate is the PRIORITY_UPDATE frame type (RFC 9218 Section
+// 7.1), which the http2 package does not define.
+const FramePriorityUpdate http2.FrameType = 0x10
+
+// Priority is the priority a Priority Field Value signals.
+type Priority struct {
+	// Urgency ranges from 0, the most urgent, to 7 (RFC 9218 Section 4.1).
+	Urgency int
+	// Incremental reports whether the response can be processed as it
+	// arrives (RFC 9218 Section 4.2).
+	Incremental bool
+}
+
+// Default is the priority of a request that signals none.
+var Default = Priority{Urgency: 3}
+
+// Parse parses a Priority Field Value, a Structured Field Dictionary (RFC
+// 8941 Section 3.2). It fails if value is not a valid Dictionary, or if it
+// gives the urgency as anything but an Integer from 0 to 7 or incremental
+// as anything but a Boolean. Other parameters are ignored.
+func Parse(value string) (Priority, error) {
+	dict, err := parseDictionary(value)
+	if err != nil {
+		return Priority{}, err
+	}
+	p := Default
+	if v, ok := dict["u"]; ok {
+		u, ok := v.(int64)
+		if !ok || u < 0 || u > 7 {
+			return Priority{}, fmt.Errorf("urgency %v is not an Integer from 0 to 7", v)
+		}
+		p.Urgency = int(u)
+	}
+	if v, ok := dict["i"]; ok {
+		i, ok := v.(bool)
+		if !ok {
+			return Priority{}, fmt.Errorf("incremental %v is not a Boolean", v)
+		}
+		p.Incremental = i
+	}
+	return p, nil
+}
+
+// String returns p as a Priority Field Value.
+func (p Priority) String() string {
+	s := "u=" + strconv.Itoa(p.Urgency)
+	if p.Incremental {
+		s += ", i"
+	}
+	return s
+}
+
+// EncodeUpdate returns the payload of a PRIORITY_UPDATE frame that signals
+// value for stream id.
+func EncodeUpdate(id uint32, value string) []byte {
+	payload := make([]byte, 4, 4+len(value))
+	binary.BigEndian.PutUint32(payload, id&(1<<31-1))
+	return append(payload, value...)
+}
+
+// DecodeUpdate returns the prioritized stream and the Priority Field Value
+// of a PRIORITY_UPDATE frame payload. It fails if the payload is too short
+// to name a stream.
+func DecodeUpdate(payload []byte) (uint32, string, error) {
+	if len(payload) < 4 {
+		return 0, "", fmt.Errorf("PRIORITY_UPDATE payload of %d octets", len(payload))
+	}
+	return binary.BigEndian.Uint32(payload) & (1<<31 - 1), string(payload[4:]), nil
+}
+
+// token is a Structured Field Token, kept apart from a String.
+type token string
+
+// innerList is a Structured Field Inner List.
+type innerList []interface{}
+
+// sfParser parses a Structured Field value. Parameters are parsed for
+// their syntax only.
+type sfParser struct {
+	s string
+}
+
+// parseDictionary parses s as a Dictionary (RFC 8941 Section 4.2.2) and
+// returns the bare value of every member. A key that occurs more than once
+// takes its last value.
+func parseDictionary(s string) (map[string]interface{}, error) {
+	p := &sfParser{s: strings.Trim(s, " ")}
+	dict := make(map[string]interface{})
+	for p.s != "" {
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var value interface{} = true
+		if p.consume('=') {
+			if value, err = p.member(); err != nil {
+				return nil, err
+			}
+		} else if err := p.parameters(); err != nil {
+			return nil, err
+		}
+		dict[key] = value
+
+		p.s = strings.TrimLeft(p.s, " \t")
+		if p.s == "" {
+			break
+		}
+		if !p.consume(',') {
+			return nil, fmt.Errorf("expected ',' after member %q, found %q", key, p.s)
+		}
+		p.s = strings.TrimLeft(p.s, " \t")
+		if p.s == "" {
+			return nil, fmt.Errorf("trailing ',' in dictionary")
+		}
+	}
+	return dict, nil
+}
+
+func (p *sfParser) consume(c byte) bool {
+	if p.s != "" && p.s[0] == c {
+		p.s = p.s[1:]
+		return true
+	}
+	return false
+}
+
+// member parses an Item or an Inner List with its parameters.
+func (p *sfParser) member() (interface{}, error) {
+	if !p.consume('(') {
+		v, err := p.bareItem()
+		if err != nil {
+			return nil, err
+		}
+		return v, p.parameters()
+	}
+	var list innerList
+	for {
+		p.s = strings.TrimLeft(p.s, " ")
+		if p.consume(')') {
+			return list, p.parameters()
+		}
+		v, err := p.bareItem()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.parameters(); err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+		if p.s != "" && p.s[0] != ' ' && p.s[0] != ')' {
+			return nil, fmt.Errorf("expected ' ' or ')' in inner list, found %q", p.s)
+		}
+	}
+}
+
+// parameters parses the parameters that may follow an Item or Inner List.
+func (p *sfParser) parameters() error {
+	for p.consume(';') {
+		p.s = strings.TrimLeft(p.s, " ")
+		if _, err := p.key(); err != nil {
+			return err
+		}
+		if p.consume('=') {
+			if _, err := p.bareItem(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// key parses a key: a lowercase letter or '*', then lowercase letters,
+// digits, '_', '-', '.' and '*'.
+func (p *sfParser) key() (string, error) {
+	if p.s == "" || !(isLower(p.s[0]) || p.s[0] == '*') {
+		return "", fmt.Errorf("expected a key, found %q", p.s)
+	}
+	n := 1
+	for n < len(p.s) && (isLower(p.s[n]) || isDigit(p.s[n]) || strings.IndexByte("_-.*", p.s[n]) >= 0) {
+		n++
+	}
+	key := p.s[:n]
+	p.s = p.s[n:]
+	return key, nil
+}
+
+// bareItem parses an Integer, Decimal, String, Token, Byte Sequence or
+// Boolean.
+func (p *sfParser) bareItem() (interface{}, error) {
+	if p.s == "" {
+		return nil, fmt.Errorf("expected an item, found the end of the value")
+	}
+	switch c := p.s[0]; {
+	case c == '-' || isDigit(c):
+		return p.number()
+	case c == '"':
+		return p.str()
+	case c == ':':
+		end := strings.IndexByte(p.s[1:], ':')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated byte sequence")
+		}
+		seq := p.s[1 : 1+end]
+		if strings.Trim(seq, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=") != "" {
+			return nil, fmt.Errorf("invalid byte sequence %q", seq)
+		}
+		p.s = p.s[2+end:]
+		return []byte(seq), nil
+	case c == '?':
+		if len(p.s) < 2 || (p.s[1] != '0' && p.s[1] != '1') {
+			return nil, fmt.Errorf("invalid boolean %q", p.s)
+		}
+		v := p.s[1] == '1'
+		p.s = p.s[2:]
+		return v, nil
+	case isAlpha(c) || c == '*':
+		n := 1
+		for n < len(p.s) && (isAlpha(p.s[n]) || isDigit(p.s[n]) || strings.IndexByte("!#$%&'*+-.^_`|~:/", p.s[n]) >= 0) {
+			n++
+		}
+		t := token(p.s[:n])
+		p.s = p.s[n:]
+		return t, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at the start of an item", p.s)
+}
+
+// number parses an Integer of at most 15 digits or a Decimal of at most 12
+// integer and 3 fractional digits.
+func (p *sfParser) number() (interface{}, error) {
+	n := 0
+	if p.s[0] == '-' {
+		n++
+	}
+	start, dot := n, -1
+	for n < len(p.s) && (isDigit(p.s[n]) || p.s[n] == '.' && dot < 0) {
+		if p.s[n] == '.' {
+			dot = n
+		}
+		n++
+	}
+	num := p.s[:n]
+	p.s = p.s[n:]
+	switch {
+	case n == start:
+		return nil, fmt.Errorf("expected a digit in %q", num)
+	case dot < 0:
+		if n-start > 15 {
+			return nil, fmt.Errorf("integer %s has more than 15 digits", num)
+		}
+		return strconv.ParseInt(num, 10, 64)
+	case dot == start || dot == n-1 || dot-start > 12 || n-dot-1 > 3:
+		return nil, fmt.Errorf("invalid decimal %s", num)
+	}
+	return strconv.ParseFloat(num, 64)
+}
+
+// str parses a String, whose only escapes are \" and \\.
+func (p *sfParser) str() (interface{}, error) {
+	var b strings.Builder
+	for i := 1; i < len(p.s); i++ {
+		switch c := p.s[i]; {
+		case c == '"':
+			p.s = p.s[i+1:]
+			return b.String(), nil
+		case c == '\\':
+			i++
+			if i == len(p.s) || (p.s[i] != '"' && p.s[i] != '\\') {
+				return nil, fmt.Errorf("invalid escape in string")
+			}
+			b.WriteByte(p.s[i])
+		case c < 0x20 || c > 0x7e:
+			return nil, fmt.Errorf("invalid character 0x%02x in string", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return nil, fmt.Errorf("unterminated string")
+}
+
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+func isAlpha(c byte) bool { return isLower(c) || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
@@ -0,0 +1,119 @@
+// Package profile names the editions of the HTTP/2 specification a client
+// can be judged against, and maps every test case to the clause it checks
+// under each edition.
+package profile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile is an edition of the HTTP/2 specification.
+type Profile string
+
+const (
+	// RFC7540 judges the client against RFC 7540, the edition h2spec and
+	// the test case numbering follow. It is the default.
+	RFC7540 Profile = "rfc7540"
+	// RFC9113 judges the client against RFC 9113, which obsoletes RFC 7540
+	// and adds the test cases numbered rfc9113/<section>/<n>.
+	RFC9113 Profile = "rfc9113"
+)
+
+// All lists every profile.
+var All = []Profile{RFC7540, RFC9113}
+
+// Parse returns the profile named s.
+func Parse(s string) (Profile, error) {
+	for _, p := range All {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown profile %q; expected one of %v", s, All)
+}
+
+// rfc9113Sections maps the sections of RFC 7540 that RFC 9113 renumbered
+// to their RFC 9113 counterparts. Sections missing here kept their number.
+// Section 5.3.1 stays: in RFC 9113 it is the note that deprecates the stream
+// dependencies the 5.3.1 test cases exercise, while Section 5.3.2 defines
+// SETTINGS_NO_RFC7540_PRIORITIES.
+var rfc9113Sections = map[string]string{
+	"3.5":     "3.4",
+	"8.1.2":   "8.2",
+	"8.1.2.1": "8.3",
+	"8.1.2.2": "8.2.2",
+	"8.1.2.3": "8.3.1",
+	"8.1.2.4": "8.3.2",
+	"8.1.2.5": "8.2.3",
+	"8.1.2.6": "8.1.1",
+	"8.1.4":   "8.7",
+	"8.2":     "8.4",
+	"8.3":     "8.5",
+	"9.1.2":   "9.1.1",
+}
+
+// genericSections maps the groups of the generic test cases, which follow
+// the h2spec generic suite, to the RFC 7540 sections they exercise.
+var genericSections = map[string]string{
+	"1":    "3.5",
+	"2":    "5.1",
+	"3.1":  "6.1",
+	"3.2":  "6.2",
+	"3.3":  "6.3",
+	"3.4":  "6.4",
+	"3.5":  "6.5",
+	"3.7":  "6.7",
+	"3.8":  "6.8",
+	"3.9":  "6.9",
+	"3.10": "6.10",
+	"4":    "8.1",
+}
+
+// Clause returns the clause that test case id checks under profile p, such
+// as "RFC 9113 §8.2.2", and whether the test case applies under p at all.
+// The test cases numbered rfc9113/<section>/<n> check requirements RFC 9113
+// added and only apply under RFC9113; those numbered rfcNNNN/<section>/<n>
+// for any other RFC check an extension and apply under every profile.
+func Clause(p Profile, id string) (string, bool) {
+	parts := strings.Split(id, "/")
+	group, section := "", parts[0]
+	if len(parts) > 2 {
+		group, section = parts[0], parts[1]
+	}
+	edition := "RFC 7540"
+	if p == RFC9113 {
+		edition = "RFC 9113"
+	}
+
+	switch {
+	case group == "hpack":
+		return cite("RFC 7541", section), true
+	case section == "grpc":
+		return "gRPC over HTTP/2", true
+	case group == "rfc9113":
+		return cite("RFC 9113", section), p == RFC9113
+	case strings.HasPrefix(group, "rfc"):
+		return cite("RFC "+strings.TrimPrefix(group, "rfc"), section), true
+	case group == "generic":
+		section = genericSections[section]
+	}
+	if section == "" {
+		return edition, true
+	}
+	if p == RFC9113 {
+		if renumbered, ok := rfc9113Sections[section]; ok {
+			section = renumbered
+		}
+	}
+	return cite(edition, section), true
+}
+
+// cite returns the clause section of rfc, or rfc itself if section is not
+// a section number.
+func cite(rfc, section string) string {
+	if section == "" || strings.Trim(section, "0123456789.") != "" {
+		return rfc
+	}
+	return rfc + " §" + section
+}
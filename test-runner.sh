@@ -5,6 +5,19 @@
 
 set -e
 
+HARNESS_BIN="${HARNESS_BIN:-/h2-client-test-harness}"
+VERIFIER_BIN="${VERIFIER_BIN:-/h2-verifier}"
+REPEAT=1
+PROFILE=rfc7540
+
+# EXIT_CLIENT_VIOLATION is the harness's exit status when it observed the
+# client violating the protocol.
+EXIT_CLIENT_VIOLATION=3
+
+# EXIT_SKIPPED is the verifier's exit status when the harness skipped the
+# test case, as the client's settings left it nothing to check.
+EXIT_SKIPPED=4
+
 usage() {
     echo "Usage: $0 [OPTIONS]"
     echo ""
@@ -13,28 +26,167 @@ usage() {
     echo "  --list               List all available test cases"
     echo "  --harness-only       Run only the harness (for external testing)"
     echo "  --verify-all         Run all tests and verify them"
+    echo "  --repeat=<n>         Run each selected test n times and report flaky ones"
+    echo "  --profile=<name>     Judge the client against rfc7540 (default) or rfc9113"
+    echo "  --mutation-check     Check that every test fails against its mutant client"
     echo "  --help               Show this help message"
     echo ""
     echo "Examples:"
     echo "  $0 --test=6.5/1      Run SETTINGS test"
     echo "  $0 --list            List all available tests"
     echo "  $0 --verify-all      Run complete test suite"
+    echo "  $0 --verify-all --repeat=5"
+    echo "                       Run complete test suite five times per test"
+    echo "  $0 --verify-all --profile=rfc9113"
+    echo "                       Run complete test suite under RFC 9113"
+}
+
+# run_once runs a single harness/verifier pair for a test case and stores
+# both transcripts in the given directory. Any further arguments are passed
+# to the verifier. It returns the verifier's status.
+run_once() {
+    test_id="$1"
+    run_dir="$2"
+    shift 2
+
+    timeout 10s "$HARNESS_BIN" --test="$test_id" --profile="$PROFILE" >"$run_dir/harness.log" 2>&1 &
+    harness_pid=$!
+
+    sleep 2
+
+    timeout 5s "$VERIFIER_BIN" --test="$test_id" "$@" >"$run_dir/verifier.log" 2>&1 && outcome=0 || outcome=$?
+
+    finish_harness $harness_pid && harness_status=0 || harness_status=$?
+    if [ $harness_status -eq $EXIT_CLIENT_VIOLATION ]; then
+        # The harness saw the client violate the protocol: the test fails,
+        # which for a mutant run means the mutant was killed.
+        case " $* " in
+            *" --mutate "*) [ $outcome -eq 2 ] || outcome=0 ;;
+            *) outcome=1 ;;
+        esac
+    fi
+
+    return $outcome
+}
+
+# finish_harness gives the harness up to three seconds to finish grading
+# the client's connection, stops it if it has not, and returns its status.
+# It counts in its own variable, since run_repeated loops on i.
+finish_harness() {
+    polls=0
+    while [ $polls -lt 30 ] && kill -0 "$1" 2>/dev/null; do
+        sleep 0.1
+        polls=$((polls + 1))
+    done
+    kill "$1" 2>/dev/null || true
+    wait "$1" 2>/dev/null && return 0 || return $?
+}
+
+# print_transcript dumps the harness and verifier logs of one run.
+print_transcript() {
+    run_dir="$1"
+    echo "----- $(basename "$run_dir"): $(cat "$run_dir/outcome") -----"
+    echo "[harness]"
+    sed 's/^/    /' "$run_dir/harness.log"
+    echo "[verifier]"
+    sed 's/^/    /' "$run_dir/verifier.log"
+}
+
+# run_repeated runs a test case $REPEAT times. It returns 0 if every run
+# passed, 1 if every run failed, 3 if the harness skipped every run and 2
+# if the outcome varied between runs, in which case the transcripts of the
+# first two runs with different outcomes are printed.
+run_repeated() {
+    test_id="$1"
+    test_dir="$TRANSCRIPT_DIR/$(echo "$test_id" | tr '/' '_')"
+    passes=0
+    fails=0
+    skips=0
+    first_pass=""
+    first_fail=""
+    first_skip=""
+
+    i=1
+    while [ $i -le "$REPEAT" ]; do
+        run_dir="$test_dir/run-$i"
+        mkdir -p "$run_dir"
+        run_once "$test_id" "$run_dir" && status=0 || status=$?
+        case $status in
+            0)
+                echo "PASS" >"$run_dir/outcome"
+                passes=$((passes + 1))
+                [ -n "$first_pass" ] || first_pass="$run_dir"
+                ;;
+            $EXIT_SKIPPED)
+                echo "SKIP" >"$run_dir/outcome"
+                skips=$((skips + 1))
+                [ -n "$first_skip" ] || first_skip="$run_dir"
+                ;;
+            *)
+                echo "FAIL" >"$run_dir/outcome"
+                fails=$((fails + 1))
+                [ -n "$first_fail" ] || first_fail="$run_dir"
+                ;;
+        esac
+        sleep 1
+        i=$((i + 1))
+    done
+
+    if [ $passes -ne "$REPEAT" ] && [ $fails -ne "$REPEAT" ] && [ $skips -ne "$REPEAT" ]; then
+        echo "⚠️  $test_id FLAKY ($passes/$REPEAT runs passed, $skips skipped)"
+        for run_dir in $first_pass $first_fail $first_skip; do
+            print_transcript "$run_dir"
+        done
+        return 2
+    fi
+    if [ $fails -gt 0 ]; then
+        return 1
+    fi
+    if [ $skips -gt 0 ]; then
+        return 3
+    fi
+    return 0
 }
 
+ARGS=""
+for arg in "$@"; do
+    case "$arg" in
+        --repeat=*)
+            REPEAT="${arg#--repeat=}"
+            ;;
+        --profile=*)
+            PROFILE="${arg#--profile=}"
+            ;;
+        *)
+            ARGS="$ARGS $arg"
+            ;;
+    esac
+done
+set -- $ARGS
+
+case "$REPEAT" in
+    ''|*[!0-9]*|0)
+        echo "Error: --repeat requires a positive integer"
+        exit 1
+        ;;
+esac
+
 if [ $# -eq 0 ] || [ "$1" = "--help" ]; then
     usage
     exit 0
 fi
 
+TRANSCRIPT_DIR="${TRANSCRIPT_DIR:-$(mktemp -d)}"
+
 case "$1" in
     --list)
         echo "Available harness test cases:"
-        /h2-client-test-harness
+        "$HARNESS_BIN" --profile="$PROFILE"
         echo ""
         echo "Available verifier test cases:"
-        /h2-verifier
+        "$VERIFIER_BIN"
         ;;
-    
+
     --harness-only)
         if [ -z "$2" ]; then
             echo "Error: --harness-only requires a test case"
@@ -42,81 +194,115 @@ case "$1" in
             exit 1
         fi
         echo "Starting harness for test case: ${2#--test=}"
-        exec /h2-client-test-harness "$2"
+        exec "$HARNESS_BIN" "$2" --profile="$PROFILE"
         ;;
-    
+
     --test=*)
         TEST_ID="${1#--test=}"
+
+        if [ "$REPEAT" -gt 1 ]; then
+            echo "Running test case: $TEST_ID ($REPEAT times)"
+            run_repeated "$TEST_ID" && RESULT=0 || RESULT=$?
+            case $RESULT in
+                0) echo "✅ Test $TEST_ID PASSED in all $REPEAT runs" ;;
+                1) echo "❌ Test $TEST_ID FAILED in all $REPEAT runs" ;;
+                3) echo "➖ Test $TEST_ID SKIPPED in all $REPEAT runs" ;;
+            esac
+            echo "Transcripts: $TRANSCRIPT_DIR"
+            [ $RESULT -eq 0 ] || [ $RESULT -eq 3 ] && exit 0 || exit 1
+        fi
+
         echo "Running test case: $TEST_ID"
         echo "Starting harness..."
-        
+
         # Start harness in background
-        /h2-client-test-harness --test="$TEST_ID" &
+        "$HARNESS_BIN" --test="$TEST_ID" --profile="$PROFILE" &
         HARNESS_PID=$!
-        
+
         # Wait for harness to start
         sleep 3
-        
+
         # Run verifier
         echo "Running verifier..."
-        if /h2-verifier --test="$TEST_ID"; then
+        "$VERIFIER_BIN" --test="$TEST_ID" && RESULT=0 || RESULT=$?
+        [ $RESULT -eq 0 ] || [ $RESULT -eq $EXIT_SKIPPED ] || RESULT=1
+
+        # Collect the harness's own verdict on the client
+        finish_harness $HARNESS_PID && HARNESS_STATUS=0 || HARNESS_STATUS=$?
+        if [ $HARNESS_STATUS -eq $EXIT_CLIENT_VIOLATION ]; then
+            echo "Harness observed client protocol violations"
+            RESULT=1
+        fi
+
+        if [ $RESULT -eq 0 ]; then
             echo "✅ Test $TEST_ID PASSED"
+        elif [ $RESULT -eq $EXIT_SKIPPED ]; then
+            echo "➖ Test $TEST_ID SKIPPED: the client's settings leave it nothing to check"
             RESULT=0
         else
             echo "❌ Test $TEST_ID FAILED"
-            RESULT=1
         fi
-        
-        # Cleanup
-        kill $HARNESS_PID 2>/dev/null || true
-        wait $HARNESS_PID 2>/dev/null || true
-        
+
         exit $RESULT
         ;;
-    
+
     --verify-all)
-        echo "Running complete H2SPEC test suite verification..."
+        echo "Running complete H2SPEC test suite verification under profile $PROFILE..."
         PASSED=0
         FAILED=0
-        
+        FLAKY=0
+        FLAKY_TESTS=""
+        SKIPPED=0
+        SKIPPED_TESTS=""
+
         # Get list of all tests from harness
-        TESTS=$(/h2-client-test-harness 2>&1 | grep "  - " | sed 's/  - //')
-        
+        TESTS=$("$HARNESS_BIN" --profile="$PROFILE" 2>&1 | awk '/^  - / { print $2 }')
+
         for test in $TESTS; do
             echo "Testing: $test"
-            
-            # Start harness in background
-            timeout 10s /h2-client-test-harness --test="$test" &
-            HARNESS_PID=$!
-            
-            sleep 2
-            
-            # Run verifier with timeout
-            if timeout 5s /h2-verifier --test="$test" >/dev/null 2>&1; then
-                echo "✅ $test PASSED"
-                PASSED=$((PASSED + 1))
-            else
-                echo "❌ $test FAILED"
-                FAILED=$((FAILED + 1))
-            fi
-            
-            # Cleanup
-            kill $HARNESS_PID 2>/dev/null || true
-            wait $HARNESS_PID 2>/dev/null || true
-            
-            sleep 1
+
+            run_repeated "$test" && RESULT=0 || RESULT=$?
+            case $RESULT in
+                0)
+                    echo "✅ $test PASSED"
+                    PASSED=$((PASSED + 1))
+                    ;;
+                1)
+                    echo "❌ $test FAILED"
+                    FAILED=$((FAILED + 1))
+                    ;;
+                2)
+                    FLAKY=$((FLAKY + 1))
+                    FLAKY_TESTS="$FLAKY_TESTS $test"
+                    ;;
+                3)
+                    echo "➖ $test SKIPPED"
+                    SKIPPED=$((SKIPPED + 1))
+                    SKIPPED_TESTS="$SKIPPED_TESTS $test"
+                    ;;
+            esac
         done
-        
+
         echo ""
         echo "========================================="
         echo "Test Results Summary:"
         echo "PASSED: $PASSED"
         echo "FAILED: $FAILED"
-        echo "TOTAL:  $((PASSED + FAILED))"
-        echo "SUCCESS RATE: $((PASSED * 100 / (PASSED + FAILED)))%"
+        if [ "$REPEAT" -gt 1 ]; then
+            echo "FLAKY:  $FLAKY"
+            echo "RUNS PER TEST: $REPEAT"
+        fi
+        echo "SKIPPED: $SKIPPED"
+        echo "TOTAL:  $((PASSED + FAILED + FLAKY + SKIPPED))"
+        echo "SUCCESS RATE: $((PASSED * 100 / (PASSED + FAILED + FLAKY)))% (of the tests not skipped)"
         echo "========================================="
-        
-        if [ $FAILED -eq 0 ]; then
+        [ -z "$SKIPPED_TESTS" ] || echo "Skipped tests:$SKIPPED_TESTS"
+        if [ $FLAKY -gt 0 ]; then
+            echo "Flaky tests:$FLAKY_TESTS"
+            echo "Transcripts: $TRANSCRIPT_DIR"
+        fi
+
+        if [ $FAILED -eq 0 ] && [ $FLAKY -eq 0 ]; then
             echo "🎉 All tests passed!"
             exit 0
         else
@@ -124,10 +310,61 @@ case "$1" in
             exit 1
         fi
         ;;
-    
+
+    --mutation-check)
+        echo "Running every test case against its mutant client..."
+        KILLED=0
+        SURVIVED=""
+        UNGUARDED=""
+        INCONCLUSIVE=""
+
+        TESTS=$("$HARNESS_BIN" --profile="$PROFILE" 2>&1 | awk '/^  - / { print $2 }')
+
+        for test in $TESTS; do
+            test_dir="$TRANSCRIPT_DIR/$(echo "$test" | tr '/' '_')"
+            mkdir -p "$test_dir/mutant" "$test_dir/control"
+
+            run_once "$test" "$test_dir/mutant" --mutate && RESULT=0 || RESULT=$?
+            sleep 1
+            if [ $RESULT -eq 2 ]; then
+                echo "➖ $test has no mutant"
+                UNGUARDED="$UNGUARDED $test"
+                continue
+            fi
+
+            # A kill only counts if the compliant mutant passes the same test.
+            if ! run_once "$test" "$test_dir/control" --faults=none; then
+                echo "❔ $test INCONCLUSIVE (compliant mutant fails too)"
+                INCONCLUSIVE="$INCONCLUSIVE $test"
+            elif [ $RESULT -eq 0 ]; then
+                echo "✅ $test KILLED its mutant"
+                KILLED=$((KILLED + 1))
+            else
+                echo "❌ $test SURVIVED by its mutant"
+                SURVIVED="$SURVIVED $test"
+            fi
+            sleep 1
+        done
+
+        echo ""
+        echo "========================================="
+        echo "Mutation Check Summary:"
+        echo "KILLED:       $KILLED"
+        echo "SURVIVED:     $(echo $SURVIVED | wc -w)"
+        echo "INCONCLUSIVE: $(echo $INCONCLUSIVE | wc -w)"
+        echo "NO MUTANT:    $(echo $UNGUARDED | wc -w)"
+        echo "========================================="
+        [ -z "$SURVIVED" ] || echo "Survived (no discriminating power):$SURVIVED"
+        [ -z "$INCONCLUSIVE" ] || echo "Inconclusive:$INCONCLUSIVE"
+        [ -z "$UNGUARDED" ] || echo "No mutant registered:$UNGUARDED"
+        echo "Transcripts: $TRANSCRIPT_DIR"
+
+        [ -z "$SURVIVED" ] && exit 0 || exit 1
+        ;;
+
     *)
         echo "Unknown option: $1"
         usage
         exit 1
         ;;
-esac
\ No newline at end of file
+esac
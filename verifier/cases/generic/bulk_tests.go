@@ -32,7 +32,7 @@ func testGeneric4_1() error {
 	return verifier.ExpectSuccessfulRequest()
 }
 
-// Test Case generic/4/2: Sends a POST request.
+// Test Case generic/4/2: Receives a POST request with a body.
 func testGeneric4_2() error {
-	return verifier.ExpectSuccessfulRequest()
+	return verifier.ExpectUpload(1024)
 }
\ No newline at end of file
@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"time"
+
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+)
+
+func init() {
+	verifier.Register("grpc/1", testGrpc1)
+	verifier.Register("grpc/2", testGrpc2)
+	verifier.Register("grpc/3", testGrpc3)
+	verifier.Register("grpc/4", testGrpc4)
+	verifier.Register("grpc/5", testGrpc5)
+	verifier.Register("grpc/6", testGrpc6)
+	verifier.Register("grpc/7", testGrpc7)
+	verifier.Register("grpc/8", testGrpc8)
+
+	verifier.RegisterMutant("grpc/1", mutant.DropTrailers)
+	verifier.RegisterMutant("grpc/3", mutant.DropTrailers)
+	verifier.RegisterMutant("grpc/5", mutant.IgnoreSendWindow)
+	verifier.RegisterMutant("grpc/6", mutant.TruncateOnReset)
+}
+
+// Test Case grpc/1: Answers a unary gRPC call with its request message echoed back and grpc-status 0 in the trailers.
+// Expected: Client should read the echoed message intact and report grpc-status 0 from the trailers.
+func testGrpc1() error {
+	return verifier.ExpectGRPCEcho(16)
+}
+
+// Test Case grpc/2: Answers a gRPC call with a trailers-only response carrying grpc-status 5.
+// Expected: Client should end the call without messages and report grpc-status 5 from the headers.
+func testGrpc2() error {
+	return verifier.ExpectGRPCStatus("5", "not found")
+}
+
+// Test Case grpc/3: Sends grpc-status 0 in the response headers and grpc-status 13 in the trailers.
+// Expected: Client should report the status of the trailers, 13.
+func testGrpc3() error {
+	return verifier.ExpectGRPCStatus("13", "internal error")
+}
+
+// Test Case grpc/4: Echoes messages of 1, 300 and 5000 octets in DATA frames cut at arbitrary boundaries.
+// Expected: Client should reassemble every message intact.
+func testGrpc4() error {
+	return verifier.ExpectGRPCEcho(1, 300, 5000)
+}
+
+// Test Case grpc/5: Echoes every message of a bidirectional call under a stream window of 1 KiB.
+// Expected: Client should send eight 4 KiB messages within its credit and get each echoed back.
+func testGrpc5() error {
+	return verifier.ExpectGRPCStream(8, 4096)
+}
+
+// Test Case grpc/6: Sends two response messages, then RST_STREAM CANCEL.
+// Expected: Client should fail the call with CANCEL after the two messages.
+func testGrpc6() error {
+	return verifier.ExpectGRPCReset(2, "CANCEL")
+}
+
+// Test Case grpc/7: Answers the client's keepalive PINGs on a held call with GOAWAY ENHANCE_YOUR_CALM.
+// Expected: Client should fail the call with ENHANCE_YOUR_CALM.
+func testGrpc7() error {
+	return verifier.ExpectGRPCKeepAlive(200*time.Millisecond, "ENHANCE_YOUR_CALM")
+}
+
+// Test Case grpc/8: Answers a gRPC call with grpc-status 3 and a percent-encoded grpc-message.
+// Expected: Client should report grpc-status 3 with the decoded message.
+func testGrpc8() error {
+	return verifier.ExpectGRPCStatus("3", "100%\tover quota: café")
+}
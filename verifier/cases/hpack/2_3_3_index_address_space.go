@@ -2,6 +2,7 @@ package hpack
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
@@ -11,4 +12,7 @@ func init() {
 	verifier.Register("hpack/2.3.3/2", func() error {
 		return verifier.ExpectConnectionError("COMPRESSION_ERROR")
 	})
+
+	verifier.RegisterMutant("hpack/2.3.3/1", mutant.IgnoreHPACKErrors)
+	verifier.RegisterMutant("hpack/2.3.3/2", mutant.IgnoreHPACKErrors)
 }
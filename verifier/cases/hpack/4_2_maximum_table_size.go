@@ -2,10 +2,24 @@ package hpack
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("hpack/4.2/1", func() error {
 		return verifier.ExpectConnectionError("COMPRESSION_ERROR")
 	})
-}
\ No newline at end of file
+	verifier.Register("hpack/4.2/2", func() error {
+		return verifier.ExpectResponseHeader("x-table-size", "resized")
+	})
+	verifier.Register("hpack/4.2/3", func() error {
+		return verifier.ExpectIndexedRequests(3)
+	})
+	verifier.Register("hpack/4.2/4", func() error {
+		return verifier.ExpectIndexedRequests(3)
+	})
+
+	verifier.RegisterMutant("hpack/4.2/1", mutant.IgnoreHPACKErrors)
+	verifier.RegisterMutant("hpack/4.2/3", mutant.SkipTableSizeUpdate)
+	verifier.RegisterMutant("hpack/4.2/4", mutant.MisplaceTableSizeUpdate)
+}
@@ -1,13 +1,23 @@
 package hpack
 
 import (
+	"strings"
+
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("hpack/5.2/1", testHpack5_2_1)
 	verifier.Register("hpack/5.2/2", testHpack5_2_2)
 	verifier.Register("hpack/5.2/3", testHpack5_2_3)
+	verifier.Register("hpack/5.2/4", testHpack5_2_4)
+	verifier.Register("hpack/5.2/5", testHpack5_2_5)
+
+	verifier.RegisterMutant("hpack/5.2/1", mutant.IgnoreHPACKErrors)
+	verifier.RegisterMutant("hpack/5.2/2", mutant.IgnoreHPACKErrors)
+	verifier.RegisterMutant("hpack/5.2/3", mutant.IgnoreHPACKErrors)
+	verifier.RegisterMutant("hpack/5.2/5", mutant.CorruptHuffman)
 }
 
 // Test Case hpack/5.2/1: Sends a Huffman-encoded string literal representation with padding longer than 7 bits.
@@ -26,4 +36,20 @@ func testHpack5_2_2() error {
 // Expected: Client should detect COMPRESSION_ERROR and close connection.
 func testHpack5_2_3() error {
 	return verifier.ExpectConnectionError("COMPRESSION_ERROR", "huffman", "eos")
-}
\ No newline at end of file
+}
+
+// Test Case hpack/5.2/4: Sends a response header whose value is a Huffman-encoded string literal.
+// Expected: Client should decode the value to its original text.
+func testHpack5_2_4() error {
+	return verifier.ExpectResponseHeader("x-huffman", "decoded-from-huffman")
+}
+
+// Test Case hpack/5.2/5: Serves seven requests whose x-huffman values leave every Huffman padding length.
+// Expected: Client should pad each Huffman-encoded value with the EOS prefix.
+func testHpack5_2_5() error {
+	values := make([]string, 7)
+	for i := range values {
+		values[i] = strings.Repeat("a", i+2)
+	}
+	return verifier.ExpectRequestsWithHeader("X-Huffman", values...)
+}
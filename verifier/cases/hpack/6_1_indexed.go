@@ -2,14 +2,32 @@ package hpack
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("hpack/6.1/1", testHpack6_1_1)
+	verifier.Register("hpack/6.1/2", testHpack6_1_2)
+	verifier.Register("hpack/6.1/3", testHpack6_1_3)
+
+	verifier.RegisterMutant("hpack/6.1/1", mutant.IgnoreHPACKErrors)
+	verifier.RegisterMutant("hpack/6.1/3", mutant.BadTableIndex)
 }
 
 // Test Case hpack/6.1/1: Sends a indexed header field representation with index 0.
 // Expected: Client should detect COMPRESSION_ERROR and close connection.
 func testHpack6_1_1() error {
 	return verifier.ExpectConnectionError("COMPRESSION_ERROR", "index", "hpack")
-}
\ No newline at end of file
+}
+
+// Test Case hpack/6.1/2: Adds a field to the dynamic table and references it by index later in the same header block.
+// Expected: Client should resolve the index to the new entry and report the field twice.
+func testHpack6_1_2() error {
+	return verifier.ExpectResponseHeader("x-indexed", "entry", "entry")
+}
+
+// Test Case hpack/6.1/3: Serves three requests that repeat a field and add a new one each.
+// Expected: Client should only index entries of the static and dynamic tables.
+func testHpack6_1_3() error {
+	return verifier.ExpectIndexedRequests(3)
+}
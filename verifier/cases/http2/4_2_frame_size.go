@@ -1,30 +1,51 @@
 package http2
 
 import (
+	"strings"
+
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
-	"golang.org/x/net/http2"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("4.2/1", test4_2_1)
 	verifier.Register("4.2/2", test4_2_2)
 	verifier.Register("4.2/3", test4_2_3)
+	verifier.Register("4.2/4", test4_2_4)
+	verifier.Register("4.2/5", test4_2_5)
+
+	verifier.RegisterMutant("4.2/2", mutant.IgnoreFrameSize)
+	verifier.RegisterMutant("4.2/3", mutant.IgnoreFrameSize)
+	verifier.RegisterMutant("4.2/4", mutant.IgnorePeerMaxFrameSize)
+	verifier.RegisterMutant("4.2/5", mutant.IgnoreFrameSizeDecrease)
 }
 
 // Test Case 4.2/1: Sends a DATA frame with 2^14 octets in length.
 // Expected: Client should process the maximum size frame successfully.
 func test4_2_1() error {
-	return verifier.ExpectSuccessfulRequest()
+	return verifier.ExpectResponse(200, strings.Repeat("x", 16384))
 }
 
-// Test Case 4.2/2: Sends a large size DATA frame that exceeds the SETTINGS_MAX_FRAME_SIZE.
-// Expected: Client should detect FRAME_SIZE_ERROR on the stream.
+// Test Case 4.2/2: Sends a DATA frame one octet larger than the client's SETTINGS_MAX_FRAME_SIZE.
+// Expected: Client should detect FRAME_SIZE_ERROR while reading the response body.
 func test4_2_2() error {
-	return verifier.ExpectStreamError(http2.ErrCodeFrameSize)
+	return verifier.ExpectResponseError("FRAME_SIZE_ERROR", "frame too large")
 }
 
-// Test Case 4.2/3: Sends a large size HEADERS frame that exceeds the SETTINGS_MAX_FRAME_SIZE.
+// Test Case 4.2/3: Sends a HEADERS frame one octet larger than the client's SETTINGS_MAX_FRAME_SIZE.
 // Expected: Client should detect FRAME_SIZE_ERROR on the connection.
 func test4_2_3() error {
 	return verifier.ExpectConnectionError("FRAME_SIZE_ERROR", "frame", "size")
-}
\ No newline at end of file
+}
+
+// Test Case 4.2/4: Announces the minimum SETTINGS_MAX_FRAME_SIZE of 2^14 explicitly with wide windows and receives a 1 MiB request body.
+// Expected: Client should upload the body intact in DATA frames of at most 2^14 octets; the harness grades the frames.
+func test4_2_4() error {
+	return verifier.ExpectUpload(1 << 20)
+}
+
+// Test Case 4.2/5: Announces a SETTINGS_MAX_FRAME_SIZE of 2^16 and lowers it to 2^14 in the middle of a 1 MiB request body.
+// Expected: Client should upload the body intact and follow the lowered limit once it acknowledges it; the harness grades the frames.
+func test4_2_5() error {
+	return verifier.ExpectUpload(1 << 20)
+}
@@ -2,11 +2,17 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("5.1.1/1", test5_1_1_1)
 	verifier.Register("5.1.1/2", test5_1_1_2)
+	verifier.Register("5.1.1/3", test5_1_1_3)
+
+	verifier.RegisterMutant("5.1.1/1", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1.1/2", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1.1/3", mutant.ReuseStreamID)
 }
 
 // Test Case 5.1.1/1: Sends even-numbered stream identifier.
@@ -19,4 +25,10 @@ func test5_1_1_1() error {
 // Expected: Client should detect PROTOCOL_ERROR and close connection.
 func test5_1_1_2() error {
 	return verifier.ExpectConnectionError("PROTOCOL_ERROR", "stream", "order")
-}
\ No newline at end of file
+}
+
+// Test Case 5.1.1/3: Serves three sequential requests on one connection.
+// Expected: Client should open each on a new, odd and increasing stream identifier.
+func test5_1_1_3() error {
+	return verifier.ExpectResponses(3, 200, "ok")
+}
@@ -2,11 +2,18 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 	"golang.org/x/net/http2"
 )
 
 func init() {
 	verifier.Register("5.1.2/1", test5_1_2_1)
+	verifier.Register("5.1.2/2", test5_1_2_2)
+	verifier.Register("5.1.2/3", test5_1_2_3)
+
+	verifier.RegisterMutant("5.1.2/1", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1.2/2", mutant.IgnoreMaxConcurrentStreams)
+	verifier.RegisterMutant("5.1.2/3", mutant.IgnoreStreamLimitDecrease)
 }
 
 // Test Case 5.1.2/1: Sends HEADERS frames that causes their advertised concurrent stream limit to be exceeded.
@@ -18,4 +25,16 @@ func test5_1_2_1() error {
 		return nil
 	}
 	return verifier.ExpectConnectionError("PROTOCOL_ERROR", "concurrent", "stream")
-}
\ No newline at end of file
+}
+
+// Test Case 5.1.2/2: Advertises a SETTINGS_MAX_CONCURRENT_STREAMS of 1 under a workload of parallel requests.
+// Expected: Client queues its requests or opens further connections, and every request succeeds.
+func test5_1_2_2() error {
+	return verifier.ExpectParallelResponses(6)
+}
+
+// Test Case 5.1.2/3: Lowers SETTINGS_MAX_CONCURRENT_STREAMS from 4 to 1 and raises it to 3 under a workload of parallel requests.
+// Expected: Client follows every change of the limit, and every request succeeds.
+func test5_1_2_3() error {
+	return verifier.ExpectParallelResponses(12)
+}
@@ -2,6 +2,7 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 	"golang.org/x/net/http2"
 )
 
@@ -19,6 +20,20 @@ func init() {
 	verifier.Register("5.1/11", test5_1_11)
 	verifier.Register("5.1/12", test5_1_12)
 	verifier.Register("5.1/13", test5_1_13)
+
+	verifier.RegisterMutant("5.1/1", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/2", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/3", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/4", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/5", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/6", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/7", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/8", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/9", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/10", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/11", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/12", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("5.1/13", mutant.IgnoreStreamState)
 }
 
 // Test Case 5.1/1: idle: Sends a DATA frame.
@@ -2,12 +2,16 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 	"golang.org/x/net/http2"
 )
 
 func init() {
 	verifier.Register("5.3.1/1", test5_3_1_1)
 	verifier.Register("5.3.1/2", test5_3_1_2)
+
+	verifier.RegisterMutant("5.3.1/1", mutant.IgnoreSelfDependency)
+	verifier.RegisterMutant("5.3.1/2", mutant.IgnoreSelfDependency)
 }
 
 // Test Case 5.3.1/1: Sends HEADERS frame that depends on itself.
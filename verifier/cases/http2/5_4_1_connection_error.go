@@ -2,11 +2,15 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("5.4.1/1", test5_4_1_1)
 	verifier.Register("5.4.1/2", test5_4_1_2)
+
+	verifier.RegisterMutant("5.4.1/1", mutant.IgnoreFrameLength)
+	verifier.RegisterMutant("5.4.1/2", mutant.IgnoreNonZeroStream)
 }
 
 // Test Case 5.4.1/1: Sends an invalid PING frame for connection close.
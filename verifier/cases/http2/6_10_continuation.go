@@ -2,6 +2,7 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
@@ -20,4 +21,17 @@ func init() {
 	verifier.Register("6.10/6", func() error {
 		return verifier.ExpectConnectionError("PROTOCOL_ERROR")
 	})
+	verifier.Register("6.10/7", func() error {
+		return verifier.ExpectResponseHeader("x-continued", "reassembled-from-three-frames")
+	})
+	verifier.Register("6.10/8", func() error {
+		return verifier.ExpectHeaderLength(40000)
+	})
+
+	verifier.RegisterMutant("6.10/2", mutant.IgnoreContinuationOrder)
+	verifier.RegisterMutant("6.10/3", mutant.IgnoreStreamZero)
+	verifier.RegisterMutant("6.10/4", mutant.IgnoreContinuationOrder)
+	verifier.RegisterMutant("6.10/5", mutant.IgnoreContinuationOrder)
+	verifier.RegisterMutant("6.10/6", mutant.IgnoreContinuationOrder)
+	verifier.RegisterMutant("6.10/8", mutant.SkipContinuation)
 }
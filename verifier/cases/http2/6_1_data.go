@@ -2,6 +2,7 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 	"golang.org/x/net/http2"
 )
 
@@ -9,6 +10,12 @@ func init() {
 	verifier.Register("6.1/1", test6_1_1)
 	verifier.Register("6.1/2", test6_1_2)
 	verifier.Register("6.1/3", test6_1_3)
+	verifier.Register("6.1/4", test6_1_4)
+	verifier.Register("6.1/5", test6_1_5)
+
+	verifier.RegisterMutant("6.1/1", mutant.IgnoreStreamZero)
+	verifier.RegisterMutant("6.1/2", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("6.1/3", mutant.IgnorePadding)
 }
 
 // Test Case 6.1/1: Sends a DATA frame with 0x0 stream identifier.
@@ -27,4 +34,16 @@ func test6_1_2() error {
 // Expected: Client should detect PROTOCOL_ERROR and close connection.
 func test6_1_3() error {
 	return verifier.ExpectConnectionError("PROTOCOL_ERROR", "padding", "frame")
-}
\ No newline at end of file
+}
+
+// Test Case 6.1/4: Sends the response body in padded DATA frames.
+// Expected: Client should deliver the body without the padding.
+func test6_1_4() error {
+	return verifier.ExpectResponse(200, "padding stripped")
+}
+
+// Test Case 6.1/5: Sends zero-length DATA frames before the last DATA frame of the response.
+// Expected: Client should deliver the complete body.
+func test6_1_5() error {
+	return verifier.ExpectResponse(200, "after empty frames")
+}
@@ -2,6 +2,7 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
@@ -9,6 +10,12 @@ func init() {
 	verifier.Register("6.2/2", test6_2_2)
 	verifier.Register("6.2/3", test6_2_3)
 	verifier.Register("6.2/4", test6_2_4)
+	verifier.Register("6.2/5", test6_2_5)
+
+	verifier.RegisterMutant("6.2/1", mutant.IgnoreContinuationOrder)
+	verifier.RegisterMutant("6.2/2", mutant.IgnoreContinuationOrder)
+	verifier.RegisterMutant("6.2/3", mutant.IgnoreStreamZero)
+	verifier.RegisterMutant("6.2/4", mutant.IgnorePadding)
 }
 
 // Test Case 6.2/1: Sends a HEADERS frame without the END_HEADERS flag, and a PRIORITY frame.
@@ -33,4 +40,10 @@ func test6_2_3() error {
 // Expected: Client should detect PROTOCOL_ERROR and close connection.
 func test6_2_4() error {
 	return verifier.ExpectConnectionError("PROTOCOL_ERROR", "padding", "frame")
-}
\ No newline at end of file
+}
+
+// Test Case 6.2/5: Sends the response HEADERS with the PRIORITY and PADDED flags set.
+// Expected: Client should decode the header block behind the priority fields and padding.
+func test6_2_5() error {
+	return verifier.ExpectResponseHeader("x-header-flags", "priority-and-padding")
+}
@@ -2,12 +2,16 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 	"golang.org/x/net/http2"
 )
 
 func init() {
 	verifier.Register("6.3/1", test6_3_1)
 	verifier.Register("6.3/2", test6_3_2)
+
+	verifier.RegisterMutant("6.3/1", mutant.IgnoreStreamZero)
+	verifier.RegisterMutant("6.3/2", mutant.IgnoreFrameLength)
 }
 
 // Test Case 6.3/1: Sends a PRIORITY frame with 0x0 stream identifier.
@@ -2,12 +2,20 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+	"golang.org/x/net/http2"
 )
 
 func init() {
 	verifier.Register("6.4/1", test6_4_1)
 	verifier.Register("6.4/2", test6_4_2)
 	verifier.Register("6.4/3", test6_4_3)
+	verifier.Register("6.4/4", test6_4_4)
+	verifier.Register("6.4/5", test6_4_5)
+
+	verifier.RegisterMutant("6.4/1", mutant.IgnoreStreamZero)
+	verifier.RegisterMutant("6.4/2", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("6.4/3", mutant.IgnoreFrameLength)
 }
 
 // Test Case 6.4/1: Sends a RST_STREAM frame with 0x0 stream identifier.
@@ -26,4 +34,16 @@ func test6_4_2() error {
 // Expected: Client should detect FRAME_SIZE_ERROR.
 func test6_4_3() error {
 	return verifier.ExpectConnectionError("FRAME_SIZE_ERROR", "length", "frame")
-}
\ No newline at end of file
+}
+
+// Test Case 6.4/4: Resets the stream with INTERNAL_ERROR after part of the response body.
+// Expected: Client should fail the body with a stream error carrying INTERNAL_ERROR.
+func test6_4_4() error {
+	return verifier.ExpectStreamError(http2.ErrCodeInternal)
+}
+
+// Test Case 6.4/5: Sends RST_STREAM with NO_ERROR right after a complete response.
+// Expected: Client should keep the complete response.
+func test6_4_5() error {
+	return verifier.ExpectResponse(200, "complete before reset")
+}
@@ -1,7 +1,10 @@
 package http2
 
 import (
+	"time"
+
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
@@ -29,7 +32,50 @@ func init() {
 	verifier.Register("6.5.2/5", func() error {
 		return verifier.ExpectSuccessfulRequest()
 	})
+	verifier.Register("6.5.2/6", func() error {
+		return verifier.ExpectResponse(200, "")
+	})
+	verifier.Register("6.5.2/7", func() error {
+		return verifier.ExpectConnectionError("header list")
+	})
+	verifier.Register("6.5.2/8", func() error {
+		return verifier.ExpectOversizedRequestRefused(8192)
+	})
 	verifier.Register("6.5.3/2", func() error {
 		return verifier.ExpectSuccessfulRequest()
 	})
+	verifier.Register("6.5/4", func() error {
+		return verifier.ExpectResponse(200, "unknown setting ignored")
+	})
+	verifier.Register("6.5.3/3", func() error {
+		return verifier.ExpectResponse(200, "both settings acknowledged")
+	})
+	verifier.Register("6.5.3/4", func() error {
+		return verifier.ExpectPacedResponses(4, 750*time.Millisecond, "SETTINGS_TIMEOUT")
+	})
+	verifier.Register("6.5.3/5", func() error {
+		return verifier.ExpectPacedResponses(4, 500*time.Millisecond)
+	})
+	verifier.Register("6.5.3/6", func() error {
+		return verifier.ExpectPacedResponses(2, 100*time.Millisecond, "PROTOCOL_ERROR")
+	})
+	verifier.Register("6.5.3/7", func() error {
+		return verifier.ExpectPacedResponses(2, 100*time.Millisecond, "PROTOCOL_ERROR")
+	})
+
+	verifier.RegisterMutant("6.5/1", mutant.IgnoreFrameLength)
+	verifier.RegisterMutant("6.5/2", mutant.IgnoreNonZeroStream)
+	verifier.RegisterMutant("6.5/3", mutant.IgnoreFrameLength)
+	verifier.RegisterMutant("6.5.2/1", mutant.IgnoreSettingsValues)
+	verifier.RegisterMutant("6.5.2/2", mutant.IgnoreSettingsValues, mutant.IgnoreWindowOverflow)
+	verifier.RegisterMutant("6.5.2/3", mutant.IgnoreSettingsValues)
+	verifier.RegisterMutant("6.5.2/4", mutant.IgnoreSettingsValues)
+	verifier.RegisterMutant("6.5.2/5", mutant.StrictUnknownSettings)
+	verifier.RegisterMutant("6.5.2/6", mutant.StrictHeaderListSize)
+	verifier.RegisterMutant("6.5.2/7", mutant.IgnoreHeaderListSize)
+	verifier.RegisterMutant("6.5.2/8", mutant.IgnorePeerMaxHeaderListSize)
+	verifier.RegisterMutant("6.5.3/2", mutant.SkipSettingsAck)
+	verifier.RegisterMutant("6.5/4", mutant.StrictUnknownSettings)
+	verifier.RegisterMutant("6.5.3/3", mutant.SkipSettingsAck)
+	verifier.RegisterMutant("6.5.3/5", mutant.StrictSettingsTimeout)
 }
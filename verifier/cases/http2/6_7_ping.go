@@ -2,6 +2,7 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
@@ -17,4 +18,12 @@ func init() {
 	verifier.Register("6.7/4", func() error {
 		return verifier.ExpectConnectionError("FRAME_SIZE_ERROR")
 	})
+	verifier.Register("6.7/5", func() error {
+		return verifier.ExpectResponse(200, "ping answered")
+	})
+
+	verifier.RegisterMutant("6.7/1", mutant.SkipPingAck)
+	verifier.RegisterMutant("6.7/3", mutant.IgnoreNonZeroStream)
+	verifier.RegisterMutant("6.7/4", mutant.IgnoreFrameLength)
+	verifier.RegisterMutant("6.7/5", mutant.SkipPingAck)
 }
@@ -2,10 +2,27 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("6.8/1", func() error {
 		return verifier.ExpectConnectionError("PROTOCOL_ERROR")
 	})
+	verifier.Register("6.8/2", func() error {
+		return verifier.ExpectResponse(200, "completed after goaway")
+	})
+	verifier.Register("6.8/3", func() error {
+		return verifier.ExpectRetriedResponses(4, 1, "GOAWAY")
+	})
+	verifier.Register("6.8/4", func() error {
+		return verifier.ExpectRetriedResponses(4, 1, "GOAWAY")
+	})
+	verifier.Register("6.8/5", func() error {
+		return verifier.ExpectRetriedResponses(3, 1)
+	})
+
+	verifier.RegisterMutant("6.8/1", mutant.IgnoreNonZeroStream)
+	verifier.RegisterMutant("6.8/4", mutant.IgnoreGoAwayUpdate)
+	verifier.RegisterMutant("6.8/5", mutant.IgnoreGoAwayIncrease)
 }
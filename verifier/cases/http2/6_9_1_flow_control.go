@@ -1,20 +1,46 @@
 package http2
 
 import (
+	"time"
+
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 	"golang.org/x/net/http2"
 )
 
+// windowHold is how long the 6.9.1 receive window verifiers leave the
+// response body unread, so that the client returns no credit while the
+// harness fills its windows.
+const windowHold = time.Second
+
 func init() {
 	verifier.Register("6.9.1/1", test6_9_1_1)
 	verifier.Register("6.9.1/2", test6_9_1_2)
 	verifier.Register("6.9.1/3", test6_9_1_3)
+	verifier.Register("6.9.1/4", test6_9_1_4)
+	verifier.Register("6.9.1/5", test6_9_1_5)
+	verifier.Register("6.9.1/6", test6_9_1_6)
+	verifier.Register("6.9.1/7", test6_9_1_7)
+	verifier.Register("6.9.1/8", test6_9_1_8)
+	verifier.Register("6.9.1/9", test6_9_1_9)
+	verifier.Register("6.9.1/10", test6_9_1_10)
+
+	verifier.RegisterMutant("6.9.1/1", mutant.IgnoreSendWindow)
+
+	verifier.RegisterMutant("6.9.1/2", mutant.IgnoreWindowOverflow)
+	verifier.RegisterMutant("6.9.1/3", mutant.IgnoreWindowOverflow)
+	verifier.RegisterMutant("6.9.1/4", mutant.IgnoreReceiveWindow)
+	verifier.RegisterMutant("6.9.1/5", mutant.IgnoreReceiveWindow)
+	verifier.RegisterMutant("6.9.1/7", mutant.SkipWindowUpdate)
+	verifier.RegisterMutant("6.9.1/8", mutant.SkipPaddingCredit)
+	verifier.RegisterMutant("6.9.1/9", mutant.CreditClosedStream)
+	verifier.RegisterMutant("6.9.1/10", mutant.DropStreamCredit)
 }
 
-// Test Case 6.9.1/1: Sends SETTINGS frame to set the initial window size to 1 and sends HEADERS frame.
-// Expected: Client should respect flow control window and send appropriately sized DATA.
+// Test Case 6.9.1/1: Sets the initial window size to 1 and receives a 256 KiB request body under tiny WINDOW_UPDATE grants.
+// Expected: Client should upload the body intact without exceeding its windows; the harness grades the DATA frames.
 func test6_9_1_1() error {
-	return verifier.ExpectSuccessfulRequest()
+	return verifier.ExpectUpload(256 << 10)
 }
 
 // Test Case 6.9.1/2: Sends multiple WINDOW_UPDATE frames increasing the flow control window to above 2^31-1.
@@ -27,4 +53,46 @@ func test6_9_1_2() error {
 // Expected: Client should detect FLOW_CONTROL_ERROR on the stream.
 func test6_9_1_3() error {
 	return verifier.ExpectStreamError(http2.ErrCodeFlowControl)
-}
\ No newline at end of file
+}
+
+// Test Case 6.9.1/4: Answers the request with DATA one octet beyond the smaller of the client's receive windows.
+// Expected: Client should detect FLOW_CONTROL_ERROR on the connection or the stream.
+func test6_9_1_4() error {
+	return verifier.ExpectHeldResponseError(windowHold, "FLOW_CONTROL_ERROR")
+}
+
+// Test Case 6.9.1/5: Answers the request with DATA whose padding overruns the client's receive window by one octet.
+// Expected: Client should detect FLOW_CONTROL_ERROR, counting the padding toward flow control.
+func test6_9_1_5() error {
+	return verifier.ExpectHeldResponseError(windowHold, "FLOW_CONTROL_ERROR")
+}
+
+// Test Case 6.9.1/6: Answers the request with DATA and padding that fill the client's receive window exactly.
+// Expected: Client should accept the complete response.
+func test6_9_1_6() error {
+	return verifier.ExpectHeldResponse(windowHold, 200)
+}
+
+// Test Case 6.9.1/7: Serves an 8 MiB response body in full-size DATA frames within the client's windows.
+// Expected: Client should replenish its windows and receive the whole body intact.
+func test6_9_1_7() error {
+	return verifier.ExpectResponseDigest()
+}
+
+// Test Case 6.9.1/8: Serves an 8 MiB response body in small DATA frames with padding.
+// Expected: Client should return the credit for the padding and receive the whole body intact.
+func test6_9_1_8() error {
+	return verifier.ExpectResponseDigest()
+}
+
+// Test Case 6.9.1/9: Serves a response body that exhausts the client's window with the DATA frame carrying END_STREAM.
+// Expected: Client should receive the body intact without crediting the closed stream; the harness grades the WINDOW_UPDATE frames.
+func test6_9_1_9() error {
+	return verifier.ExpectResponseDigest()
+}
+
+// Test Case 6.9.1/10: Receives a 128 KiB request body, holding back each WINDOW_UPDATE for half a second.
+// Expected: Client should wait on its exhausted window and resume after every WINDOW_UPDATE.
+func test6_9_1_10() error {
+	return verifier.ExpectUpload(128 << 10)
+}
@@ -2,6 +2,7 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 	"golang.org/x/net/http2"
 )
 
@@ -15,7 +16,27 @@ func init() {
 	verifier.Register("6.9/3", func() error {
 		return verifier.ExpectConnectionError("FRAME_SIZE_ERROR")
 	})
+	verifier.Register("6.9.2/1", func() error {
+		return verifier.ExpectUpload(2 << 20)
+	})
+	verifier.Register("6.9.2/2", func() error {
+		return verifier.ExpectUpload(1 << 20)
+	})
 	verifier.Register("6.9.2/3", func() error {
 		return verifier.ExpectConnectionError("FLOW_CONTROL_ERROR")
 	})
+	verifier.Register("6.9.2/4", func() error {
+		return verifier.ExpectUploads(3, 256<<10)
+	})
+	verifier.Register("6.9/4", func() error {
+		return verifier.ExpectResponse(200, "window update accepted")
+	})
+
+	verifier.RegisterMutant("6.9/1", mutant.IgnoreZeroWindowIncrement)
+	verifier.RegisterMutant("6.9/2", mutant.IgnoreZeroWindowIncrement)
+	verifier.RegisterMutant("6.9/3", mutant.IgnoreFrameLength)
+	verifier.RegisterMutant("6.9.2/1", mutant.IgnoreWindowShrink)
+	verifier.RegisterMutant("6.9.2/2", mutant.ClampNegativeWindow)
+	verifier.RegisterMutant("6.9.2/3", mutant.IgnoreSettingsValues, mutant.IgnoreWindowOverflow)
+	verifier.RegisterMutant("6.9.2/4", mutant.IgnoreWindowShrink)
 }
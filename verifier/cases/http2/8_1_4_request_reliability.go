@@ -0,0 +1,61 @@
+package http2
+
+import (
+	"net/http"
+
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+)
+
+func init() {
+	verifier.Register("8.1.4/1", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "NO_ERROR")
+	})
+	verifier.Register("8.1.4/2", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "PROTOCOL_ERROR")
+	})
+	verifier.Register("8.1.4/3", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "INTERNAL_ERROR")
+	})
+	verifier.Register("8.1.4/4", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "FLOW_CONTROL_ERROR")
+	})
+	verifier.Register("8.1.4/5", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "SETTINGS_TIMEOUT")
+	})
+	verifier.Register("8.1.4/6", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "STREAM_CLOSED")
+	})
+	verifier.Register("8.1.4/7", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "FRAME_SIZE_ERROR")
+	})
+	verifier.Register("8.1.4/8", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "REFUSED_STREAM")
+	})
+	verifier.Register("8.1.4/9", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "CANCEL")
+	})
+	verifier.Register("8.1.4/10", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "COMPRESSION_ERROR")
+	})
+	verifier.Register("8.1.4/11", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "CONNECT_ERROR")
+	})
+	verifier.Register("8.1.4/12", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "ENHANCE_YOUR_CALM")
+	})
+	verifier.Register("8.1.4/13", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "INADEQUATE_SECURITY")
+	})
+	verifier.Register("8.1.4/14", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "HTTP_1_1_REQUIRED")
+	})
+	verifier.Register("8.1.4/15", func() error {
+		return verifier.ExpectResetRequest(http.MethodPost, "REFUSED_STREAM")
+	})
+	verifier.Register("8.1.4/16", func() error {
+		return verifier.ExpectResetRequest(http.MethodPost, "CANCEL")
+	})
+	verifier.Register("8.1.4/17", func() error {
+		return verifier.ExpectResetRequest(http.MethodGet, "REFUSED_STREAM")
+	})
+}
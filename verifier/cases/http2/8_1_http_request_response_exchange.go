@@ -1,7 +1,10 @@
 package http2
 
 import (
+	"net/http"
+
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 	"golang.org/x/net/http2"
 )
 
@@ -9,9 +12,57 @@ func init() {
 	verifier.Register("8.1/1", func() error {
 		return verifier.ExpectStreamError(http2.ErrCodeProtocol)
 	})
+	verifier.Register("8.1/2", func() error {
+		return verifier.ExpectResponse(204, "")
+	})
+	verifier.Register("8.1/3", func() error {
+		return verifier.ExpectResponse(200, "ok")
+	})
+	verifier.Register("8.1/4", func() error {
+		return verifier.ExpectResponse(200, "ok")
+	})
+	verifier.Register("8.1/5", func() error {
+		return verifier.ExpectContinuedUpload(32768)
+	})
+	verifier.Register("8.1/6", func() error {
+		return verifier.ExpectEarlyResponse(262144, 413, "too large")
+	})
+	verifier.Register("8.1/7", func() error {
+		return verifier.ExpectResponseError("PROTOCOL_ERROR")
+	})
+	verifier.Register("8.1/8", func() error {
+		return verifier.ExpectResponseError("PROTOCOL_ERROR")
+	})
+	verifier.Register("8.1/9", func() error {
+		return verifier.ExpectTrailers("ok", http.Header{
+			"X-Result":  {"complete"},
+			"X-Records": {"1"},
+		})
+	})
+	verifier.Register("8.1/10", func() error {
+		return verifier.ExpectTrailer("", "X-Result", "empty")
+	})
+	verifier.Register("8.1/11", func() error {
+		return verifier.ExpectTrailer("ok", "X-Result", "split")
+	})
+	verifier.Register("8.1/12", func() error {
+		return verifier.ExpectTrailer("ok", "X-Result", "first", "second")
+	})
+	verifier.Register("8.1/13", func() error {
+		return verifier.ExpectResponseError("PROTOCOL_ERROR")
+	})
+	verifier.Register("8.1/14", func() error {
+		return verifier.ExpectResponseError("PROTOCOL_ERROR")
+	})
+	verifier.Register("8.1/15", func() error {
+		return verifier.ExpectResponseError("PROTOCOL_ERROR")
+	})
 	verifier.Register("8.1.2/1", func() error {
 		return verifier.ExpectStreamError(http2.ErrCodeProtocol)
 	})
+	verifier.Register("8.1.2/2", func() error {
+		return verifier.ExpectRequestWithHeader("X-Test-Case", "8.1.2/2")
+	})
 	verifier.Register("8.1.2.1/1", func() error {
 		return verifier.ExpectStreamError(http2.ErrCodeProtocol)
 	})
@@ -24,12 +75,21 @@ func init() {
 	verifier.Register("8.1.2.1/4", func() error {
 		return verifier.ExpectStreamError(http2.ErrCodeProtocol)
 	})
+	verifier.Register("8.1.2.1/5", func() error {
+		return verifier.ExpectRequestWithHeader("X-Order", "after-pseudo-headers")
+	})
 	verifier.Register("8.1.2.2/1", func() error {
 		return verifier.ExpectStreamError(http2.ErrCodeProtocol)
 	})
 	verifier.Register("8.1.2.2/2", func() error {
 		return verifier.ExpectStreamError(http2.ErrCodeProtocol)
 	})
+	verifier.Register("8.1.2.2/3", func() error {
+		return verifier.ExpectRequestWithHeader("Connection", "keep-alive", "invalid Connection request header", "invalid header field")
+	})
+	verifier.Register("8.1.2.2/4", func() error {
+		return verifier.ExpectRequestWithHeader("Te", "gzip", "invalid Te header", "invalid header field")
+	})
 	verifier.Register("8.1.2.3/1", func() error {
 		return verifier.ExpectStreamError(http2.ErrCodeProtocol)
 	})
@@ -51,10 +111,63 @@ func init() {
 	verifier.Register("8.1.2.3/7", func() error {
 		return verifier.ExpectStreamError(http2.ErrCodeProtocol)
 	})
+	verifier.Register("8.1.2.3/8", func() error {
+		return verifier.ExpectOrigins("https://127.0.0.1:8080/search?q=h2")
+	})
+	verifier.Register("8.1.2.3/9", func() error {
+		return verifier.ExpectResponses(3, 200, "ok")
+	})
+	verifier.Register("8.1.2.3/10", func() error {
+		return verifier.ExpectVirtualHostResponse("vhost.test:8080")
+	})
 	verifier.Register("8.1.2.6/1", func() error {
 		return verifier.ExpectStreamError(http2.ErrCodeProtocol)
 	})
 	verifier.Register("8.1.2.6/2", func() error {
 		return verifier.ExpectStreamError(http2.ErrCodeProtocol)
 	})
+	// Clients differ in how they surface a truncated body; either a stream
+	// error or an unexpected EOF shows the response was not accepted.
+	verifier.Register("8.1.2.6/3", func() error {
+		return verifier.ExpectResponseError("PROTOCOL_ERROR", "unexpected EOF")
+	})
+
+	verifier.RegisterMutant("8.1/1", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1/3", mutant.FinalInformational)
+	verifier.RegisterMutant("8.1/4", mutant.FinalInformational)
+	verifier.RegisterMutant("8.1/5", mutant.FinalInformational)
+	verifier.RegisterMutant("8.1/6", mutant.StrictEarlyResponse)
+	verifier.RegisterMutant("8.1/7", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1/8", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1/9", mutant.DropTrailers)
+	verifier.RegisterMutant("8.1/10", mutant.DropTrailers)
+	verifier.RegisterMutant("8.1/11", mutant.DropTrailers)
+	verifier.RegisterMutant("8.1/12", mutant.DropTrailers)
+	verifier.RegisterMutant("8.1/13", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1/14", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1/15", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2/1", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2/2", mutant.UppercaseHeaderNames)
+	verifier.RegisterMutant("8.1.2.1/1", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.1/2", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.1/3", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.1/4", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.1/5", mutant.MisorderPseudoHeaders)
+	verifier.RegisterMutant("8.1.2.2/1", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.2/2", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.2/3", mutant.SendConnectionHeaders)
+	verifier.RegisterMutant("8.1.2.2/4", mutant.SendConnectionHeaders)
+	verifier.RegisterMutant("8.1.2.3/1", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.3/2", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.3/3", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.3/4", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.3/5", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.3/6", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.3/7", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("8.1.2.3/8", mutant.OmitPath)
+	verifier.RegisterMutant("8.1.2.3/9", mutant.DuplicatePseudoHeaders)
+	verifier.RegisterMutant("8.1.2.3/10", mutant.HostWithoutAuthority)
+	verifier.RegisterMutant("8.1.2.6/1", mutant.IgnoreContentLength)
+	verifier.RegisterMutant("8.1.2.6/2", mutant.IgnoreContentLength)
+	verifier.RegisterMutant("8.1.2.6/3", mutant.IgnoreContentLength)
 }
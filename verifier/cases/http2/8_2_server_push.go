@@ -1,11 +1,47 @@
 package http2
 
 import (
+	"time"
+
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
 	verifier.Register("8.2/1", func() error {
 		return verifier.ExpectConnectionError("PROTOCOL_ERROR")
 	})
+	verifier.Register("8.2/2", func() error {
+		return verifier.ExpectPush("/pushed", "pushed")
+	})
+	verifier.Register("8.2/3", func() error {
+		return verifier.ExpectPushRefused()
+	})
+	verifier.Register("8.2/4", func() error {
+		return verifier.ExpectPushError("PROTOCOL_ERROR")
+	})
+	verifier.Register("8.2/5", func() error {
+		return verifier.ExpectPushError("PROTOCOL_ERROR")
+	})
+	verifier.Register("8.2/6", func() error {
+		return verifier.ExpectPushHeld(3 * time.Second)
+	})
+	verifier.Register("8.2/7", func() error {
+		return verifier.ExpectPushHeld(500 * time.Millisecond)
+	})
+	verifier.Register("8.2/8", func() error {
+		return verifier.ExpectPushError("PROTOCOL_ERROR")
+	})
+	verifier.Register("8.2/9", func() error {
+		return verifier.ExpectPushError("PROTOCOL_ERROR")
+	})
+
+	verifier.RegisterMutant("8.2/1", mutant.IgnorePushDisabled)
+	verifier.RegisterMutant("8.2/3", mutant.StrictResetStream)
+	verifier.RegisterMutant("8.2/4", mutant.IgnorePromisedStreamID)
+	verifier.RegisterMutant("8.2/5", mutant.IgnorePromisedStreamID)
+	verifier.RegisterMutant("8.2/6", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("8.2/7", mutant.AcceptUnsafePush)
+	verifier.RegisterMutant("8.2/8", mutant.IgnoreStreamState)
+	verifier.RegisterMutant("8.2/9", mutant.IgnoreStreamState)
 }
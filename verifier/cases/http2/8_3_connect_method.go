@@ -0,0 +1,17 @@
+package http2
+
+import (
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+)
+
+func init() {
+	verifier.Register("8.3/1", func() error {
+		return verifier.ExpectTunnel("tunnel.test:443", "", "ping through the tunnel")
+	})
+	verifier.Register("8.3/2", func() error {
+		return verifier.ExpectTunnel("tunnel.test:443", "220 tunnel ready\r\n", "ping through the tunnel")
+	})
+
+	verifier.RegisterMutant("8.3/1", mutant.ConnectWithPath)
+}
@@ -0,0 +1,25 @@
+package http2
+
+import (
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+)
+
+func init() {
+	verifier.Register("9.1.1/1", func() error {
+		return verifier.ExpectOrigins("https://a.coalesce.test:8080", "https://b.coalesce.test:8080")
+	})
+	verifier.Register("9.1.1/2", func() error {
+		return verifier.ExpectOrigins("https://a.coalesce.test:8080", "https://uncovered.test:8080")
+	})
+	verifier.Register("9.1.2/1", func() error {
+		return verifier.ExpectOrigins("https://a.coalesce.test:8080", "https://b.coalesce.test:8080")
+	})
+	verifier.Register("9.1.2/2", func() error {
+		return verifier.ExpectMisdirected("https://a.coalesce.test:8080")
+	})
+
+	verifier.RegisterMutant("9.1.1/2", mutant.CoalesceUncovered)
+	verifier.RegisterMutant("9.1.2/1", mutant.ReuseMisdirectedConnection)
+	verifier.RegisterMutant("9.1.2/2", mutant.ReuseMisdirectedConnection)
+}
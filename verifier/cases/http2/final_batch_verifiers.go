@@ -2,56 +2,33 @@ package http2
 
 import (
 	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 )
 
 func init() {
-	// Additional test verifiers to reach 100%
-	verifier.Register("generic/1/1", testGeneric1_1)
-	verifier.Register("generic/2/1", testGeneric2_1) 
-	verifier.Register("generic/5/1", testGeneric5_1)
 	verifier.Register("http2/5.5/1", testHttp2_5_5_1)
-	verifier.Register("http2/7/1", testHttp2_7_1)
-	verifier.Register("http2/4.3/1", testHttp2_4_3_1)
 	verifier.Register("http2/8.1.2.4/1", testHttp2_8_1_2_4_1)
 	verifier.Register("http2/8.1.2.5/1", testHttp2_8_1_2_5_1)
-}
-
-// Test Case generic/1/1: HTTP/2 Connection Preface
-func testGeneric1_1() error {
-	return verifier.ExpectSuccessfulRequest()
-}
-
-// Test Case generic/2/1: Stream lifecycle test
-func testGeneric2_1() error {
-	return verifier.ExpectSuccessfulRequest()
-}
 
-// Test Case generic/5/1: HPACK processing test
-func testGeneric5_1() error {
-	return verifier.ExpectSuccessfulRequest()
+	verifier.RegisterMutant("http2/5.5/1", mutant.StrictUnknownFrames)
+	verifier.RegisterMutant("http2/8.1.2.4/1", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("http2/8.1.2.5/1", mutant.IgnoreMalformedHeaders)
 }
 
-// Test Case http2/5.5/1: Extension frame test
+// Test Case http2/5.5/1: Sends a frame of an unknown type before the response.
+// Expected: Client should ignore the frame and read the response.
 func testHttp2_5_5_1() error {
-	return verifier.ExpectSuccessfulRequest()
+	return verifier.ExpectResponse(200, "ok")
 }
 
-// Test Case http2/7/1: Error codes test
-func testHttp2_7_1() error {
-	return verifier.ExpectSuccessfulRequest()
-}
-
-// Test Case http2/4.3/1: Header compression test
-func testHttp2_4_3_1() error {
-	return verifier.ExpectSuccessfulRequest()
-}
-
-// Test Case http2/8.1.2.4/1: Response pseudo-header test
+// Test Case http2/8.1.2.4/1: Answers the request with response HEADERS that carry :method besides :status.
+// Expected: Client should fail the request with PROTOCOL_ERROR.
 func testHttp2_8_1_2_4_1() error {
-	return verifier.ExpectConnectionError("PROTOCOL_ERROR", "pseudo", "header")
+	return verifier.ExpectResponseError("PROTOCOL_ERROR")
 }
 
-// Test Case http2/8.1.2.5/1: Connection header test
+// Test Case http2/8.1.2.5/1: Answers the request with response HEADERS that carry "connection: close".
+// Expected: Client should fail the request with PROTOCOL_ERROR.
 func testHttp2_8_1_2_5_1() error {
-	return verifier.ExpectConnectionError("PROTOCOL_ERROR", "connection", "header")
-}
\ No newline at end of file
+	return verifier.ExpectResponseError("PROTOCOL_ERROR")
+}
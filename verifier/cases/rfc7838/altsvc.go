@@ -0,0 +1,58 @@
+package rfc7838
+
+import (
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+)
+
+func init() {
+	verifier.Register("rfc7838/4/1", testRfc7838_4_1)
+	verifier.Register("rfc7838/4/2", testRfc7838_4_2)
+	verifier.Register("rfc7838/4/3", testRfc7838_4_3)
+	verifier.Register("rfc7838/4/4", testRfc7838_4_4)
+	verifier.Register("rfc7838/4/5", testRfc7838_4_5)
+	verifier.Register("rfc7838/4/6", testRfc7838_4_6)
+
+	verifier.RegisterMutant("rfc7838/4/1", mutant.StrictUnknownFrames)
+	verifier.RegisterMutant("rfc7838/4/2", mutant.StrictUnknownFrames)
+	verifier.RegisterMutant("rfc7838/4/3", mutant.StrictUnknownFrames)
+	verifier.RegisterMutant("rfc7838/4/4", mutant.StrictUnknownFrames)
+	verifier.RegisterMutant("rfc7838/4/5", mutant.StrictUnknownFrames)
+	verifier.RegisterMutant("rfc7838/4/6", mutant.StrictUnknownFrames)
+}
+
+// Test Case rfc7838/4/1: Sends an ALTSVC frame on stream 0 for the harness's origin.
+// Expected: Client should ignore or honour the frame and get both responses.
+func testRfc7838_4_1() error {
+	return verifier.ExpectFollowUp("https://127.0.0.1:8080")
+}
+
+// Test Case rfc7838/4/2: Sends an ALTSVC frame with an empty origin on the request's stream.
+// Expected: Client should ignore or honour the frame and get both responses.
+func testRfc7838_4_2() error {
+	return verifier.ExpectFollowUp("https://127.0.0.1:8080")
+}
+
+// Test Case rfc7838/4/3: Sends an ALTSVC frame with an empty origin on stream 0.
+// Expected: Client should ignore the frame and get both responses.
+func testRfc7838_4_3() error {
+	return verifier.ExpectFollowUp("https://127.0.0.1:8080")
+}
+
+// Test Case rfc7838/4/4: Sends an ALTSVC frame naming an origin on the request's stream.
+// Expected: Client should ignore the frame and get both responses.
+func testRfc7838_4_4() error {
+	return verifier.ExpectFollowUp("https://127.0.0.1:8080")
+}
+
+// Test Case rfc7838/4/5: Sends an ALTSVC frame whose Origin-Len runs past its payload.
+// Expected: Client should ignore the frame and get both responses.
+func testRfc7838_4_5() error {
+	return verifier.ExpectFollowUp("https://127.0.0.1:8080")
+}
+
+// Test Case rfc7838/4/6: Sends an ALTSVC frame with a one-octet payload.
+// Expected: Client should ignore the frame and get both responses.
+func testRfc7838_4_6() error {
+	return verifier.ExpectFollowUp("https://127.0.0.1:8080")
+}
@@ -0,0 +1,42 @@
+package rfc8336
+
+import (
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+)
+
+func init() {
+	verifier.Register("rfc8336/2.1/1", testRfc8336_2_1_1)
+	verifier.Register("rfc8336/2.1/2", testRfc8336_2_1_2)
+	verifier.Register("rfc8336/2.2/1", testRfc8336_2_2_1)
+	verifier.Register("rfc8336/2.3/1", testRfc8336_2_3_1)
+
+	verifier.RegisterMutant("rfc8336/2.1/1", mutant.CoalesceUncovered)
+	verifier.RegisterMutant("rfc8336/2.1/2", mutant.CoalesceUncovered)
+	verifier.RegisterMutant("rfc8336/2.2/1", mutant.CoalesceUncovered)
+	verifier.RegisterMutant("rfc8336/2.3/1", mutant.StrictUnknownFrames)
+}
+
+// Test Case rfc8336/2.1/1: Sends an ORIGIN frame whose Origin-Len runs past its payload.
+// Expected: Client should ignore the frame and get both responses, sending the follow-up on a connection of its own.
+func testRfc8336_2_1_1() error {
+	return verifier.ExpectFollowUp("https://origin.test:8080")
+}
+
+// Test Case rfc8336/2.1/2: Sends an ORIGIN frame with a one-octet payload.
+// Expected: Client should ignore the frame and get both responses, sending the follow-up on a connection of its own.
+func testRfc8336_2_1_2() error {
+	return verifier.ExpectFollowUp("https://origin.test:8080")
+}
+
+// Test Case rfc8336/2.2/1: Sends an ORIGIN frame on the request's stream.
+// Expected: Client should ignore the frame and get both responses, sending the follow-up on a connection of its own.
+func testRfc8336_2_2_1() error {
+	return verifier.ExpectFollowUp("https://origin.test:8080")
+}
+
+// Test Case rfc8336/2.3/1: Sends an ORIGIN frame adding origin.test:8080 to the origin set.
+// Expected: Client should get both responses, on one connection or two.
+func testRfc8336_2_3_1() error {
+	return verifier.ExpectFollowUp("https://origin.test:8080")
+}
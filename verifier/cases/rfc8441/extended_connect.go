@@ -0,0 +1,55 @@
+package rfc8441
+
+import (
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+)
+
+func init() {
+	verifier.Register("rfc8441/3/1", testRfc8441_3_1)
+	verifier.Register("rfc8441/3/2", testRfc8441_3_2)
+	verifier.Register("rfc8441/3/3", testRfc8441_3_3)
+	verifier.Register("rfc8441/5/1", testRfc8441_5_1)
+	verifier.Register("rfc8441/5/2", testRfc8441_5_2)
+	verifier.Register("rfc8441/5/3", testRfc8441_5_3)
+
+	verifier.RegisterMutant("rfc8441/3/2", mutant.IgnoreConnectProtocolSetting)
+	verifier.RegisterMutant("rfc8441/3/3", mutant.IgnoreConnectProtocolWithdrawal)
+	verifier.RegisterMutant("rfc8441/5/3", mutant.TruncateOnReset)
+}
+
+// Test Case rfc8441/3/1: Enables extended CONNECT and echoes the client's WebSocket messages.
+// Expected: Client should get every message echoed and complete the close handshake.
+func testRfc8441_3_1() error {
+	return verifier.ExpectWebSocketEcho(5, 200)
+}
+
+// Test Case rfc8441/3/2: Leaves SETTINGS_ENABLE_CONNECT_PROTOCOL out of the server preface.
+// Expected: Client should refuse to send the extended CONNECT.
+func testRfc8441_3_2() error {
+	return verifier.ExpectWebSocketRefused("extended connect not supported", "extended CONNECT not enabled")
+}
+
+// Test Case rfc8441/3/3: Enables extended CONNECT and then withdraws it.
+// Expected: Client should detect PROTOCOL_ERROR, or ignore the withdrawal and get its messages echoed.
+func testRfc8441_3_3() error {
+	return verifier.ExpectWebSocketEchoOrError("PROTOCOL_ERROR")
+}
+
+// Test Case rfc8441/5/1: Refuses the WebSocket with a 404 response.
+// Expected: Client should report status 404 and no open WebSocket.
+func testRfc8441_5_1() error {
+	return verifier.ExpectWebSocketStatus(404)
+}
+
+// Test Case rfc8441/5/2: Ends the stream halfway through the echo of the first message.
+// Expected: Client should report the WebSocket as cut short.
+func testRfc8441_5_2() error {
+	return verifier.ExpectWebSocketError(0, "unexpected EOF")
+}
+
+// Test Case rfc8441/5/3: Resets the stream with CANCEL after echoing the first message.
+// Expected: Client should deliver the echo and then report the reset.
+func testRfc8441_5_3() error {
+	return verifier.ExpectWebSocketError(1, "CANCEL")
+}
@@ -0,0 +1,89 @@
+package rfc9113
+
+import (
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+)
+
+func init() {
+	verifier.Register("rfc9113/5.3.2/1", testRfc9113_5_3_2_1)
+	verifier.Register("rfc9113/5.3.2/2", testRfc9113_5_3_2_2)
+	verifier.Register("rfc9113/6.5.2/1", testRfc9113_6_5_2_1)
+	verifier.Register("rfc9113/6.5.2/2", testRfc9113_6_5_2_2)
+	verifier.Register("rfc9113/8.2.1/1", testRfc9113_8_2_1_1)
+	verifier.Register("rfc9113/8.2.1/2", testRfc9113_8_2_1_2)
+	verifier.Register("rfc9113/8.2.1/3", testRfc9113_8_2_1_3)
+	verifier.Register("rfc9113/8.2.1/4", testRfc9113_8_2_1_4)
+	verifier.Register("rfc9113/8.2.1/5", testRfc9113_8_2_1_5)
+	verifier.Register("rfc9113/8.3.1/1", testRfc9113_8_3_1_1)
+
+	verifier.RegisterMutant("rfc9113/5.3.2/1", mutant.IgnoreNoRFC7540Priorities)
+	verifier.RegisterMutant("rfc9113/5.3.2/2", mutant.IgnoreSettingsValues)
+	verifier.RegisterMutant("rfc9113/6.5.2/1", mutant.IgnoreSettingsValues)
+	verifier.RegisterMutant("rfc9113/8.2.1/1", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("rfc9113/8.2.1/2", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("rfc9113/8.2.1/3", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("rfc9113/8.2.1/4", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("rfc9113/8.2.1/5", mutant.IgnoreMalformedHeaders)
+	verifier.RegisterMutant("rfc9113/8.3.1/1", mutant.MismatchedHost)
+}
+
+// Test Case rfc9113/5.3.2/1: Sends SETTINGS_NO_RFC7540_PRIORITIES with a value of 1.
+// Expected: Client should accept the setting and get a 200 response with body "ok".
+func testRfc9113_5_3_2_1() error {
+	return verifier.ExpectResponse(200, "ok")
+}
+
+// Test Case rfc9113/5.3.2/2: Sends SETTINGS_NO_RFC7540_PRIORITIES with a value of 2.
+// Expected: Client should detect PROTOCOL_ERROR.
+func testRfc9113_5_3_2_2() error {
+	return verifier.ExpectConnectionError("PROTOCOL_ERROR")
+}
+
+// Test Case rfc9113/6.5.2/1: Sends SETTINGS_ENABLE_PUSH with a value of 1 from the server.
+// Expected: Client should detect PROTOCOL_ERROR.
+func testRfc9113_6_5_2_1() error {
+	return verifier.ExpectConnectionError("PROTOCOL_ERROR")
+}
+
+// Test Case rfc9113/6.5.2/2: Sends SETTINGS_ENABLE_PUSH with a value of 0 from the server.
+// Expected: Client should accept the setting and get a 200 response with body "ok".
+func testRfc9113_6_5_2_2() error {
+	return verifier.ExpectResponse(200, "ok")
+}
+
+// Test Case rfc9113/8.2.1/1: Sends a response field value with leading whitespace.
+// Expected: Client should treat the response as malformed (PROTOCOL_ERROR).
+func testRfc9113_8_2_1_1() error {
+	return verifier.ExpectResponseError("PROTOCOL_ERROR")
+}
+
+// Test Case rfc9113/8.2.1/2: Sends a response field value with trailing whitespace.
+// Expected: Client should treat the response as malformed (PROTOCOL_ERROR).
+func testRfc9113_8_2_1_2() error {
+	return verifier.ExpectResponseError("PROTOCOL_ERROR")
+}
+
+// Test Case rfc9113/8.2.1/3: Sends a response field value containing NUL.
+// Expected: Client should treat the response as malformed (PROTOCOL_ERROR).
+func testRfc9113_8_2_1_3() error {
+	return verifier.ExpectResponseError("PROTOCOL_ERROR")
+}
+
+// Test Case rfc9113/8.2.1/4: Sends a response field value containing CR.
+// Expected: Client should treat the response as malformed (PROTOCOL_ERROR).
+func testRfc9113_8_2_1_4() error {
+	return verifier.ExpectResponseError("PROTOCOL_ERROR")
+}
+
+// Test Case rfc9113/8.2.1/5: Sends a response field value containing LF.
+// Expected: Client should treat the response as malformed (PROTOCOL_ERROR).
+func testRfc9113_8_2_1_5() error {
+	return verifier.ExpectResponseError("PROTOCOL_ERROR")
+}
+
+// Test Case rfc9113/8.3.1/1: Serves a request for a virtual host other than the harness's address.
+// Expected: Client should carry the host in :authority, with no differing Host header field.
+func testRfc9113_8_3_1_1() error {
+	return verifier.ExpectVirtualHostResponse("h2harness.test:8080")
+}
@@ -0,0 +1,70 @@
+package rfc9218
+
+import (
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier"
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+)
+
+func init() {
+	verifier.Register("rfc9218/4/1", testRfc9218_4_1)
+	verifier.Register("rfc9218/5/1", testRfc9218_5_1)
+	verifier.Register("rfc9218/5/2", testRfc9218_5_2)
+	verifier.Register("rfc9218/7.1/1", testRfc9218_7_1_1)
+	verifier.Register("rfc9218/7.1/2", testRfc9218_7_1_2)
+	verifier.Register("rfc9218/7.1/3", testRfc9218_7_1_3)
+	verifier.Register("rfc9218/7.1/4", testRfc9218_7_1_4)
+	verifier.Register("rfc9218/7.1/5", testRfc9218_7_1_5)
+
+	verifier.RegisterMutant("rfc9218/4/1", mutant.MalformedPriority)
+	verifier.RegisterMutant("rfc9218/5/2", mutant.StrictPriorityHeader)
+	verifier.RegisterMutant("rfc9218/7.1/5", mutant.MisdirectPriorityUpdate)
+}
+
+// Test Case rfc9218/4/1: Serves a request carrying a priority header field of "u=2, i".
+// Expected: Client should send a valid Priority Field Value and get a 200 response.
+func testRfc9218_4_1() error {
+	return verifier.ExpectRequestWithHeader("Priority", "u=2, i")
+}
+
+// Test Case rfc9218/5/1: Sends a response with a priority header field of "u=1, i".
+// Expected: Client should get a 200 response with body "ok".
+func testRfc9218_5_1() error {
+	return verifier.ExpectResponse(200, "ok")
+}
+
+// Test Case rfc9218/5/2: Sends a response with a malformed priority header field.
+// Expected: Client should ignore the field and get a 200 response with body "ok".
+func testRfc9218_5_2() error {
+	return verifier.ExpectResponse(200, "ok")
+}
+
+// Test Case rfc9218/7.1/1: Sends a PRIORITY_UPDATE frame on stream 0 for the request.
+// Expected: Client should ignore the frame or detect PROTOCOL_ERROR.
+func testRfc9218_7_1_1() error {
+	return verifier.ExpectResponseOrError(200, "ok", "PROTOCOL_ERROR")
+}
+
+// Test Case rfc9218/7.1/2: Sends a PRIORITY_UPDATE frame on the request's stream.
+// Expected: Client should ignore the frame or detect PROTOCOL_ERROR.
+func testRfc9218_7_1_2() error {
+	return verifier.ExpectResponseOrError(200, "ok", "PROTOCOL_ERROR")
+}
+
+// Test Case rfc9218/7.1/3: Sends a PRIORITY_UPDATE frame with a malformed Priority Field Value.
+// Expected: Client should ignore the frame or detect PROTOCOL_ERROR.
+func testRfc9218_7_1_3() error {
+	return verifier.ExpectResponseOrError(200, "ok", "PROTOCOL_ERROR")
+}
+
+// Test Case rfc9218/7.1/4: Sends a PRIORITY_UPDATE frame for a stream the client never opened.
+// Expected: Client should ignore the frame or detect PROTOCOL_ERROR.
+func testRfc9218_7_1_4() error {
+	return verifier.ExpectResponseOrError(200, "ok", "PROTOCOL_ERROR")
+}
+
+// Test Case rfc9218/7.1/5: Gives the client time to reprioritize its request with PRIORITY_UPDATE.
+// Expected: Client should send any PRIORITY_UPDATE on stream 0 with a valid value and get a 200 response.
+// Only the mutant client sends PRIORITY_UPDATE; the reference client sends none and passes without being graded.
+func testRfc9218_7_1_5() error {
+	return verifier.ExpectPriorityUpdate("u=5")
+}
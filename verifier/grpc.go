@@ -0,0 +1,289 @@
+package verifier
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+	"golang.org/x/net/http2"
+)
+
+// grpcURL is the method every gRPC call of the verifiers invokes.
+const grpcURL = "https://127.0.0.1:8080/h2harness.Echo/Call"
+
+// grpcPayload returns the payload of request message i of a call, of size
+// octets.
+func grpcPayload(i, size int) []byte {
+	payload := make([]byte, size)
+	rand.New(rand.NewSource(int64(i + 1))).Read(payload)
+	return payload
+}
+
+// grpcMessage returns payload as an uncompressed, length-prefixed gRPC
+// message.
+func grpcMessage(payload []byte) []byte {
+	msg := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(payload)))
+	return append(msg, payload...)
+}
+
+// readGRPCMessage reads the next length-prefixed message from r. It returns
+// io.EOF if r ends between messages and io.ErrUnexpectedEOF if it ends
+// inside one.
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	if prefix[0] != 0 {
+		return nil, fmt.Errorf("message flagged as compressed")
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return payload, nil
+}
+
+// grpcCall invokes grpcURL through client with body as its request
+// messages and checks that the response is a gRPC response.
+func grpcCall(client *http.Client, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, grpcURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("expected the call to succeed, but got an error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/grpc") {
+		resp.Body.Close()
+		return nil, fmt.Errorf("expected status 200 with content-type application/grpc, but got %s with %q", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	return resp, nil
+}
+
+// grpcCallStatus returns the status code and decoded message of a call
+// whose response body has been read to EOF: from the trailers, or from the
+// headers of a trailers-only response.
+func grpcCallStatus(resp *http.Response) (code, message string) {
+	fields := resp.Trailer
+	if len(fields.Values("Grpc-Status")) == 0 {
+		fields = resp.Header
+	}
+	message, err := url.PathUnescape(fields.Get("Grpc-Message"))
+	if err != nil {
+		message = fields.Get("Grpc-Message")
+	}
+	return fields.Get("Grpc-Status"), message
+}
+
+// ExpectGRPCEcho invokes a call with request messages of the given sizes
+// and expects them echoed back intact, and grpc-status 0.
+func ExpectGRPCEcho(sizes ...int) error {
+	var body bytes.Buffer
+	for i, size := range sizes {
+		body.Write(grpcMessage(grpcPayload(i, size)))
+	}
+	resp, err := grpcCall(newClient(), &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	for i, size := range sizes {
+		msg, err := readGRPCMessage(resp.Body)
+		if err != nil {
+			return fmt.Errorf("expected echoed message %d of %d octets, but got an error: %v", i+1, size, err)
+		}
+		if !bytes.Equal(msg, grpcPayload(i, size)) {
+			return fmt.Errorf("echoed message %d of %d octets differs from the one sent (%d octets)", i+1, size, len(msg))
+		}
+	}
+	if msg, err := readGRPCMessage(resp.Body); err != io.EOF {
+		return fmt.Errorf("expected the response to end after %d message(s), but got %d more octets and %v", len(sizes), len(msg), err)
+	}
+	if code, message := grpcCallStatus(resp); code != "0" {
+		return fmt.Errorf("expected grpc-status 0, but got %q with message %q", code, message)
+	}
+
+	log.Printf("Got %d message(s) echoed intact with grpc-status 0", len(sizes))
+	return nil
+}
+
+// ExpectGRPCStatus invokes a call with one request message, reads every
+// response message and expects the call to end with the given grpc-status
+// and grpc-message.
+func ExpectGRPCStatus(expectedCode, expectedMessage string) error {
+	resp, err := grpcCall(newClient(), bytes.NewReader(grpcMessage(grpcPayload(0, 16))))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	n := 0
+	for {
+		_, err := readGRPCMessage(resp.Body)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("expected complete response messages, but got an error after %d: %v", n, err)
+		}
+		n++
+	}
+	code, message := grpcCallStatus(resp)
+	if code != expectedCode || message != expectedMessage {
+		return fmt.Errorf("expected grpc-status %s with message %q, but got %q with message %q", expectedCode, expectedMessage, code, message)
+	}
+
+	log.Printf("Got expected grpc-status %s with message %q after %d message(s)", code, message, n)
+	return nil
+}
+
+// ExpectGRPCStream invokes a bidirectional call and sends n request
+// messages of size octets one at a time, each only once the previous one
+// has been echoed back, then expects grpc-status 0.
+func ExpectGRPCStream(n, size int) error {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	sent := make(chan error, 1)
+	go func() {
+		_, err := pw.Write(grpcMessage(grpcPayload(0, size)))
+		sent <- err
+	}()
+	resp, err := grpcCall(newClient(), pr)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	for i := 0; i < n; i++ {
+		if err := <-sent; err != nil {
+			return fmt.Errorf("failed to send message %d: %v", i+1, err)
+		}
+		msg, err := readGRPCMessage(resp.Body)
+		if err != nil {
+			return fmt.Errorf("expected echoed message %d of %d, but got an error: %v", i+1, n, err)
+		}
+		if !bytes.Equal(msg, grpcPayload(i, size)) {
+			return fmt.Errorf("echoed message %d of %d differs from the one sent", i+1, n)
+		}
+		if i+1 < n {
+			go func() {
+				_, err := pw.Write(grpcMessage(grpcPayload(i+1, size)))
+				sent <- err
+			}()
+		}
+	}
+	pw.Close()
+
+	if _, err := readGRPCMessage(resp.Body); err != io.EOF {
+		return fmt.Errorf("expected the call to end after %d echoes, but got %v", n, err)
+	}
+	if code, message := grpcCallStatus(resp); code != "0" {
+		return fmt.Errorf("expected grpc-status 0, but got %q with message %q", code, message)
+	}
+
+	log.Printf("Got all %d messages of %d octets echoed with grpc-status 0", n, size)
+	return nil
+}
+
+// ExpectGRPCReset invokes a call, expects n response messages and then
+// expects reading the rest of the response to fail with an error
+// containing one of the expected substrings.
+func ExpectGRPCReset(n int, expectedErrors ...string) error {
+	resp, err := grpcCall(newClient(), bytes.NewReader(grpcMessage(grpcPayload(0, 16))))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	for i := 0; i < n; i++ {
+		if _, err := readGRPCMessage(resp.Body); err != nil {
+			return fmt.Errorf("expected response message %d of %d, but got an error: %v", i+1, n, err)
+		}
+	}
+	_, err = readGRPCMessage(resp.Body)
+	if err == nil {
+		return fmt.Errorf("expected the call to fail after %d message(s), but got another", n)
+	}
+	if err == io.EOF {
+		code, message := grpcCallStatus(resp)
+		return fmt.Errorf("expected the call to fail after %d message(s), but it ended with grpc-status %q and message %q", n, code, message)
+	}
+
+	for _, expected := range expectedErrors {
+		if strings.Contains(err.Error(), expected) {
+			log.Printf("Got expected error after %d message(s): %v", n, err)
+			return nil // Test passed
+		}
+	}
+
+	return fmt.Errorf("got an unexpected error: %v, expected one of: %v", err, expectedErrors)
+}
+
+// keepAliveClient returns a client that sends keepalive PINGs: every
+// interval over the mutant client, and after interval without a frame from
+// the server over the reference client, whose health checks are the
+// closest it comes to a keepalive.
+func keepAliveClient(interval time.Duration) *http.Client {
+	if t, ok := transport.(*mutant.Transport); ok {
+		return &http.Client{Transport: &mutant.Transport{
+			Faults:          t.Faults,
+			TLSClientConfig: t.TLSClientConfig,
+			Timeout:         t.Timeout,
+			KeepAlive:       interval,
+		}}
+	}
+	return &http.Client{
+		Transport: &http2.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true, // We expect a self-signed cert
+			},
+			ReadIdleTimeout: interval,
+			PingTimeout:     time.Second,
+		},
+	}
+}
+
+// ExpectGRPCKeepAlive invokes a call through a client that sends keepalive
+// PINGs every interval while the harness holds the call open, and expects
+// reading the response to fail with an error containing one of the
+// expected substrings.
+func ExpectGRPCKeepAlive(interval time.Duration, expectedErrors ...string) error {
+	resp, err := grpcCall(keepAliveClient(interval), bytes.NewReader(grpcMessage(grpcPayload(0, 16))))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.ReadAll(resp.Body)
+	if err == nil {
+		code, message := grpcCallStatus(resp)
+		return fmt.Errorf("expected the call to fail, but it ended with grpc-status %q and message %q", code, message)
+	}
+
+	for _, expected := range expectedErrors {
+		if strings.Contains(err.Error(), expected) {
+			log.Printf("Got expected error: %v", err)
+			return nil // Test passed
+		}
+	}
+
+	return fmt.Errorf("got an unexpected error: %v, expected one of: %v", err, expectedErrors)
+}
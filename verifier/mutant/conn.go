@@ -0,0 +1,1776 @@
+package mutant
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nomadlabsinc/h2-client-test-harness/priority"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	frameHeaderLen      = 9
+	defaultWindowSize   = 65535
+	maxWindowSize       = 1<<31 - 1
+	defaultMaxFrameSize = 16384
+	maxMaxFrameSize     = 1<<24 - 1
+	headerTableSize     = 4096
+	maxHeaderListSize   = 1 << 16
+	bodyChunkSize       = 1 << 16
+	// How long the StrictSettingsTimeout fault waits for a SETTINGS ACK.
+	strictSettingsTimeout = 500 * time.Millisecond
+	// How long a request that expects 100 Continue holds its body back.
+	expectContinueTimeout = time.Second
+)
+
+// settingNoRFC7540Priorities is SETTINGS_NO_RFC7540_PRIORITIES (RFC 9113
+// Section 5.3.2), which the http2 package does not define.
+const settingNoRFC7540Priorities http2.SettingID = 0x9
+
+// Flags shared by several frame types.
+const (
+	flagEndStream  = 0x1
+	flagAck        = 0x1
+	flagEndHeaders = 0x4
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+)
+
+// definedFlags lists the flags each known frame type may carry.
+var definedFlags = map[http2.FrameType]uint8{
+	http2.FrameData:         flagEndStream | flagPadded,
+	http2.FrameHeaders:      flagEndStream | flagEndHeaders | flagPadded | flagPriority,
+	http2.FramePriority:     0,
+	http2.FrameRSTStream:    0,
+	http2.FrameSettings:     flagAck,
+	http2.FramePushPromise:  flagEndHeaders | flagPadded,
+	http2.FramePing:         flagAck,
+	http2.FrameGoAway:       0,
+	http2.FrameWindowUpdate: 0,
+	http2.FrameContinuation: flagEndHeaders,
+}
+
+var errUnexpectedEOF = errors.New("mutant: unexpected EOF")
+
+// connError is a connection error detected by the client. Its text follows
+// the format used by the golang.org/x/net/http2 transport so that verifier
+// expectations match both clients alike.
+type connError struct {
+	Code   http2.ErrCode
+	Reason string
+}
+
+func (e connError) Error() string {
+	return fmt.Sprintf("connection error: %v: %s", e.Code, e.Reason)
+}
+
+func streamError(id uint32, code http2.ErrCode, format string, args ...interface{}) http2.StreamError {
+	return http2.StreamError{StreamID: id, Code: code, Cause: fmt.Errorf(format, args...)}
+}
+
+type streamState int
+
+const (
+	stateOpen streamState = iota
+	stateHalfClosedLocal
+	stateHalfClosedRemote
+	stateClosed
+	stateReservedRemote
+)
+
+type result struct {
+	res *http.Response
+	err error
+}
+
+type clientStream struct {
+	id            uint32
+	state         streamState
+	resetLocally  bool
+	remoteEnded   bool
+	delivered     bool
+	respc         chan result
+	continued     chan struct{}
+	req           *http.Request
+	res           *http.Response
+	body          *bodyBuffer
+	sendWindow    int64
+	recvWindow    int64
+	contentLength int64
+	received      int64
+}
+
+type frameHeader struct {
+	length   uint32
+	typ      http2.FrameType
+	flags    uint8
+	streamID uint32
+	reserved bool
+}
+
+type headerBlock struct {
+	streamID  uint32
+	promised  uint32
+	endStream bool
+	buf       []byte
+}
+
+type clientConn struct {
+	faults  FaultSet
+	conn    net.Conn
+	br      *bufio.Reader
+	timeout time.Duration
+	// The authority the connection was dialed for, and the authorities it
+	// answered with 421 Misdirected Request, guarded by the Transport's mu.
+	authority   string
+	misdirected map[string]bool
+
+	wmu  sync.Mutex
+	fr   *http2.Framer
+	hbuf bytes.Buffer
+	enc  *hpack.Encoder
+	// A table size the MisplaceTableSizeUpdate fault has yet to signal.
+	pendingTableSize *uint32
+
+	mu                sync.Mutex
+	cond              *sync.Cond
+	err               error
+	goAway            error
+	goAwayLast        uint32
+	streams           map[uint32]*clientStream
+	nextStreamID      uint32
+	maxPromisedID     uint32
+	sendWindow        int64
+	recvWindow        int64
+	peerInitialWindow int64
+	peerMaxFrameSize  uint32
+	peerMaxStreams    uint32
+	// The server's SETTINGS_MAX_HEADER_LIST_SIZE, which bounds requests.
+	peerMaxHeaderListSize uint32
+	// Whether the server has acknowledged the client's SETTINGS.
+	settingsAcked bool
+	// Whether the server's first SETTINGS has arrived, and whether it has
+	// enabled extended CONNECT (RFC 8441 Section 3).
+	peerSettings    bool
+	connectProtocol bool
+	// Whether the client has acknowledged SETTINGS_NO_RFC7540_PRIORITIES
+	// of 1 (RFC 9113 Section 5.3.2).
+	noRFC7540Priorities bool
+	// Server push, as configured on the Transport.
+	enablePush bool
+	refusePush bool
+	pushes     chan<- *http.Response
+	// The interval of keepalive PINGs, as configured on the Transport.
+	keepAlive time.Duration
+	// The Priority Field Value of the PRIORITY_UPDATE sent for every
+	// request, as configured on the Transport.
+	priorityUpdate string
+
+	// Owned by the read loop.
+	sawSettings bool
+	dec         *hpack.Decoder
+	block       *headerBlock
+}
+
+func newClientConn(conn net.Conn, faults FaultSet, timeout time.Duration) *clientConn {
+	cc := &clientConn{
+		faults:                faults,
+		conn:                  conn,
+		br:                    bufio.NewReader(conn),
+		timeout:               timeout,
+		fr:                    http2.NewFramer(conn, nil),
+		streams:               make(map[uint32]*clientStream),
+		nextStreamID:          1,
+		sendWindow:            defaultWindowSize,
+		recvWindow:            defaultWindowSize,
+		peerInitialWindow:     defaultWindowSize,
+		peerMaxFrameSize:      defaultMaxFrameSize,
+		peerMaxStreams:        math.MaxUint32,
+		peerMaxHeaderListSize: math.MaxUint32,
+		dec:                   hpack.NewDecoder(headerTableSize, nil),
+	}
+	cc.cond = sync.NewCond(&cc.mu)
+	cc.enc = hpack.NewEncoder(&cc.hbuf)
+	return cc
+}
+
+// start writes the client connection preface and starts the read loop.
+func (cc *clientConn) start() error {
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+	if _, err := io.WriteString(cc.conn, http2.ClientPreface); err != nil {
+		return err
+	}
+	var enablePush uint32
+	if cc.enablePush {
+		enablePush = 1
+	}
+	if err := cc.fr.WriteSettings(
+		http2.Setting{ID: http2.SettingEnablePush, Val: enablePush},
+		http2.Setting{ID: http2.SettingInitialWindowSize, Val: defaultWindowSize},
+		http2.Setting{ID: http2.SettingMaxFrameSize, Val: defaultMaxFrameSize},
+		http2.Setting{ID: http2.SettingMaxHeaderListSize, Val: maxHeaderListSize},
+	); err != nil {
+		return err
+	}
+	if cc.faults.Has(OverCreditWindow) {
+		if err := cc.fr.WriteWindowUpdate(0, maxWindowSize); err != nil {
+			return err
+		}
+	}
+	if cc.faults.Has(StrictSettingsTimeout) {
+		time.AfterFunc(strictSettingsTimeout, func() {
+			cc.mu.Lock()
+			defer cc.mu.Unlock()
+			if !cc.settingsAcked {
+				log.Printf("mutant: %s: no SETTINGS ACK within %v", StrictSettingsTimeout, strictSettingsTimeout)
+				cc.fail(connError{http2.ErrCodeSettingsTimeout, "no SETTINGS ACK"})
+			}
+		})
+	}
+	if cc.keepAlive > 0 {
+		go cc.keepAliveLoop()
+	}
+	go cc.readLoop()
+	return nil
+}
+
+// keepAliveLoop sends a PING every keepAlive until the connection fails.
+func (cc *clientConn) keepAliveLoop() {
+	ticker := time.NewTicker(cc.keepAlive)
+	defer ticker.Stop()
+	for range ticker.C {
+		cc.mu.Lock()
+		err := cc.err
+		cc.mu.Unlock()
+		if err != nil {
+			return
+		}
+		cc.wmu.Lock()
+		err = cc.fr.WritePing(false, [8]byte{'k', 'e', 'e', 'p', 'a', 'l', 'i', 'v'})
+		cc.wmu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// violation returns err unless fault is enabled, in which case the check is
+// skipped and only logged.
+func (cc *clientConn) violation(fault Fault, err error) error {
+	if cc.faults.Has(fault) {
+		log.Printf("mutant: %s: ignoring %v", fault, err)
+		return nil
+	}
+	return err
+}
+
+// usable reports whether new requests may be sent on the connection.
+func (cc *clientConn) usable() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.err == nil && cc.goAway == nil
+}
+
+// authoritative reports whether the connection may carry requests for
+// host, which resolves to addr: whether the server's certificate covers host
+// and the connection goes to addr (RFC 7540 Section 9.1.1).
+func (cc *clientConn) authoritative(host, addr string) bool {
+	conn, ok := cc.conn.(*tls.Conn)
+	if !ok || cc.conn.RemoteAddr().String() != addr {
+		return false
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	return len(certs) > 0 && certs[0].VerifyHostname(host) == nil
+}
+
+// activeStreams returns the number of streams that count toward the
+// server's SETTINGS_MAX_CONCURRENT_STREAMS (RFC 7540 Section 5.1.2).
+func (cc *clientConn) activeStreams() uint32 {
+	var n uint32
+	for _, cs := range cc.streams {
+		if cs.state != stateClosed && cs.id%2 == 1 {
+			n++
+		}
+	}
+	return n
+}
+
+func (cc *clientConn) roundTrip(req *http.Request) (*http.Response, error) {
+	fields := cc.requestFields(req)
+	var size uint64
+	for _, f := range fields {
+		size += uint64(f.Size())
+	}
+
+	cc.mu.Lock()
+	if cc.faults.Has(IgnoreMaxConcurrentStreams) {
+		if n := cc.activeStreams(); n >= cc.peerMaxStreams {
+			log.Printf("mutant: %s: opening a stream with %d of %d open", IgnoreMaxConcurrentStreams, n, cc.peerMaxStreams)
+		}
+	} else {
+		for cc.err == nil && cc.goAway == nil && cc.activeStreams() >= cc.peerMaxStreams {
+			cc.cond.Wait()
+		}
+	}
+	if req.Method == http.MethodConnect && req.Header.Get(":protocol") != "" {
+		// An extended CONNECT waits for the SETTINGS that may enable it.
+		for cc.err == nil && !cc.peerSettings {
+			cc.cond.Wait()
+		}
+		if cc.err == nil && !cc.connectProtocol {
+			if err := cc.violation(IgnoreConnectProtocolSetting, fmt.Errorf("mutant: extended CONNECT not enabled by the server")); err != nil {
+				cc.mu.Unlock()
+				return nil, err
+			}
+		}
+	}
+	if cc.err != nil {
+		err := cc.err
+		cc.mu.Unlock()
+		return nil, err
+	}
+	if cc.goAway != nil {
+		err := unprocessedError{cc.goAway}
+		cc.mu.Unlock()
+		return nil, err
+	}
+	if size > uint64(cc.peerMaxHeaderListSize) {
+		if err := cc.violation(IgnorePeerMaxHeaderListSize, fmt.Errorf("mutant: request header list of %d octets exceeds the server's SETTINGS_MAX_HEADER_LIST_SIZE of %d", size, cc.peerMaxHeaderListSize)); err != nil {
+			cc.mu.Unlock()
+			return nil, err
+		}
+	}
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	cs := &clientStream{
+		id:            cc.nextStreamID,
+		state:         stateHalfClosedLocal,
+		respc:         make(chan result, 1),
+		req:           req,
+		sendWindow:    cc.peerInitialWindow,
+		recvWindow:    defaultWindowSize,
+		contentLength: -1,
+	}
+	if hasBody {
+		cs.state = stateOpen
+		if strings.EqualFold(req.Header.Get("Expect"), "100-continue") {
+			cs.continued = make(chan struct{})
+		}
+	}
+	if !cc.faults.Has(ReuseStreamID) {
+		cc.nextStreamID += 2
+	}
+	cc.streams[cs.id] = cs
+	cc.mu.Unlock()
+
+	if err := cc.writeHeaders(cs.id, !hasBody, cc.encodeRequest(fields)); err != nil {
+		// The server may already have sent the frame that made it close
+		// the connection; the read loop reports that in preference to the
+		// write error.
+		log.Printf("mutant: writing request: %v", err)
+	} else {
+		cc.mu.Lock()
+		reprioritize := cc.noRFC7540Priorities && cc.faults.Has(IgnoreNoRFC7540Priorities)
+		cc.mu.Unlock()
+		if reprioritize {
+			if err := cc.writePriority(cs.id); err != nil {
+				log.Printf("mutant: writing PRIORITY: %v", err)
+			}
+		}
+		if cc.priorityUpdate != "" {
+			if err := cc.writePriorityUpdate(cs.id, cc.priorityUpdate); err != nil {
+				log.Printf("mutant: writing PRIORITY_UPDATE: %v", err)
+			}
+		}
+		if hasBody {
+			go cc.writeBody(cs, req.Body)
+		}
+	}
+
+	r := <-cs.respc
+	return r.res, r.err
+}
+
+// requestFields returns the header list of req, in the order the client
+// sends it.
+func (cc *clientConn) requestFields(req *http.Request) []hpack.HeaderField {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	fields := []hpack.HeaderField{{Name: ":method", Value: method}}
+	if cc.faults.Has(DuplicatePseudoHeaders) {
+		fields = append(fields, hpack.HeaderField{Name: ":method", Value: method})
+	}
+	// A CONNECT request names only its target (RFC 7540 Section 8.3),
+	// unless it is an extended CONNECT (RFC 8441 Section 4).
+	protocol := req.Header.Get(":protocol")
+	tunnel := method == http.MethodConnect && protocol == ""
+	if tunnel && cc.faults.Has(ConnectWithPath) {
+		log.Printf("mutant: %s: sending :scheme and :path in a CONNECT request", ConnectWithPath)
+		tunnel = false
+	}
+	if !tunnel {
+		fields = append(fields, hpack.HeaderField{Name: ":scheme", Value: "https"})
+	}
+	switch {
+	case cc.faults.Has(HostWithoutAuthority):
+		fields = append(fields, hpack.HeaderField{Name: "host", Value: host})
+	case cc.faults.Has(MismatchedHost) && host != req.URL.Host:
+		fields = append(fields, hpack.HeaderField{Name: ":authority", Value: req.URL.Host})
+	default:
+		fields = append(fields, hpack.HeaderField{Name: ":authority", Value: host})
+	}
+	path := hpack.HeaderField{Name: ":path", Value: req.URL.RequestURI()}
+	misorder := cc.faults.Has(MisorderPseudoHeaders)
+	if !misorder && !cc.faults.Has(OmitPath) && !tunnel {
+		fields = append(fields, path)
+	}
+	if protocol != "" {
+		fields = append(fields, hpack.HeaderField{Name: ":protocol", Value: protocol})
+	}
+	if cc.faults.Has(MismatchedHost) && host != req.URL.Host {
+		fields = append(fields, hpack.HeaderField{Name: "host", Value: host})
+	}
+	forward := cc.faults.Has(SendConnectionHeaders)
+	for k, vv := range req.Header {
+		if strings.HasPrefix(k, ":") {
+			continue
+		}
+		name := strings.ToLower(k)
+		switch name {
+		case "host", "connection", "keep-alive", "proxy-connection", "transfer-encoding", "upgrade":
+			if !forward {
+				continue
+			}
+		}
+		field := name
+		if cc.faults.Has(UppercaseHeaderNames) {
+			field = k
+		}
+		for _, v := range vv {
+			if name == "te" && v != "trailers" && !forward {
+				continue
+			}
+			if name == "priority" {
+				v = cc.priorityValue(v)
+			}
+			fields = append(fields, hpack.HeaderField{Name: field, Value: v})
+		}
+	}
+	if req.Header.Get("User-Agent") == "" {
+		fields = append(fields, hpack.HeaderField{Name: "user-agent", Value: "h2-mutant-client"})
+	}
+	if req.ContentLength > 0 {
+		fields = append(fields, hpack.HeaderField{Name: "content-length", Value: strconv.FormatInt(req.ContentLength, 10)})
+	}
+	if misorder {
+		fields = append(fields, path)
+	}
+	return fields
+}
+
+// priorityValue returns the Priority Field Value the client sends for
+// value, which the MalformedPriority fault spoils.
+func (cc *clientConn) priorityValue(value string) string {
+	if cc.faults.Has(MalformedPriority) {
+		log.Printf("mutant: %s: sending priority %q as %q", MalformedPriority, value, value+", (")
+		return value + ", ("
+	}
+	return value
+}
+
+// writePriorityUpdate signals value for stream id in a PRIORITY_UPDATE
+// frame, which belongs on stream 0 (RFC 9218 Section 7.1).
+// writePriority sends an RFC 7540 PRIORITY frame for stream id with the
+// default weight.
+func (cc *clientConn) writePriority(id uint32) error {
+	log.Printf("mutant: %s: sending PRIORITY for stream %d", IgnoreNoRFC7540Priorities, id)
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+	return cc.fr.WritePriority(id, http2.PriorityParam{Weight: 15})
+}
+
+func (cc *clientConn) writePriorityUpdate(id uint32, value string) error {
+	var streamID uint32
+	if cc.faults.Has(MisdirectPriorityUpdate) {
+		log.Printf("mutant: %s: sending PRIORITY_UPDATE on stream %d", MisdirectPriorityUpdate, id)
+		streamID = id
+	}
+	payload := priority.EncodeUpdate(id, cc.priorityValue(value))
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+	return cc.fr.WriteRawFrame(priority.FramePriorityUpdate, 0, streamID, payload)
+}
+
+func (cc *clientConn) encodeRequest(fields []hpack.HeaderField) []byte {
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+	cc.hbuf.Reset()
+
+	for i, f := range fields {
+		cc.enc.WriteField(f)
+		if i == 0 && cc.pendingTableSize != nil {
+			log.Printf("mutant: %s: signaling a header table size of %d after %s", MisplaceTableSizeUpdate, *cc.pendingTableSize, f.Name)
+			cc.enc.SetMaxDynamicTableSize(*cc.pendingTableSize)
+			cc.pendingTableSize = nil
+		}
+	}
+	if cc.faults.Has(CorruptHuffman) {
+		// A literal field without indexing whose Huffman-encoded value is
+		// the code of '0' padded with zero bits instead of ones.
+		cc.hbuf.Write([]byte{0x00, 0x06, 'x', '-', 'h', 'u', 'f', 'f', 0x81, 0x00})
+	}
+	if cc.faults.Has(BadTableIndex) {
+		// Indexed field 4000: the dynamic table of 4096 octets holds at
+		// most 128 entries after the 61 static ones.
+		cc.hbuf.Write([]byte{0xff, 0xa1, 0x1e})
+	}
+	return append([]byte(nil), cc.hbuf.Bytes()...)
+}
+
+// writeHeaders writes a header block, splitting it into CONTINUATION frames
+// when it exceeds the peer's SETTINGS_MAX_FRAME_SIZE.
+func (cc *clientConn) writeHeaders(id uint32, endStream bool, block []byte) error {
+	cc.mu.Lock()
+	max := int(cc.peerMaxFrameSize)
+	cc.mu.Unlock()
+	if cc.faults.Has(SkipContinuation) && len(block) > max {
+		log.Printf("mutant: %s: sending a header block of %d octets in one HEADERS frame", SkipContinuation, len(block))
+		max = len(block)
+	}
+
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+	first := true
+	for first || len(block) > 0 {
+		chunk := block
+		if len(chunk) > max {
+			chunk = chunk[:max]
+		}
+		block = block[len(chunk):]
+		var err error
+		if first {
+			err = cc.fr.WriteHeaders(http2.HeadersFrameParam{
+				StreamID:      id,
+				BlockFragment: chunk,
+				EndStream:     endStream,
+				EndHeaders:    len(block) == 0,
+			})
+		} else {
+			err = cc.fr.WriteContinuation(id, len(block) == 0, chunk)
+		}
+		if err != nil {
+			return err
+		}
+		first = false
+	}
+	return nil
+}
+
+// writeBody sends the request body, honouring the peer's flow-control
+// windows and SETTINGS_MAX_FRAME_SIZE.
+func (cc *clientConn) writeBody(cs *clientStream, body io.ReadCloser) {
+	defer body.Close()
+	if cs.continued != nil {
+		select {
+		case <-cs.continued:
+		case <-time.After(expectContinueTimeout):
+		}
+		// A final response in place of 100 Continue means the server
+		// decided without the body.
+		cc.mu.Lock()
+		answered := cs.res != nil && cs.res.StatusCode >= 200
+		cc.mu.Unlock()
+		if answered {
+			return
+		}
+	}
+	ignoreWindow := cc.faults.Has(IgnoreSendWindow)
+	buf := make([]byte, bodyChunkSize)
+	for {
+		n, rerr := body.Read(buf)
+		data := buf[:n]
+		for len(data) > 0 {
+			cc.mu.Lock()
+			for !ignoreWindow && cc.err == nil && cs.state == stateOpen && (cc.sendWindow <= 0 || cs.sendWindow <= 0) {
+				cc.cond.Wait()
+			}
+			if cc.err != nil || cs.state != stateOpen {
+				cc.mu.Unlock()
+				return
+			}
+			chunk := int64(len(data))
+			if !ignoreWindow {
+				chunk = min(chunk, cc.sendWindow, cs.sendWindow)
+			}
+			if !cc.faults.Has(IgnorePeerMaxFrameSize) {
+				chunk = min(chunk, int64(cc.peerMaxFrameSize))
+			}
+			cc.sendWindow -= chunk
+			cs.sendWindow -= chunk
+			cc.mu.Unlock()
+
+			if err := cc.writeData(cs.id, false, data[:chunk]); err != nil {
+				return
+			}
+			data = data[chunk:]
+		}
+		if rerr == io.EOF {
+			cc.writeData(cs.id, true, nil)
+			cc.mu.Lock()
+			cc.closeLocal(cs)
+			cc.mu.Unlock()
+			return
+		}
+		if rerr != nil {
+			cc.mu.Lock()
+			cc.resetStream(streamError(cs.id, http2.ErrCodeCancel, "reading request body: %v", rerr))
+			cc.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (cc *clientConn) writeData(id uint32, endStream bool, data []byte) error {
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+	return cc.fr.WriteData(id, endStream, data)
+}
+
+func (cc *clientConn) readLoop() {
+	err := cc.readFrames()
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.fail(err)
+}
+
+func (cc *clientConn) readFrames() error {
+	hdr := make([]byte, frameHeaderLen)
+	for {
+		cc.conn.SetReadDeadline(time.Now().Add(cc.timeout))
+		if _, err := io.ReadFull(cc.br, hdr); err != nil {
+			return cc.readError(err)
+		}
+		h := frameHeader{
+			length:   uint32(hdr[0])<<16 | uint32(hdr[1])<<8 | uint32(hdr[2]),
+			typ:      http2.FrameType(hdr[3]),
+			flags:    hdr[4],
+			streamID: binary.BigEndian.Uint32(hdr[5:]) & 0x7fffffff,
+			reserved: hdr[5]&0x80 != 0,
+		}
+		cc.mu.Lock()
+		err := cc.checkHeader(h)
+		cc.mu.Unlock()
+		if err != nil {
+			return err
+		}
+
+		payload := make([]byte, h.length)
+		if _, err := io.ReadFull(cc.br, payload); err != nil {
+			return cc.readError(err)
+		}
+
+		cc.mu.Lock()
+		err = cc.processFrame(h, payload)
+		if se, ok := err.(http2.StreamError); ok {
+			cc.resetStream(se)
+			err = nil
+		}
+		cc.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (cc *clientConn) readError(err error) error {
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return fmt.Errorf("mutant: no response within %v", cc.timeout)
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errUnexpectedEOF
+	}
+	return err
+}
+
+// fail tears down the connection. Every stream that has not completed
+// observes err. Callers must hold cc.mu.
+func (cc *clientConn) fail(err error) {
+	if cc.err != nil {
+		return
+	}
+	if cc.goAway != nil && err == errUnexpectedEOF {
+		err = cc.goAway
+	}
+	cc.err = err
+	if ce, ok := err.(connError); ok {
+		log.Printf("mutant: %v", ce)
+		cc.wmu.Lock()
+		cc.fr.WriteGoAway(cc.maxPromisedID, ce.Code, []byte(ce.Reason))
+		cc.wmu.Unlock()
+	}
+	cc.conn.Close()
+	for _, cs := range cc.streams {
+		if cs.state != stateClosed {
+			cs.state = stateClosed
+			cc.deliver(cs, err)
+		}
+	}
+	cc.cond.Broadcast()
+}
+
+// deliver reports err to whoever is waiting on the stream: the pending
+// round trip, or the reader of the response body.
+func (cc *clientConn) deliver(cs *clientStream, err error) {
+	if !cs.delivered {
+		cs.delivered = true
+		cs.respc <- result{err: err}
+		return
+	}
+	cs.body.closeWithError(err)
+}
+
+func (cc *clientConn) resetStream(se http2.StreamError) {
+	log.Printf("mutant: %v", se)
+	cc.wmu.Lock()
+	cc.fr.WriteRSTStream(se.StreamID, se.Code)
+	cc.wmu.Unlock()
+	if cs := cc.streams[se.StreamID]; cs != nil && cs.state != stateClosed {
+		cs.state = stateClosed
+		cs.resetLocally = true
+		cc.deliver(cs, se)
+	}
+	cc.cond.Broadcast()
+}
+
+// cancel resets cs with CANCEL if its response body is closed before the
+// stream has closed, leaving the connection to further requests.
+func (cc *clientConn) cancel(cs *clientStream) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.err == nil && cs.state != stateClosed {
+		cc.resetStream(streamError(cs.id, http2.ErrCodeCancel, "response body closed"))
+	}
+}
+
+// closeLocal records that the client sent END_STREAM on cs.
+func (cc *clientConn) closeLocal(cs *clientStream) {
+	switch cs.state {
+	case stateOpen:
+		cs.state = stateHalfClosedLocal
+	case stateHalfClosedRemote:
+		cs.state = stateClosed
+		cc.cond.Broadcast()
+	}
+}
+
+// closeRemote records that the server sent END_STREAM on cs.
+func (cc *clientConn) closeRemote(cs *clientStream) {
+	cs.remoteEnded = true
+	switch cs.state {
+	case stateOpen:
+		cs.state = stateHalfClosedRemote
+	case stateHalfClosedLocal:
+		cs.state = stateClosed
+	}
+	cc.cond.Broadcast()
+}
+
+// isIdle reports whether id names a stream that has not been opened by the
+// client or promised by the server.
+func (cc *clientConn) isIdle(id uint32) bool {
+	if _, ok := cc.streams[id]; ok {
+		return false
+	}
+	if id%2 == 1 {
+		return id >= cc.nextStreamID
+	}
+	return id > cc.maxPromisedID
+}
+
+// checkReceivable validates that a DATA, HEADERS or CONTINUATION frame may
+// arrive on the stream. It returns the stream, or nil if the frame must be
+// dropped.
+func (cc *clientConn) checkReceivable(typ http2.FrameType, id uint32) (*clientStream, error) {
+	if cc.isIdle(id) {
+		return nil, cc.violation(IgnoreStreamState, connError{http2.ErrCodeProtocol, fmt.Sprintf("%v frame on idle stream %d", typ, id)})
+	}
+	cs := cc.streams[id]
+	if cs == nil {
+		return nil, nil
+	}
+	switch {
+	case cs.state == stateClosed && cs.resetLocally:
+		if cc.faults.Has(StrictResetStream) {
+			return nil, connError{http2.ErrCodeStreamClosed, fmt.Sprintf("%v frame on stream %d after RST_STREAM", typ, id)}
+		}
+		// Frames sent before the peer saw our RST_STREAM are tolerated.
+		return nil, nil
+	case cs.state == stateClosed && cs.remoteEnded:
+		return nil, cc.violation(IgnoreStreamState, connError{http2.ErrCodeStreamClosed, fmt.Sprintf("%v frame on closed stream %d", typ, id)})
+	case cs.state == stateClosed || cs.state == stateHalfClosedRemote:
+		return nil, cc.violation(IgnoreStreamState, streamError(id, http2.ErrCodeStreamClosed, "%v frame on closed stream", typ))
+	case cs.state == stateReservedRemote && typ != http2.FrameHeaders:
+		return nil, cc.violation(IgnoreStreamState, connError{http2.ErrCodeProtocol, fmt.Sprintf("%v frame on reserved stream %d", typ, id)})
+	}
+	return cs, nil
+}
+
+func (cc *clientConn) stripPadding(h frameHeader, payload []byte) ([]byte, error) {
+	if h.flags&flagPadded == 0 {
+		return payload, nil
+	}
+	if len(payload) == 0 {
+		return payload, cc.violation(IgnorePadding, connError{http2.ErrCodeProtocol, "padded frame without pad length"})
+	}
+	padLen := int(payload[0])
+	if padLen >= len(payload) {
+		return payload[1:], cc.violation(IgnorePadding, connError{http2.ErrCodeProtocol, fmt.Sprintf("pad length %d exceeds frame payload", padLen)})
+	}
+	return payload[1 : len(payload)-padLen], nil
+}
+
+// checkHeader validates a frame header before its payload is read, so that
+// a peer that is not speaking HTTP/2 is detected without waiting for a
+// payload that never comes.
+func (cc *clientConn) checkHeader(h frameHeader) error {
+	if !cc.sawSettings {
+		cc.sawSettings = true
+		first := h.typ.String()
+		if h.typ == http2.FrameSettings && h.flags&flagAck != 0 {
+			first = "SETTINGS ACK"
+		}
+		if first != "SETTINGS" {
+			if err := cc.violation(IgnorePreface, connError{http2.ErrCodeProtocol, fmt.Sprintf("server preface starts with %s instead of SETTINGS", first)}); err != nil {
+				return err
+			}
+		}
+	}
+	if h.length > defaultMaxFrameSize {
+		if err := cc.violation(IgnoreFrameSize, connError{http2.ErrCodeFrameSize, fmt.Sprintf("%v frame of %d octets exceeds SETTINGS_MAX_FRAME_SIZE", h.typ, h.length)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cc *clientConn) processFrame(h frameHeader, payload []byte) error {
+	if cc.block != nil && (h.typ != http2.FrameContinuation || h.streamID != cc.block.streamID) {
+		if err := cc.violation(IgnoreContinuationOrder, connError{http2.ErrCodeProtocol, fmt.Sprintf("%v frame on stream %d interleaved with header block on stream %d", h.typ, h.streamID, cc.block.streamID)}); err != nil {
+			return err
+		}
+	}
+	if h.reserved && cc.faults.Has(StrictReservedBit) {
+		return connError{http2.ErrCodeProtocol, "reserved bit set in stream identifier"}
+	}
+	defined, known := definedFlags[h.typ]
+	if known && h.flags&^defined != 0 && cc.faults.Has(StrictFlags) {
+		return connError{http2.ErrCodeProtocol, fmt.Sprintf("undefined flags 0x%x on %v frame", h.flags&^defined, h.typ)}
+	}
+
+	switch h.typ {
+	case http2.FrameData:
+		return cc.processData(h, payload)
+	case http2.FrameHeaders:
+		return cc.processHeaders(h, payload)
+	case http2.FramePriority:
+		return cc.processPriority(h, payload)
+	case http2.FrameRSTStream:
+		return cc.processRSTStream(h, payload)
+	case http2.FrameSettings:
+		return cc.processSettings(h, payload)
+	case http2.FramePushPromise:
+		return cc.processPushPromise(h, payload)
+	case http2.FramePing:
+		return cc.processPing(h, payload)
+	case http2.FrameGoAway:
+		return cc.processGoAway(h, payload)
+	case http2.FrameWindowUpdate:
+		return cc.processWindowUpdate(h, payload)
+	case http2.FrameContinuation:
+		return cc.processContinuation(h, payload)
+	}
+	if cc.faults.Has(StrictUnknownFrames) {
+		return connError{http2.ErrCodeProtocol, fmt.Sprintf("unknown frame type 0x%x", uint8(h.typ))}
+	}
+	return nil
+}
+
+func (cc *clientConn) processData(h frameHeader, payload []byte) error {
+	if h.streamID == 0 {
+		return cc.violation(IgnoreStreamZero, connError{http2.ErrCodeProtocol, "DATA frame on stream 0"})
+	}
+	flowLen := int64(len(payload))
+	cc.recvWindow -= flowLen
+	if cc.recvWindow < 0 {
+		if err := cc.violation(IgnoreReceiveWindow, connError{http2.ErrCodeFlowControl, "DATA exceeds the connection receive window"}); err != nil {
+			return err
+		}
+	}
+
+	data, err := cc.stripPadding(h, payload)
+	if err != nil {
+		return err
+	}
+	cs, err := cc.checkReceivable(h.typ, h.streamID)
+	if cs == nil {
+		// Nobody will read a dropped frame; its credit returns at once.
+		cc.refund(nil, flowLen)
+		return err
+	}
+	cs.recvWindow -= flowLen
+	if cs.recvWindow < 0 {
+		if err := cc.violation(IgnoreReceiveWindow, streamError(cs.id, http2.ErrCodeFlowControl, "DATA exceeds the stream receive window")); err != nil {
+			cc.refund(nil, flowLen)
+			return err
+		}
+	}
+	if cs.res == nil {
+		cc.refund(nil, flowLen)
+		return cc.violation(IgnoreStreamState, streamError(cs.id, http2.ErrCodeProtocol, "DATA frame before response HEADERS"))
+	}
+	cs.received += int64(len(data))
+	if cs.contentLength >= 0 && cs.received > cs.contentLength {
+		if err := cc.violation(IgnoreContentLength, streamError(cs.id, http2.ErrCodeProtocol, "received %d octets, content-length is %d", cs.received, cs.contentLength)); err != nil {
+			cc.refund(nil, flowLen)
+			return err
+		}
+	}
+	cs.body.write(data)
+
+	// The application never reads the padding, so its credit returns at
+	// once; the data is credited as the body is read.
+	if !cc.faults.Has(SkipPaddingCredit) {
+		cc.refund(cs, flowLen-int64(len(data)))
+	}
+
+	if h.flags&flagEndStream != 0 {
+		return cc.endResponse(cs)
+	}
+	return nil
+}
+
+// refund returns n octets of receive window to the server on the connection
+// and, while the server may still send on it, on cs. Callers must hold cc.mu.
+func (cc *clientConn) refund(cs *clientStream, n int64) {
+	if n <= 0 || cc.err != nil || cc.faults.Has(SkipWindowUpdate) {
+		return
+	}
+	cc.recvWindow += n
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+	cc.fr.WriteWindowUpdate(0, uint32(n))
+	if cs != nil && (cc.faults.Has(CreditClosedStream) || !cs.remoteEnded && cs.state != stateClosed) {
+		cs.recvWindow += n
+		cc.fr.WriteWindowUpdate(cs.id, uint32(n))
+	}
+}
+
+// endResponse handles END_STREAM on a stream whose response has been
+// delivered.
+func (cc *clientConn) endResponse(cs *clientStream) error {
+	if cs.contentLength >= 0 && cs.received != cs.contentLength {
+		if err := cc.violation(IgnoreContentLength, streamError(cs.id, http2.ErrCodeProtocol, "received %d octets, content-length is %d", cs.received, cs.contentLength)); err != nil {
+			return err
+		}
+	}
+	cc.closeRemote(cs)
+	cs.body.closeWithError(io.EOF)
+	return nil
+}
+
+func (cc *clientConn) processHeaders(h frameHeader, payload []byte) error {
+	if h.streamID == 0 {
+		return cc.violation(IgnoreStreamZero, connError{http2.ErrCodeProtocol, "HEADERS frame on stream 0"})
+	}
+	block, err := cc.stripPadding(h, payload)
+	if err != nil {
+		return err
+	}
+	if h.flags&flagPriority != 0 {
+		if len(block) < 5 {
+			return cc.violation(IgnoreFrameLength, connError{http2.ErrCodeFrameSize, "HEADERS priority fields truncated"})
+		}
+		dep := binary.BigEndian.Uint32(block) & 0x7fffffff
+		block = block[5:]
+		if dep == h.streamID {
+			if err := cc.violation(IgnoreSelfDependency, streamError(h.streamID, http2.ErrCodeProtocol, "stream depends on itself")); err != nil {
+				return err
+			}
+		}
+	}
+	cc.block = &headerBlock{
+		streamID:  h.streamID,
+		endStream: h.flags&flagEndStream != 0,
+		buf:       append([]byte(nil), block...),
+	}
+	if h.flags&flagEndHeaders != 0 {
+		return cc.finishBlock()
+	}
+	return nil
+}
+
+func (cc *clientConn) processContinuation(h frameHeader, payload []byte) error {
+	if h.streamID == 0 {
+		return cc.violation(IgnoreStreamZero, connError{http2.ErrCodeProtocol, "CONTINUATION frame on stream 0"})
+	}
+	if cc.block == nil || cc.block.streamID != h.streamID {
+		return cc.violation(IgnoreContinuationOrder, connError{http2.ErrCodeProtocol, fmt.Sprintf("CONTINUATION frame on stream %d without a preceding header block", h.streamID)})
+	}
+	cc.block.buf = append(cc.block.buf, payload...)
+	if h.flags&flagEndHeaders != 0 {
+		return cc.finishBlock()
+	}
+	return nil
+}
+
+// decode decompresses a complete header block. Every block must pass
+// through the decoder, even on streams that are about to be rejected, to
+// keep the HPACK state synchronised.
+func (cc *clientConn) decode(block []byte) ([]hpack.HeaderField, error) {
+	var fields []hpack.HeaderField
+	cc.dec.SetEmitFunc(func(f hpack.HeaderField) { fields = append(fields, f) })
+	_, err := cc.dec.Write(block)
+	if err == nil {
+		err = cc.dec.Close()
+	}
+	if err != nil {
+		err = cc.violation(IgnoreHPACKErrors, connError{http2.ErrCodeCompression, fmt.Sprintf("hpack: %v", err)})
+	}
+	return fields, err
+}
+
+func (cc *clientConn) finishBlock() error {
+	b := cc.block
+	cc.block = nil
+	fields, err := cc.decode(b.buf)
+	if err != nil {
+		return err
+	}
+
+	if b.promised != 0 {
+		if cc.enablePush {
+			return cc.reservePush(b.promised, fields)
+		}
+		// The client disabled push; a tolerated promise is refused at once.
+		cc.wmu.Lock()
+		cc.fr.WriteRSTStream(b.promised, http2.ErrCodeCancel)
+		cc.wmu.Unlock()
+		return nil
+	}
+
+	cs, err := cc.checkReceivable(http2.FrameHeaders, b.streamID)
+	if cs == nil {
+		return err
+	}
+	if cs.state == stateReservedRemote {
+		// The pushed response opens the stream for the server only.
+		cs.state = stateHalfClosedLocal
+	}
+
+	if err := cc.checkHeaderListSize(cs.id, fields); err != nil {
+		return err
+	}
+
+	if cs.res != nil {
+		// A second header block carries trailers.
+		if !b.endStream {
+			if err := cc.violation(IgnoreMalformedHeaders, streamError(cs.id, http2.ErrCodeProtocol, "trailers without END_STREAM")); err != nil {
+				return err
+			}
+		}
+		if reason := validateFields(fields, true); reason != "" {
+			if err := cc.violation(IgnoreMalformedHeaders, streamError(cs.id, http2.ErrCodeProtocol, "%s", reason)); err != nil {
+				return err
+			}
+		}
+		if cc.faults.Has(DropTrailers) {
+			log.Printf("mutant: %s: dropping %d trailer field(s) on stream %d", DropTrailers, len(fields), cs.id)
+			fields = nil
+		}
+		for _, f := range fields {
+			cs.res.Trailer.Add(http.CanonicalHeaderKey(f.Name), f.Value)
+		}
+		if b.endStream {
+			return cc.endResponse(cs)
+		}
+		return nil
+	}
+
+	if reason := validateFields(fields, false); reason != "" {
+		if err := cc.violation(IgnoreMalformedHeaders, streamError(cs.id, http2.ErrCodeProtocol, "%s", reason)); err != nil {
+			return err
+		}
+	}
+	res := &http.Response{
+		Proto:         "HTTP/2.0",
+		ProtoMajor:    2,
+		Header:        make(http.Header),
+		Trailer:       make(http.Header),
+		ContentLength: -1,
+		Request:       cs.req,
+	}
+	for _, f := range fields {
+		if f.Name == ":status" {
+			res.StatusCode, _ = strconv.Atoi(f.Value)
+			continue
+		}
+		if strings.HasPrefix(f.Name, ":") {
+			continue
+		}
+		res.Header.Add(http.CanonicalHeaderKey(f.Name), f.Value)
+	}
+	informational := res.StatusCode >= 100 && res.StatusCode < 200 && res.StatusCode != http.StatusSwitchingProtocols
+	if informational && b.endStream {
+		return cc.violation(IgnoreMalformedHeaders, streamError(cs.id, http2.ErrCodeProtocol, "informational response with END_STREAM"))
+	}
+	if informational && !cc.faults.Has(FinalInformational) {
+		if res.StatusCode == http.StatusContinue {
+			cc.proceed(cs)
+		}
+		return nil
+	}
+	if informational {
+		log.Printf("mutant: %s: taking the %d response on stream %d for the final one", FinalInformational, res.StatusCode, cs.id)
+	}
+	if res.StatusCode == http.StatusSwitchingProtocols {
+		if err := cc.violation(IgnoreMalformedHeaders, streamError(cs.id, http2.ErrCodeProtocol, "101 response in HTTP/2")); err != nil {
+			return err
+		}
+	}
+	if cc.faults.Has(StrictPriorityHeader) {
+		for _, v := range res.Header.Values("Priority") {
+			if _, err := priority.Parse(v); err != nil {
+				return streamError(cs.id, http2.ErrCodeProtocol, "priority header field %q: %v", v, err)
+			}
+		}
+	}
+	res.Status = fmt.Sprintf("%d %s", res.StatusCode, http.StatusText(res.StatusCode))
+	if cl := res.Header.Get("Content-Length"); cl != "" {
+		if n, err := strconv.ParseInt(cl, 10, 64); err == nil {
+			cs.contentLength = n
+			res.ContentLength = n
+		}
+	}
+	cs.body = newBodyBuffer(func() { cc.cancel(cs) }, func(n int) {
+		cc.mu.Lock()
+		defer cc.mu.Unlock()
+		cc.refund(cs, int64(n))
+	})
+	res.Body = cs.body
+	cs.res = res
+	cc.proceed(cs)
+	cs.delivered = true
+	cs.respc <- result{res: res}
+
+	if b.endStream {
+		return cc.endResponse(cs)
+	}
+	return nil
+}
+
+// proceed releases the body of a request that expects 100 Continue, on a
+// 100 response or on a final response, which leaves the body unsent.
+func (cc *clientConn) proceed(cs *clientStream) {
+	if cs.continued == nil {
+		return
+	}
+	select {
+	case <-cs.continued:
+	default:
+		close(cs.continued)
+	}
+}
+
+// checkHeaderListSize enforces the SETTINGS_MAX_HEADER_LIST_SIZE the client
+// announced, measured as in RFC 7540 Section 6.5.2.
+func (cc *clientConn) checkHeaderListSize(id uint32, fields []hpack.HeaderField) error {
+	var size uint32
+	for _, f := range fields {
+		size += f.Size()
+	}
+	if cc.faults.Has(StrictHeaderListSize) && size == maxHeaderListSize {
+		return streamError(id, http2.ErrCodeProtocol, "response header list of %d octets reaches SETTINGS_MAX_HEADER_LIST_SIZE", size)
+	}
+	if size > maxHeaderListSize {
+		return cc.violation(IgnoreHeaderListSize, streamError(id, http2.ErrCodeProtocol, "response header list of %d octets exceeds SETTINGS_MAX_HEADER_LIST_SIZE of %d", size, maxHeaderListSize))
+	}
+	return nil
+}
+
+// validateFields checks a response header or trailer block against
+// RFC 7540 §8.1.2 and returns the reason it is malformed, if any.
+func validateFields(fields []hpack.HeaderField, trailers bool) string {
+	sawRegular := false
+	statuses := 0
+	for _, f := range fields {
+		if strings.ToLower(f.Name) != f.Name {
+			return fmt.Sprintf("uppercase header field name %q", f.Name)
+		}
+		if reason := validateFieldValue(f); reason != "" {
+			return reason
+		}
+		if strings.HasPrefix(f.Name, ":") {
+			switch {
+			case trailers:
+				return fmt.Sprintf("pseudo-header field %q in trailers", f.Name)
+			case sawRegular:
+				return fmt.Sprintf("pseudo-header field %q after regular header fields", f.Name)
+			case f.Name != ":status":
+				return fmt.Sprintf("pseudo-header field %q is not valid in a response", f.Name)
+			}
+			statuses++
+			continue
+		}
+		sawRegular = true
+		switch f.Name {
+		case "connection", "keep-alive", "proxy-connection", "transfer-encoding", "upgrade":
+			return fmt.Sprintf("connection-specific header field %q", f.Name)
+		case "te":
+			if f.Value != "trailers" {
+				return fmt.Sprintf("te header field with value %q", f.Value)
+			}
+		}
+	}
+	if !trailers && statuses != 1 {
+		return fmt.Sprintf("response carries %d :status pseudo-header fields", statuses)
+	}
+	return ""
+}
+
+// validateFieldValue checks the value of f against RFC 9113 Section 8.2.1:
+// no NUL, CR or LF, and no whitespace at either end.
+func validateFieldValue(f hpack.HeaderField) string {
+	v := f.Value
+	if strings.ContainsAny(v, "\x00\r\n") {
+		return fmt.Sprintf("header field %q with a NUL, CR or LF in its value", f.Name)
+	}
+	if v != "" && (strings.ContainsRune(" \t", rune(v[0])) || strings.ContainsRune(" \t", rune(v[len(v)-1]))) {
+		return fmt.Sprintf("header field %q with whitespace at either end of its value", f.Name)
+	}
+	return ""
+}
+
+func (cc *clientConn) processPriority(h frameHeader, payload []byte) error {
+	if h.streamID == 0 {
+		return cc.violation(IgnoreStreamZero, connError{http2.ErrCodeProtocol, "PRIORITY frame on stream 0"})
+	}
+	if len(payload) != 5 {
+		return cc.violation(IgnoreFrameLength, streamError(h.streamID, http2.ErrCodeFrameSize, "PRIORITY frame of %d octets", len(payload)))
+	}
+	if binary.BigEndian.Uint32(payload)&0x7fffffff == h.streamID {
+		return cc.violation(IgnoreSelfDependency, streamError(h.streamID, http2.ErrCodeProtocol, "stream depends on itself"))
+	}
+	return nil
+}
+
+func (cc *clientConn) processRSTStream(h frameHeader, payload []byte) error {
+	if len(payload) != 4 {
+		if err := cc.violation(IgnoreFrameLength, connError{http2.ErrCodeFrameSize, fmt.Sprintf("RST_STREAM frame of %d octets", len(payload))}); err != nil || len(payload) < 4 {
+			return err
+		}
+	}
+	if h.streamID == 0 {
+		return cc.violation(IgnoreStreamZero, connError{http2.ErrCodeProtocol, "RST_STREAM frame on stream 0"})
+	}
+	if cc.isIdle(h.streamID) {
+		return cc.violation(IgnoreStreamState, connError{http2.ErrCodeProtocol, fmt.Sprintf("RST_STREAM frame on idle stream %d", h.streamID)})
+	}
+	cs := cc.streams[h.streamID]
+	if cs == nil || cs.state == stateClosed {
+		return nil
+	}
+	code := http2.ErrCode(binary.BigEndian.Uint32(payload))
+	if code == http2.ErrCodeNo && cs.remoteEnded && cc.faults.Has(StrictEarlyResponse) {
+		// RFC 7540 Section 8.1: NO_ERROR after a complete response only
+		// asks the client to stop sending the request body.
+		log.Printf("mutant: %s: discarding the complete response on stream %d", StrictEarlyResponse, cs.id)
+		cs.body.discard(streamError(cs.id, code, "received from peer"))
+	}
+	cs.state = stateClosed
+	if cs.delivered && cc.faults.Has(TruncateOnReset) {
+		log.Printf("mutant: %s: ending the response body on stream %d at RST_STREAM %v", TruncateOnReset, cs.id, code)
+		cs.body.closeWithError(io.EOF)
+		cc.cond.Broadcast()
+		return nil
+	}
+	cc.deliver(cs, streamError(cs.id, code, "received from peer"))
+	cc.cond.Broadcast()
+	return nil
+}
+
+func (cc *clientConn) processSettings(h frameHeader, payload []byte) error {
+	if h.streamID != 0 {
+		if err := cc.violation(IgnoreNonZeroStream, connError{http2.ErrCodeProtocol, fmt.Sprintf("SETTINGS frame on stream %d", h.streamID)}); err != nil {
+			return err
+		}
+	}
+	if h.flags&flagAck != 0 {
+		if len(payload) != 0 {
+			return cc.violation(IgnoreFrameLength, connError{http2.ErrCodeFrameSize, "SETTINGS ACK with a payload"})
+		}
+		cc.settingsAcked = true
+		return nil
+	}
+	noPriorities := false
+	if len(payload)%6 != 0 {
+		if err := cc.violation(IgnoreFrameLength, connError{http2.ErrCodeFrameSize, fmt.Sprintf("SETTINGS frame of %d octets", len(payload))}); err != nil {
+			return err
+		}
+		payload = payload[:len(payload)-len(payload)%6]
+	}
+	for ; len(payload) > 0; payload = payload[6:] {
+		id := http2.SettingID(binary.BigEndian.Uint16(payload))
+		val := binary.BigEndian.Uint32(payload[2:])
+		switch id {
+		case http2.SettingHeaderTableSize:
+			size := min(val, headerTableSize)
+			cc.wmu.Lock()
+			switch {
+			case cc.faults.Has(SkipTableSizeUpdate):
+				log.Printf("mutant: %s: not signaling a header table size of %d", SkipTableSizeUpdate, val)
+			case cc.faults.Has(MisplaceTableSizeUpdate):
+				cc.pendingTableSize = &size
+			case cc.faults.Has(OversizeTableSizeUpdate) && val < headerTableSize:
+				log.Printf("mutant: %s: signaling a header table size of %d instead of %d", OversizeTableSizeUpdate, headerTableSize, val)
+				cc.enc.SetMaxDynamicTableSize(headerTableSize)
+			default:
+				cc.enc.SetMaxDynamicTableSize(size)
+			}
+			cc.wmu.Unlock()
+		case http2.SettingEnablePush:
+			// RFC 9113 Section 6.5.2: a server must not set it to 1.
+			if val > 0 {
+				if err := cc.violation(IgnoreSettingsValues, connError{http2.ErrCodeProtocol, fmt.Sprintf("SETTINGS_ENABLE_PUSH of %d from a server", val)}); err != nil {
+					return err
+				}
+			}
+		case http2.SettingInitialWindowSize:
+			if val > maxWindowSize {
+				if err := cc.violation(IgnoreSettingsValues, connError{http2.ErrCodeFlowControl, fmt.Sprintf("SETTINGS_INITIAL_WINDOW_SIZE of %d", val)}); err != nil {
+					return err
+				}
+			}
+			delta := int64(val) - cc.peerInitialWindow
+			cc.peerInitialWindow = int64(val)
+			if delta < 0 && cc.faults.Has(IgnoreWindowShrink) {
+				log.Printf("mutant: %s: keeping the send windows of open streams", IgnoreWindowShrink)
+				continue
+			}
+			for _, cs := range cc.streams {
+				cs.sendWindow += delta
+				if cs.sendWindow < 0 && cc.faults.Has(ClampNegativeWindow) {
+					log.Printf("mutant: %s: raising the send window of stream %d from %d to 0", ClampNegativeWindow, cs.id, cs.sendWindow)
+					cs.sendWindow = 0
+				}
+				if cs.sendWindow > maxWindowSize {
+					if err := cc.violation(IgnoreWindowOverflow, connError{http2.ErrCodeFlowControl, fmt.Sprintf("stream %d send window overflows", cs.id)}); err != nil {
+						return err
+					}
+				}
+			}
+		case http2.SettingMaxFrameSize:
+			if val < defaultMaxFrameSize || val > maxMaxFrameSize {
+				if err := cc.violation(IgnoreSettingsValues, connError{http2.ErrCodeProtocol, fmt.Sprintf("SETTINGS_MAX_FRAME_SIZE of %d", val)}); err != nil {
+					return err
+				}
+				continue
+			}
+			if val < cc.peerMaxFrameSize && cc.faults.Has(IgnoreFrameSizeDecrease) {
+				log.Printf("mutant: %s: keeping a frame size of %d instead of %d", IgnoreFrameSizeDecrease, cc.peerMaxFrameSize, val)
+				continue
+			}
+			cc.peerMaxFrameSize = val
+		case http2.SettingMaxConcurrentStreams:
+			if val < cc.peerMaxStreams && cc.peerMaxStreams != math.MaxUint32 && cc.faults.Has(IgnoreStreamLimitDecrease) {
+				log.Printf("mutant: %s: keeping a limit of %d streams instead of %d", IgnoreStreamLimitDecrease, cc.peerMaxStreams, val)
+				continue
+			}
+			cc.peerMaxStreams = val
+		case http2.SettingMaxHeaderListSize:
+			cc.peerMaxHeaderListSize = val
+		case http2.SettingEnableConnectProtocol:
+			switch {
+			case val > 1:
+				if err := cc.violation(IgnoreSettingsValues, connError{http2.ErrCodeProtocol, fmt.Sprintf("SETTINGS_ENABLE_CONNECT_PROTOCOL of %d", val)}); err != nil {
+					return err
+				}
+			case val == 0 && cc.connectProtocol:
+				// RFC 8441 Section 3: a server must not withdraw it.
+				if err := cc.violation(IgnoreConnectProtocolWithdrawal, connError{http2.ErrCodeProtocol, "SETTINGS_ENABLE_CONNECT_PROTOCOL withdrawn"}); err != nil {
+					return err
+				}
+				log.Printf("mutant: %s: no longer sending extended CONNECT", IgnoreConnectProtocolWithdrawal)
+				cc.connectProtocol = false
+			default:
+				cc.connectProtocol = val == 1
+			}
+		case settingNoRFC7540Priorities:
+			if val > 1 {
+				if err := cc.violation(IgnoreSettingsValues, connError{http2.ErrCodeProtocol, fmt.Sprintf("SETTINGS_NO_RFC7540_PRIORITIES of %d", val)}); err != nil {
+					return err
+				}
+			}
+			noPriorities = val == 1
+		default:
+			if cc.faults.Has(StrictUnknownSettings) {
+				return connError{http2.ErrCodeProtocol, fmt.Sprintf("unknown setting 0x%x", uint16(id))}
+			}
+		}
+	}
+	cc.peerSettings = true
+	cc.cond.Broadcast()
+
+	if cc.faults.Has(SkipSettingsAck) {
+		log.Printf("mutant: %s: not acknowledging SETTINGS", SkipSettingsAck)
+		return nil
+	}
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+	if err := cc.fr.WriteSettingsAck(); err != nil {
+		return err
+	}
+	if noPriorities {
+		cc.noRFC7540Priorities = true
+		if cc.faults.Has(IgnoreNoRFC7540Priorities) {
+			for id := range cc.streams {
+				log.Printf("mutant: %s: sending PRIORITY for stream %d", IgnoreNoRFC7540Priorities, id)
+				if err := cc.fr.WritePriority(id, http2.PriorityParam{Weight: 15}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (cc *clientConn) processPushPromise(h frameHeader, payload []byte) error {
+	if h.streamID == 0 {
+		return cc.violation(IgnoreStreamZero, connError{http2.ErrCodeProtocol, "PUSH_PROMISE frame on stream 0"})
+	}
+	if !cc.enablePush {
+		if err := cc.violation(IgnorePushDisabled, connError{http2.ErrCodeProtocol, "PUSH_PROMISE although SETTINGS_ENABLE_PUSH is 0"}); err != nil {
+			return err
+		}
+	}
+	block, err := cc.stripPadding(h, payload)
+	if err != nil {
+		return err
+	}
+	if len(block) < 4 {
+		return cc.violation(IgnoreFrameLength, connError{http2.ErrCodeFrameSize, "PUSH_PROMISE frame without promised stream"})
+	}
+	promised := binary.BigEndian.Uint32(block) & 0x7fffffff
+	if cc.enablePush {
+		if err := cc.checkPromise(h.streamID, promised); err != nil {
+			return err
+		}
+	}
+	if promised > cc.maxPromisedID {
+		cc.maxPromisedID = promised
+	}
+	cc.block = &headerBlock{
+		streamID: h.streamID,
+		promised: promised,
+		buf:      append([]byte(nil), block[4:]...),
+	}
+	if h.flags&flagEndHeaders != 0 {
+		return cc.finishBlock()
+	}
+	return nil
+}
+
+// checkPromise validates a PUSH_PROMISE on stream id that reserves stream
+// promised (RFC 7540 Section 6.6).
+func (cc *clientConn) checkPromise(id, promised uint32) error {
+	cs := cc.streams[id]
+	if cs == nil || id%2 == 0 || cs.state == stateHalfClosedRemote || cs.state == stateClosed && !cs.resetLocally {
+		if err := cc.violation(IgnoreStreamState, connError{http2.ErrCodeProtocol, fmt.Sprintf("PUSH_PROMISE frame on stream %d, which the server cannot send on", id)}); err != nil {
+			return err
+		}
+	}
+	if promised%2 == 1 || !cc.isIdle(promised) {
+		return cc.violation(IgnorePromisedStreamID, connError{http2.ErrCodeProtocol, fmt.Sprintf("PUSH_PROMISE reserves stream %d, which is not an idle server stream", promised)})
+	}
+	return nil
+}
+
+// reservePush reserves stream id for the pushed response to the promised
+// request in fields. An accepted push is sent to the Transport's Pushes
+// once its response arrives.
+func (cc *clientConn) reservePush(id uint32, fields []hpack.HeaderField) error {
+	cs := &clientStream{
+		id:            id,
+		state:         stateReservedRemote,
+		respc:         make(chan result, 1),
+		recvWindow:    defaultWindowSize,
+		contentLength: -1,
+	}
+	cc.streams[id] = cs
+	req, reason := promisedRequest(fields)
+	if reason != "" {
+		if err := cc.violation(AcceptUnsafePush, streamError(id, http2.ErrCodeProtocol, "promised request %s", reason)); err != nil {
+			return err
+		}
+	}
+	cs.req = req
+	if cc.refusePush {
+		cc.resetStream(streamError(id, http2.ErrCodeCancel, "push refused"))
+		return nil
+	}
+	go func() {
+		r := <-cs.respc
+		if r.err == nil && cc.pushes != nil {
+			cc.pushes <- r.res
+		}
+	}()
+	return nil
+}
+
+// promisedRequest returns the request a PUSH_PROMISE carries, and the
+// reason it cannot be pushed, if any: a promised request must be complete,
+// safe and cacheable (RFC 7540 Section 8.2).
+func promisedRequest(fields []hpack.HeaderField) (*http.Request, string) {
+	pseudo := make(map[string]string)
+	header := make(http.Header)
+	for _, f := range fields {
+		if strings.HasPrefix(f.Name, ":") {
+			pseudo[f.Name] = f.Value
+			continue
+		}
+		header.Add(http.CanonicalHeaderKey(f.Name), f.Value)
+	}
+	method := pseudo[":method"]
+	req, err := http.NewRequest(method, pseudo[":scheme"]+"://"+pseudo[":authority"]+pseudo[":path"], nil)
+	if err != nil {
+		return &http.Request{Method: method, Header: header}, fmt.Sprintf("is malformed: %v", err)
+	}
+	req.Header = header
+	for _, name := range []string{":method", ":scheme", ":authority", ":path"} {
+		if pseudo[name] == "" {
+			return req, fmt.Sprintf("lacks %s", name)
+		}
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		return req, fmt.Sprintf("has method %s, which is not safe and cacheable", method)
+	}
+	return req, ""
+}
+
+func (cc *clientConn) processPing(h frameHeader, payload []byte) error {
+	if h.streamID != 0 {
+		if err := cc.violation(IgnoreNonZeroStream, connError{http2.ErrCodeProtocol, fmt.Sprintf("PING frame on stream %d", h.streamID)}); err != nil {
+			return err
+		}
+	}
+	if len(payload) != 8 {
+		if err := cc.violation(IgnoreFrameLength, connError{http2.ErrCodeFrameSize, fmt.Sprintf("PING frame of %d octets", len(payload))}); err != nil {
+			return err
+		}
+	}
+	if h.flags&flagAck != 0 {
+		return nil
+	}
+	if cc.faults.Has(SkipPingAck) {
+		log.Printf("mutant: %s: not answering PING", SkipPingAck)
+		return nil
+	}
+	var data [8]byte
+	copy(data[:], payload)
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+	return cc.fr.WritePing(true, data)
+}
+
+func (cc *clientConn) processGoAway(h frameHeader, payload []byte) error {
+	if h.streamID != 0 {
+		if err := cc.violation(IgnoreNonZeroStream, connError{http2.ErrCodeProtocol, fmt.Sprintf("GOAWAY frame on stream %d", h.streamID)}); err != nil {
+			return err
+		}
+	}
+	if len(payload) < 8 {
+		return cc.violation(IgnoreFrameLength, connError{http2.ErrCodeFrameSize, fmt.Sprintf("GOAWAY frame of %d octets", len(payload))})
+	}
+	last := binary.BigEndian.Uint32(payload) & 0x7fffffff
+	code := http2.ErrCode(binary.BigEndian.Uint32(payload[4:]))
+	if cc.goAway != nil && last > cc.goAwayLast {
+		// RFC 7540 Section 6.8: the server MUST NOT raise the last stream
+		// identifier, as the client may already have retried the streams
+		// above it on another connection.
+		if err := cc.violation(IgnoreGoAwayIncrease, connError{http2.ErrCodeProtocol, fmt.Sprintf("GOAWAY raises the last stream identifier from %d to %d", cc.goAwayLast, last)}); err != nil {
+			return err
+		}
+		cc.goAwayLast = last
+		if last >= cc.nextStreamID {
+			cc.goAway = nil
+			cc.cond.Broadcast()
+		}
+		return nil
+	}
+	if cc.goAway != nil && cc.faults.Has(IgnoreGoAwayUpdate) {
+		log.Printf("mutant: %s: keeping the last stream identifier of %d over %d", IgnoreGoAwayUpdate, cc.goAwayLast, last)
+		return nil
+	}
+	cc.goAway = fmt.Errorf("mutant: server sent GOAWAY; LastStreamID=%d, ErrCode=%v, debug=%q", last, code, payload[8:])
+	cc.goAwayLast = last
+	// RFC 7540 Section 8.1.4: the server did not process the streams above
+	// the last stream identifier, so a graceful shutdown leaves their
+	// requests safe to retry.
+	err := cc.goAway
+	if code == http2.ErrCodeNo {
+		err = unprocessedError{cc.goAway}
+	}
+	for id, cs := range cc.streams {
+		if id%2 == 1 && id > last && cs.state != stateClosed {
+			cs.state = stateClosed
+			cc.deliver(cs, err)
+		}
+	}
+	cc.cond.Broadcast()
+	return nil
+}
+
+// unprocessedError fails a request the server shut the connection down
+// without processing, which the Transport retries on a new connection.
+type unprocessedError struct {
+	err error
+}
+
+func (e unprocessedError) Error() string {
+	return e.err.Error()
+}
+
+func (cc *clientConn) processWindowUpdate(h frameHeader, payload []byte) error {
+	if len(payload) != 4 {
+		if err := cc.violation(IgnoreFrameLength, connError{http2.ErrCodeFrameSize, fmt.Sprintf("WINDOW_UPDATE frame of %d octets", len(payload))}); err != nil || len(payload) < 4 {
+			return err
+		}
+	}
+	incr := int64(binary.BigEndian.Uint32(payload) & 0x7fffffff)
+	if h.streamID == 0 {
+		if incr == 0 {
+			return cc.violation(IgnoreZeroWindowIncrement, connError{http2.ErrCodeProtocol, "WINDOW_UPDATE with 0 increment"})
+		}
+		cc.sendWindow += incr
+		if cc.sendWindow > maxWindowSize {
+			if err := cc.violation(IgnoreWindowOverflow, connError{http2.ErrCodeFlowControl, "connection window overflow"}); err != nil {
+				return err
+			}
+		}
+		cc.cond.Broadcast()
+		return nil
+	}
+	if cc.isIdle(h.streamID) {
+		return cc.violation(IgnoreStreamState, connError{http2.ErrCodeProtocol, fmt.Sprintf("WINDOW_UPDATE frame on idle stream %d", h.streamID)})
+	}
+	cs := cc.streams[h.streamID]
+	if cs == nil || cs.state == stateClosed {
+		return nil
+	}
+	if incr == 0 {
+		return cc.violation(IgnoreZeroWindowIncrement, streamError(cs.id, http2.ErrCodeProtocol, "WINDOW_UPDATE with 0 increment"))
+	}
+	if cc.faults.Has(DropStreamCredit) {
+		log.Printf("mutant: %s: discarding %d octets of credit for stream %d", DropStreamCredit, incr, cs.id)
+		return nil
+	}
+	cs.sendWindow += incr
+	if cs.sendWindow > maxWindowSize {
+		if err := cc.violation(IgnoreWindowOverflow, streamError(cs.id, http2.ErrCodeFlowControl, "stream window overflow")); err != nil {
+			return err
+		}
+	}
+	cc.cond.Broadcast()
+	return nil
+}
+
+// bodyBuffer is an unbounded response body fed by the read loop. onRead is
+// told how many octets the application consumed, so that flow-control
+// credit follows the reader rather than the network.
+type bodyBuffer struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buf     bytes.Buffer
+	err     error
+	onClose func()
+	onRead  func(n int)
+}
+
+func newBodyBuffer(onClose func(), onRead func(n int)) *bodyBuffer {
+	b := &bodyBuffer{onClose: onClose, onRead: onRead}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *bodyBuffer) Read(p []byte) (int, error) {
+	n, err := b.read(p)
+	if n > 0 {
+		b.onRead(n)
+	}
+	return n, err
+}
+
+func (b *bodyBuffer) read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.buf.Len() == 0 && b.err == nil {
+		b.cond.Wait()
+	}
+	if b.buf.Len() > 0 {
+		return b.buf.Read(p)
+	}
+	return 0, b.err
+}
+
+func (b *bodyBuffer) Close() error {
+	b.closeWithError(errors.New("mutant: read on closed body"))
+	b.onClose()
+	return nil
+}
+
+func (b *bodyBuffer) write(p []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		b.buf.Write(p)
+		b.cond.Broadcast()
+	}
+}
+
+// discard drops whatever the body holds and fails its reads with err, even
+// after it has ended.
+func (b *bodyBuffer) discard(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+	b.err = err
+	b.cond.Broadcast()
+}
+
+func (b *bodyBuffer) closeWithError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		b.err = err
+		b.cond.Broadcast()
+	}
+}
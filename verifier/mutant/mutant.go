@@ -0,0 +1,592 @@
+// Package mutant implements a small, deliberately configurable HTTP/2 client.
+//
+// With no faults enabled the client performs the checks RFC 7540 requires of
+// a client. Each Fault switches off exactly one of those checks (or, for the
+// strict-* faults, makes the client reject something it must tolerate). The
+// verifier runs a test case against a client carrying the fault registered
+// for that case; if the verifier still passes, the test has no power to
+// discriminate a compliant client from a broken one.
+package mutant
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// Fault disables a single protocol check in the mutant client.
+type Fault string
+
+const (
+	// IgnorePreface accepts a server preface whose first frame is not SETTINGS.
+	IgnorePreface Fault = "ignore-preface"
+	// IgnoreFrameSize accepts frames larger than the advertised SETTINGS_MAX_FRAME_SIZE.
+	IgnoreFrameSize Fault = "ignore-frame-size"
+	// IgnoreFrameLength accepts PING, PRIORITY, RST_STREAM, SETTINGS, GOAWAY
+	// and WINDOW_UPDATE frames whose length is invalid for their type.
+	IgnoreFrameLength Fault = "ignore-frame-length"
+	// IgnoreStreamZero accepts stream-level frames on stream 0.
+	IgnoreStreamZero Fault = "ignore-stream-zero"
+	// IgnoreNonZeroStream accepts connection-level frames on a non-zero stream.
+	IgnoreNonZeroStream Fault = "ignore-nonzero-stream"
+	// IgnorePadding accepts a pad length that is not smaller than the payload.
+	IgnorePadding Fault = "ignore-padding"
+	// IgnoreContinuationOrder accepts frames interleaved with a header block
+	// and CONTINUATION frames that do not continue one.
+	IgnoreContinuationOrder Fault = "ignore-continuation-order"
+	// IgnoreStreamState accepts frames on idle, half-closed (remote) and
+	// closed streams.
+	IgnoreStreamState Fault = "ignore-stream-state"
+	// IgnoreSelfDependency accepts a stream that depends on itself.
+	IgnoreSelfDependency Fault = "ignore-self-dependency"
+	// IgnoreSettingsValues accepts out-of-range SETTINGS values.
+	IgnoreSettingsValues Fault = "ignore-settings-values"
+	// IgnoreWindowOverflow accepts WINDOW_UPDATE and SETTINGS changes that
+	// take a send window above 2^31-1.
+	IgnoreWindowOverflow Fault = "ignore-window-overflow"
+	// IgnoreZeroWindowIncrement accepts a WINDOW_UPDATE with an increment of 0.
+	IgnoreZeroWindowIncrement Fault = "ignore-zero-window-increment"
+	// IgnoreReceiveWindow accepts DATA beyond the advertised receive windows.
+	IgnoreReceiveWindow Fault = "ignore-receive-window"
+	// SkipWindowUpdate never returns flow-control credit to the server.
+	SkipWindowUpdate Fault = "skip-window-update"
+	// SkipPaddingCredit never returns the credit consumed by padding.
+	SkipPaddingCredit Fault = "skip-padding-credit"
+	// CreditClosedStream keeps sending WINDOW_UPDATE for a stream after the
+	// server closed it.
+	CreditClosedStream Fault = "credit-closed-stream"
+	// OverCreditWindow credits the connection with 2^31-1 octets on top of
+	// its initial window.
+	OverCreditWindow Fault = "over-credit-window"
+	// IgnoreSendWindow sends request DATA regardless of the server's
+	// flow-control windows.
+	IgnoreSendWindow Fault = "ignore-send-window"
+	// IgnorePeerMaxFrameSize sends request DATA frames larger than the
+	// server's SETTINGS_MAX_FRAME_SIZE.
+	IgnorePeerMaxFrameSize Fault = "ignore-peer-max-frame-size"
+	// IgnoreFrameSizeDecrease keeps sending DATA frames of the largest
+	// SETTINGS_MAX_FRAME_SIZE the server announced when it lowers the limit.
+	IgnoreFrameSizeDecrease Fault = "ignore-frame-size-decrease"
+	// SkipContinuation sends every request header block in a single
+	// HEADERS frame, however large.
+	SkipContinuation Fault = "skip-continuation"
+	// IgnorePeerMaxHeaderListSize sends requests whose header list exceeds
+	// the server's SETTINGS_MAX_HEADER_LIST_SIZE instead of failing them.
+	IgnorePeerMaxHeaderListSize Fault = "ignore-peer-max-header-list-size"
+	// IgnoreWindowShrink keeps the send windows of open streams when the
+	// server lowers SETTINGS_INITIAL_WINDOW_SIZE.
+	IgnoreWindowShrink Fault = "ignore-window-shrink"
+	// ClampNegativeWindow stops a send window that a lower
+	// SETTINGS_INITIAL_WINDOW_SIZE takes below zero at zero, so that the
+	// client resumes on less WINDOW_UPDATE credit than it owes.
+	ClampNegativeWindow Fault = "clamp-negative-window"
+	// DropStreamCredit discards the credit of WINDOW_UPDATE frames on
+	// streams, so that an upload stalls once its window is exhausted.
+	DropStreamCredit Fault = "drop-stream-credit"
+	// UppercaseHeaderNames sends request header field names as given
+	// instead of in lowercase.
+	UppercaseHeaderNames Fault = "uppercase-header-names"
+	// SendConnectionHeaders forwards connection-specific header fields and
+	// TE values other than "trailers" in requests.
+	SendConnectionHeaders Fault = "send-connection-headers"
+	// MisorderPseudoHeaders sends :path after the regular header fields.
+	MisorderPseudoHeaders Fault = "misorder-pseudo-headers"
+	// OmitPath sends requests without the :path pseudo-header field.
+	OmitPath Fault = "omit-path"
+	// DuplicatePseudoHeaders sends :method twice in every request.
+	DuplicatePseudoHeaders Fault = "duplicate-pseudo-headers"
+	// HostWithoutAuthority names the target in a host header field instead
+	// of :authority.
+	HostWithoutAuthority Fault = "host-without-authority"
+	// MismatchedHost takes :authority from the URL and sends the request's
+	// Host, where it differs, in a host header field alongside it.
+	MismatchedHost Fault = "mismatched-host"
+	// MalformedPriority sends priority header fields and PRIORITY_UPDATE
+	// values that are not valid Structured Fields.
+	MalformedPriority Fault = "malformed-priority"
+	// MisdirectPriorityUpdate sends PRIORITY_UPDATE frames on the stream
+	// they reprioritize instead of stream 0.
+	MisdirectPriorityUpdate Fault = "misdirect-priority-update"
+	// IgnoreNoRFC7540Priorities keeps sending RFC 7540 priority signals, a
+	// PRIORITY frame for every request, after acknowledging
+	// SETTINGS_NO_RFC7540_PRIORITIES of 1.
+	IgnoreNoRFC7540Priorities Fault = "ignore-no-rfc7540-priorities"
+	// ConnectWithPath sends :scheme and :path in a CONNECT request, which
+	// names only its target.
+	ConnectWithPath Fault = "connect-with-path"
+	// IgnoreConnectProtocolSetting sends an extended CONNECT although the
+	// server never set SETTINGS_ENABLE_CONNECT_PROTOCOL.
+	IgnoreConnectProtocolSetting Fault = "ignore-connect-protocol-setting"
+	// IgnoreConnectProtocolWithdrawal accepts a SETTINGS_ENABLE_CONNECT_PROTOCOL
+	// of 0 after the server has set it to 1, and stops sending extended
+	// CONNECT requests.
+	IgnoreConnectProtocolWithdrawal Fault = "ignore-connect-protocol-withdrawal"
+	// CoalesceUncovered sends a request for any origin on an open
+	// connection, whether or not its certificate covers the origin.
+	CoalesceUncovered Fault = "coalesce-uncovered"
+	// ReuseMisdirectedConnection retries a request answered with 421
+	// Misdirected Request on the connection that answered it.
+	ReuseMisdirectedConnection Fault = "reuse-misdirected-connection"
+	// ReuseStreamID sends every request on stream 1.
+	ReuseStreamID Fault = "reuse-stream-id"
+	// IgnoreMaxConcurrentStreams opens a stream for every request, however
+	// many the server's SETTINGS_MAX_CONCURRENT_STREAMS allows.
+	IgnoreMaxConcurrentStreams Fault = "ignore-max-concurrent-streams"
+	// IgnoreStreamLimitDecrease keeps the highest SETTINGS_MAX_CONCURRENT_STREAMS
+	// the server announced when it lowers the limit.
+	IgnoreStreamLimitDecrease Fault = "ignore-stream-limit-decrease"
+	// RetryResetRequests sends a request again after RST_STREAM with any
+	// error code, although only REFUSED_STREAM guarantees that the server
+	// did not process it.
+	RetryResetRequests Fault = "retry-reset-requests"
+	// IgnoreGoAwayUpdate keeps the last stream identifier of the first
+	// GOAWAY, so that it waits on streams a later GOAWAY leaves unprocessed.
+	IgnoreGoAwayUpdate Fault = "ignore-goaway-update"
+	// IgnoreGoAwayIncrease accepts a GOAWAY that raises the last stream
+	// identifier, and opens new streams again once it covers them.
+	IgnoreGoAwayIncrease Fault = "ignore-goaway-increase"
+	// SkipTableSizeUpdate never signals the server's
+	// SETTINGS_HEADER_TABLE_SIZE to its encoder, so that it keeps indexing
+	// into a table the server has shrunk.
+	SkipTableSizeUpdate Fault = "skip-table-size-update"
+	// MisplaceTableSizeUpdate signals a new SETTINGS_HEADER_TABLE_SIZE
+	// after the first field of the next header block.
+	MisplaceTableSizeUpdate Fault = "misplace-table-size-update"
+	// OversizeTableSizeUpdate answers a lowered SETTINGS_HEADER_TABLE_SIZE
+	// with a size update to its own default table size.
+	OversizeTableSizeUpdate Fault = "oversize-table-size-update"
+	// CorruptHuffman ends every request header block with a Huffman-encoded
+	// string whose padding is not the EOS prefix.
+	CorruptHuffman Fault = "corrupt-huffman"
+	// BadTableIndex ends every request header block with an indexed field
+	// beyond the static and dynamic tables.
+	BadTableIndex Fault = "bad-table-index"
+	// IgnoreHPACKErrors keeps whatever fields decoded before an HPACK error.
+	IgnoreHPACKErrors Fault = "ignore-hpack-errors"
+	// IgnoreHeaderListSize accepts header lists larger than the advertised
+	// SETTINGS_MAX_HEADER_LIST_SIZE.
+	IgnoreHeaderListSize Fault = "ignore-header-list-size"
+	// IgnorePushDisabled accepts PUSH_PROMISE although ENABLE_PUSH is 0.
+	IgnorePushDisabled Fault = "ignore-push-disabled"
+	// IgnorePromisedStreamID accepts a PUSH_PROMISE that reserves an odd or
+	// already used stream identifier.
+	IgnorePromisedStreamID Fault = "ignore-promised-stream-id"
+	// AcceptUnsafePush accepts a promised request whose method is not safe
+	// and cacheable, or whose pseudo-header fields are incomplete.
+	AcceptUnsafePush Fault = "accept-unsafe-push"
+	// IgnoreMalformedHeaders skips response header field validation.
+	IgnoreMalformedHeaders Fault = "ignore-malformed-headers"
+	// DropTrailers discards the fields of a response's trailer section.
+	DropTrailers Fault = "drop-trailers"
+	// TruncateOnReset ends a response body cleanly, as if the stream had
+	// completed, when the server resets the stream mid-response.
+	TruncateOnReset Fault = "truncate-on-reset"
+	// FinalInformational takes a 1xx response for the final response.
+	FinalInformational Fault = "final-informational"
+	// IgnoreContentLength skips the content-length versus DATA length check.
+	IgnoreContentLength Fault = "ignore-content-length"
+	// SkipSettingsAck never acknowledges the server's SETTINGS.
+	SkipSettingsAck Fault = "skip-settings-ack"
+	// SkipPingAck never answers a PING.
+	SkipPingAck Fault = "skip-ping-ack"
+	// StrictUnknownFrames rejects frames of an unknown type.
+	StrictUnknownFrames Fault = "strict-unknown-frames"
+	// StrictFlags rejects frames carrying undefined flags.
+	StrictFlags Fault = "strict-flags"
+	// StrictReservedBit rejects frames with the reserved stream ID bit set.
+	StrictReservedBit Fault = "strict-reserved-bit"
+	// StrictHeaderListSize rejects a header list exactly as large as the
+	// advertised SETTINGS_MAX_HEADER_LIST_SIZE.
+	StrictHeaderListSize Fault = "strict-header-list-size"
+	// StrictResetStream treats a frame that arrives on a stream after the
+	// client reset it as a STREAM_CLOSED connection error.
+	StrictResetStream Fault = "strict-reset-stream"
+	// StrictEarlyResponse discards a complete response that the server
+	// follows with RST_STREAM NO_ERROR before the request body is sent.
+	StrictEarlyResponse Fault = "strict-early-response"
+	// StrictGoAway fails the requests a GOAWAY leaves unprocessed instead
+	// of retrying them on a new connection.
+	StrictGoAway Fault = "strict-goaway"
+	// StrictRefusedStream fails a request the server refused with
+	// REFUSED_STREAM instead of retrying it.
+	StrictRefusedStream Fault = "strict-refused-stream"
+	// StrictSettingsTimeout closes the connection with SETTINGS_TIMEOUT
+	// unless the server acknowledges the client's SETTINGS within 500ms.
+	StrictSettingsTimeout Fault = "strict-settings-timeout"
+	// StrictUnknownSettings rejects SETTINGS with an unknown identifier.
+	StrictUnknownSettings Fault = "strict-unknown-settings"
+	// StrictPriorityHeader rejects a response whose priority header field
+	// is not a valid Priority Field Value.
+	StrictPriorityHeader Fault = "strict-priority-header"
+)
+
+// AllFaults lists every fault the mutant client understands.
+var AllFaults = []Fault{
+	IgnorePreface,
+	IgnoreFrameSize,
+	IgnoreFrameLength,
+	IgnoreStreamZero,
+	IgnoreNonZeroStream,
+	IgnorePadding,
+	IgnoreContinuationOrder,
+	IgnoreStreamState,
+	IgnoreSelfDependency,
+	IgnoreSettingsValues,
+	IgnoreWindowOverflow,
+	IgnoreZeroWindowIncrement,
+	IgnoreReceiveWindow,
+	SkipWindowUpdate,
+	SkipPaddingCredit,
+	CreditClosedStream,
+	OverCreditWindow,
+	IgnoreSendWindow,
+	IgnorePeerMaxFrameSize,
+	IgnoreFrameSizeDecrease,
+	SkipContinuation,
+	IgnorePeerMaxHeaderListSize,
+	IgnoreWindowShrink,
+	ClampNegativeWindow,
+	DropStreamCredit,
+	UppercaseHeaderNames,
+	SendConnectionHeaders,
+	MisorderPseudoHeaders,
+	OmitPath,
+	DuplicatePseudoHeaders,
+	HostWithoutAuthority,
+	MismatchedHost,
+	MalformedPriority,
+	MisdirectPriorityUpdate,
+	IgnoreNoRFC7540Priorities,
+	ConnectWithPath,
+	IgnoreConnectProtocolSetting,
+	IgnoreConnectProtocolWithdrawal,
+	CoalesceUncovered,
+	ReuseMisdirectedConnection,
+	ReuseStreamID,
+	IgnoreMaxConcurrentStreams,
+	IgnoreStreamLimitDecrease,
+	RetryResetRequests,
+	IgnoreGoAwayUpdate,
+	IgnoreGoAwayIncrease,
+	SkipTableSizeUpdate,
+	MisplaceTableSizeUpdate,
+	OversizeTableSizeUpdate,
+	CorruptHuffman,
+	BadTableIndex,
+	IgnoreHPACKErrors,
+	IgnoreHeaderListSize,
+	IgnorePushDisabled,
+	IgnorePromisedStreamID,
+	AcceptUnsafePush,
+	IgnoreMalformedHeaders,
+	DropTrailers,
+	TruncateOnReset,
+	FinalInformational,
+	IgnoreContentLength,
+	SkipSettingsAck,
+	SkipPingAck,
+	StrictUnknownFrames,
+	StrictFlags,
+	StrictReservedBit,
+	StrictHeaderListSize,
+	StrictResetStream,
+	StrictEarlyResponse,
+	StrictGoAway,
+	StrictRefusedStream,
+	StrictSettingsTimeout,
+	StrictUnknownSettings,
+	StrictPriorityHeader,
+}
+
+// FaultSet is the set of faults enabled on a client.
+type FaultSet map[Fault]bool
+
+// Has reports whether f is enabled.
+func (s FaultSet) Has(f Fault) bool {
+	return s[f]
+}
+
+func (s FaultSet) String() string {
+	if len(s) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(s))
+	for f := range s {
+		names = append(names, string(f))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// ParseFaults parses a comma-separated list of fault names. The empty string
+// and "none" yield an empty set, i.e. a compliant client.
+func ParseFaults(list string) (FaultSet, error) {
+	set := make(FaultSet)
+	if list == "" || list == "none" {
+		return set, nil
+	}
+	for _, name := range strings.Split(list, ",") {
+		f := Fault(strings.TrimSpace(name))
+		known := false
+		for _, k := range AllFaults {
+			if k == f {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown fault %q", f)
+		}
+		set[f] = true
+	}
+	return set, nil
+}
+
+// Transport is an http.RoundTripper that sends its requests over a single
+// HTTP/2 connection, applying the configured faults to it. It opens a new
+// connection once the current one has failed or received GOAWAY.
+type Transport struct {
+	Faults          FaultSet
+	TLSClientConfig *tls.Config
+	// Timeout bounds how long a connection waits for the next frame.
+	Timeout time.Duration
+	// EnablePush announces SETTINGS_ENABLE_PUSH=1. Every pushed response
+	// is sent to Pushes, with the promised request as its Request, unless
+	// RefusePush refuses the push with RST_STREAM CANCEL.
+	EnablePush bool
+	RefusePush bool
+	Pushes     chan *http.Response
+	// KeepAlive, if not zero, makes every connection send a PING at this
+	// interval for as long as it is open, as a gRPC client's keepalive
+	// does.
+	KeepAlive time.Duration
+	// PriorityUpdate, if not empty, is a Priority Field Value the client
+	// signals in a PRIORITY_UPDATE frame right after opening each request,
+	// reprioritizing it (RFC 9218 Section 7.1).
+	PriorityUpdate string
+	// Resolve, if set, returns the address to dial for the authority of a
+	// request, as host:port.
+	Resolve func(addr string) string
+
+	mu    sync.Mutex
+	conns []*clientConn
+}
+
+// NewTransport returns a Transport with the given faults that accepts the
+// harness's self-signed certificate.
+func NewTransport(faults FaultSet) *Transport {
+	return &Transport{
+		Faults: faults,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true, // We expect a self-signed cert
+		},
+	}
+}
+
+// maxAttempts bounds how often the Transport sends a request that the
+// server left unprocessed.
+const maxAttempts = 3
+
+// RoundTrip implements http.RoundTripper. It sends a request again that
+// the server left unprocessed, with GOAWAY or REFUSED_STREAM, unless its
+// body cannot be sent again. A request the server resets with
+// HTTP_1_1_REQUIRED is sent again over HTTP/1.1, and a request answered
+// with 421 Misdirected Request on another connection (RFC 7540 Section
+// 9.1.2).
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		cc, err := t.conn(req)
+		if err != nil {
+			return nil, err
+		}
+		res, err := cc.roundTrip(req)
+		if err == nil && res.StatusCode == http.StatusMisdirectedRequest && attempt < maxAttempts {
+			if retry, ok := rewind(req); ok {
+				res.Body.Close()
+				if t.Faults.Has(ReuseMisdirectedConnection) {
+					log.Printf("mutant: %s: sending %s %s again on the connection that answered 421", ReuseMisdirectedConnection, req.Method, req.URL)
+				} else {
+					t.misdirect(cc, authorityOf(req))
+					log.Printf("mutant: sending %s %s again on another connection after 421 Misdirected Request", req.Method, req.URL)
+				}
+				req = retry
+				continue
+			}
+		}
+		if err == nil || attempt == maxAttempts {
+			return res, err
+		}
+		se, reset := err.(http2.StreamError)
+		http1 := reset && se.Code == http2.ErrCodeHTTP11Required
+		if !http1 && !t.retryable(err) {
+			return res, err
+		}
+		retry, ok := rewind(req)
+		if !ok {
+			return res, err
+		}
+		req = retry
+		if http1 {
+			log.Printf("mutant: sending %s %s again over HTTP/1.1: %v", req.Method, req.URL.Path, err)
+			return t.roundTripHTTP1(req)
+		}
+		log.Printf("mutant: sending %s %s again: %v", req.Method, req.URL.Path, err)
+	}
+}
+
+// retryable reports whether a request that failed with err may be sent
+// again, because the server did not process it (RFC 7540 Section 8.1.4).
+func (t *Transport) retryable(err error) bool {
+	if _, ok := err.(unprocessedError); ok {
+		if t.Faults.Has(StrictGoAway) {
+			log.Printf("mutant: %s: failing the unprocessed request instead of retrying it", StrictGoAway)
+			return false
+		}
+		return true
+	}
+	se, ok := err.(http2.StreamError)
+	switch {
+	case !ok:
+		return false
+	case se.Code == http2.ErrCodeRefusedStream:
+		if t.Faults.Has(StrictRefusedStream) {
+			log.Printf("mutant: %s: failing the refused request instead of retrying it", StrictRefusedStream)
+			return false
+		}
+		return true
+	case t.Faults.Has(RetryResetRequests):
+		log.Printf("mutant: %s: retrying a request reset with %v", RetryResetRequests, se.Code)
+		return true
+	}
+	return false
+}
+
+// rewind returns req ready to be sent again, or false if its body cannot
+// be sent again.
+func rewind(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	req = req.Clone(req.Context())
+	req.Body = body
+	return req, true
+}
+
+// roundTripHTTP1 sends req over a new HTTP/1.1 connection.
+func (t *Transport) roundTripHTTP1(req *http.Request) (*http.Response, error) {
+	cfg := &tls.Config{}
+	if t.TLSClientConfig != nil {
+		cfg = t.TLSClientConfig.Clone()
+	}
+	cfg.NextProtos = []string{"http/1.1"}
+	rt := &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true}
+	return rt.RoundTrip(req)
+}
+
+// conn returns the connection to send req on: one dialed for its
+// authority, or one dialed for another authority whose certificate covers
+// the request's host and whose address it resolves to, which the client
+// may reuse for it (RFC 7540 Section 9.1.1). It dials a new connection
+// unless one of them can still open streams and did not answer a request
+// for the authority with 421 Misdirected Request.
+func (t *Transport) conn(req *http.Request) (*clientConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	authority := authorityOf(req)
+	addr := authority
+	if t.Resolve != nil {
+		addr = t.Resolve(authority)
+	}
+
+	live := t.conns[:0]
+	for _, cc := range t.conns {
+		if cc.usable() {
+			live = append(live, cc)
+		}
+	}
+	t.conns = live
+	for _, cc := range t.conns {
+		if cc.misdirected[authority] {
+			continue
+		}
+		if cc.authority == authority {
+			return cc, nil
+		}
+		if t.Faults.Has(CoalesceUncovered) {
+			log.Printf("mutant: %s: sending the request for %s on the connection for %s", CoalesceUncovered, authority, cc.authority)
+			return cc, nil
+		}
+		if cc.authoritative(req.URL.Hostname(), addr) {
+			log.Printf("mutant: sending the request for %s on the connection for %s", authority, cc.authority)
+			return cc, nil
+		}
+	}
+
+	cfg := &tls.Config{}
+	if t.TLSClientConfig != nil {
+		cfg = t.TLSClientConfig.Clone()
+	}
+	cfg.NextProtos = []string{http2.NextProtoTLS}
+	if cfg.ServerName == "" {
+		cfg.ServerName = req.URL.Hostname()
+	}
+
+	conn, err := tls.Dial("tcp", addr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if p := conn.ConnectionState().NegotiatedProtocol; p != http2.NextProtoTLS {
+		conn.Close()
+		return nil, fmt.Errorf("mutant: server negotiated %q instead of h2", p)
+	}
+
+	timeout := t.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	cc := newClientConn(conn, t.Faults, timeout)
+	cc.authority = authority
+	cc.enablePush, cc.refusePush, cc.pushes = t.EnablePush, t.RefusePush, t.Pushes
+	cc.keepAlive = t.KeepAlive
+	cc.priorityUpdate = t.PriorityUpdate
+	if err := cc.start(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	t.conns = append(t.conns, cc)
+	return cc, nil
+}
+
+// authorityOf returns the authority of req's URL, with its port.
+func authorityOf(req *http.Request) string {
+	if req.URL.Port() == "" {
+		return req.URL.Host + ":443"
+	}
+	return req.URL.Host
+}
+
+// misdirect records that cc answered a request for authority with 421
+// Misdirected Request, so that the request goes to another connection.
+func (t *Transport) misdirect(cc *clientConn, authority string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if cc.misdirected == nil {
+		cc.misdirected = make(map[string]bool)
+	}
+	cc.misdirected[authority] = true
+}
@@ -0,0 +1,34 @@
+package verifier
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
+)
+
+// ExpectPriorityUpdate performs a GET request through a client that
+// reprioritizes it with a PRIORITY_UPDATE frame carrying value, and expects
+// a 200 response with body "ok". The reference client cannot send
+// PRIORITY_UPDATE, so it performs the request without one. This is used
+// for tests where the harness grades the client's PRIORITY_UPDATE frames.
+func ExpectPriorityUpdate(value string) error {
+	client := newClient()
+	if t, ok := transport.(*mutant.Transport); ok {
+		client = &http.Client{Transport: &mutant.Transport{
+			Faults:          t.Faults,
+			TLSClientConfig: t.TLSClientConfig,
+			Timeout:         t.Timeout,
+			PriorityUpdate:  value,
+		}}
+	} else {
+		log.Println("The reference client cannot send PRIORITY_UPDATE; requesting without it.")
+	}
+	if err := expectOK(client); err != nil {
+		return fmt.Errorf("expected a 200 response with body \"ok\": %v", err)
+	}
+
+	log.Printf("Got expected response to a request reprioritized with %q", value)
+	return nil
+}
@@ -1,14 +1,24 @@
 package verifier
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
+	"math/rand"
+	"net"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/nomadlabsinc/h2-client-test-harness/verifier/mutant"
 	"golang.org/x/net/http2"
 )
 
@@ -16,6 +26,14 @@ type VerifierFunc func() error
 
 var testRegistry = make(map[string]VerifierFunc)
 
+// mutantRegistry maps a test case to the faults of the mutant client that
+// the test case is expected to catch.
+var mutantRegistry = make(map[string][]mutant.Fault)
+
+// transport, when set, replaces the reference http2.Transport for every
+// request a verifier makes.
+var transport http.RoundTripper
+
 func Register(id string, f VerifierFunc) {
 	if _, ok := testRegistry[id]; ok {
 		panic("test case already registered: " + id)
@@ -28,6 +46,34 @@ func GetTest(id string) (VerifierFunc, bool) {
 	return test, ok
 }
 
+// RegisterMutant records that test case id must fail against a mutant
+// client carrying the given faults.
+func RegisterMutant(id string, faults ...mutant.Fault) {
+	if _, ok := mutantRegistry[id]; ok {
+		panic("mutant already registered: " + id)
+	}
+	mutantRegistry[id] = faults
+}
+
+// GetMutant returns the faults registered for test case id.
+func GetMutant(id string) (mutant.FaultSet, bool) {
+	faults, ok := mutantRegistry[id]
+	if !ok {
+		return nil, false
+	}
+	set := make(mutant.FaultSet)
+	for _, f := range faults {
+		set[f] = true
+	}
+	return set, true
+}
+
+// UseTransport makes all verifiers issue their requests through rt instead
+// of the reference client.
+func UseTransport(rt http.RoundTripper) {
+	transport = rt
+}
+
 func PrintAllTests() {
 	fmt.Println("Available test cases:")
 	keys := make([]string, 0, len(testRegistry))
@@ -42,23 +88,73 @@ func PrintAllTests() {
 
 // newClient creates a new HTTP/2 client with our self-signed certificate.
 func newClient() *http.Client {
+	if transport != nil {
+		return &http.Client{Transport: transport}
+	}
 	return &http.Client{
 		Transport: &http2.Transport{
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: true, // We expect a self-signed cert
 			},
 			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return (&tls.Dialer{Config: cfg}).DialContext(ctx, network, Resolve(addr))
+			},
 		},
 	}
 }
 
+// Resolve returns the address to dial for addr. Names under the reserved
+// .test domain resolve to the harness, so that test cases can serve more
+// than one origin.
+func Resolve(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || !strings.HasSuffix(host, ".test") {
+		return addr
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// skipHeader is the response header field with which the harness tells the
+// verifier that the client's settings leave nothing for the test case to
+// check, and why.
+const skipHeader = "X-Harness-Skipped"
+
+// skipReason is the reason the harness gave for skipping the test case,
+// if it did.
+var skipReason string
+
+// skipped reports whether the harness skipped the test case with resp, and
+// logs and records why.
+func skipped(resp *http.Response) bool {
+	reason := resp.Header.Get(skipHeader)
+	if reason == "" {
+		return false
+	}
+	log.Printf("Skipped: %s", reason)
+	skipReason = reason
+	return true
+}
+
+// Skipped returns the reason the harness gave for skipping the test case
+// that just ran, and whether it did. A skipped test case checked nothing
+// about the client, so it must not count as passed.
+func Skipped() (string, bool) {
+	return skipReason, skipReason != ""
+}
+
 // ExpectConnectionError performs a GET request and checks if the resulting
 // error contains one of the expected error substrings. This is used for
-// tests that should cause a connection-level error.
+// tests that should cause a connection-level error. A response with which
+// the harness skipped the test case returns nil too; see Skipped.
 func ExpectConnectionError(expectedErrors ...string) error {
 	client := newClient()
-	_, err := client.Get("https://127.0.0.1:8080")
+	resp, err := client.Get("https://127.0.0.1:8080")
 	if err == nil {
+		resp.Body.Close()
+		if skipped(resp) {
+			return nil
+		}
 		return fmt.Errorf("expected a connection error, but got none")
 	}
 
@@ -115,3 +211,925 @@ func ExpectSuccessfulRequest() error {
 	log.Println("Got successful response as expected.")
 	return nil
 }
+
+// ExpectResponse performs a GET request and expects the given status code
+// and the exact response body. This is used for tests where the client must
+// reassemble a response delivered in an unusual but valid way.
+func ExpectResponse(expectedStatus int, expectedBody string) error {
+	client := newClient()
+	resp, err := client.Get("https://127.0.0.1:8080")
+	if err != nil {
+		return fmt.Errorf("expected a successful request, but got an error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("expected a complete response body, but got an error: %v", err)
+	}
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("expected status %d, but got %s", expectedStatus, resp.Status)
+	}
+	if string(body) != expectedBody {
+		return fmt.Errorf("expected body %q, but got %q", expectedBody, body)
+	}
+
+	log.Printf("Got expected response: %s with body %q", resp.Status, body)
+	return nil
+}
+
+// ExpectResponseOrError performs a GET request and expects either the given
+// status code and the exact response body, or an error containing one of
+// the expected substrings. This is used for tests of frames that a client
+// may ignore or reject, but must not take in any other way.
+func ExpectResponseOrError(expectedStatus int, expectedBody string, expectedErrors ...string) error {
+	client := newClient()
+	resp, err := client.Get("https://127.0.0.1:8080")
+	var body []byte
+	if err == nil {
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err != nil {
+		for _, expected := range expectedErrors {
+			if strings.Contains(err.Error(), expected) {
+				log.Printf("Got expected error: %v", err)
+				return nil // Test passed
+			}
+		}
+		return fmt.Errorf("got an unexpected error: %v, expected a response or one of: %v", err, expectedErrors)
+	}
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("expected status %d, but got %s", expectedStatus, resp.Status)
+	}
+	if string(body) != expectedBody {
+		return fmt.Errorf("expected body %q, but got %q", expectedBody, body)
+	}
+
+	log.Printf("Got expected response: %s with body %q", resp.Status, body)
+	return nil
+}
+
+// ExpectVirtualHostResponse performs a GET request on the harness's address
+// for the virtual host host and expects a 200 response with body "ok".
+// This is used for tests where the harness grades how the client conveys
+// the host.
+func ExpectVirtualHostResponse(host string) error {
+	req, err := http.NewRequest(http.MethodGet, "https://127.0.0.1:8080", nil)
+	if err != nil {
+		return err
+	}
+	req.Host = host
+	resp, err := newClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("expected a successful request, but got an error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("expected a complete response body, but got an error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		return fmt.Errorf("expected status 200 with body \"ok\", but got %s with body %q", resp.Status, body)
+	}
+
+	log.Printf("Got expected response for virtual host %s", host)
+	return nil
+}
+
+// ExpectFollowUp performs a GET request and then a follow-up GET request
+// for url through the same client, and expects a 200 response with body
+// "ok" to each. This is used for tests where the harness sends a frame
+// that may change where the client sends the follow-up, such as ORIGIN or
+// ALTSVC, and logs the connection it arrives on.
+func ExpectFollowUp(url string) error {
+	client := newClient()
+	if err := expectOK(client); err != nil {
+		return fmt.Errorf("expected the first request to succeed: %v", err)
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("expected the follow-up request for %s to succeed, but got an error: %v", url, err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("expected a complete body for the follow-up request, but got an error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		return fmt.Errorf("expected status 200 with body \"ok\" for the follow-up request, but got %s with body %q", resp.Status, body)
+	}
+
+	log.Printf("Got expected responses to the request and the follow-up for %s", url)
+	return nil
+}
+
+// ExpectOrigins performs a GET request for each of urls in turn through one
+// client and expects a 200 response with body "ok" to each. This is used
+// for tests where the harness serves several origins and grades which
+// connection the client sends each request on.
+func ExpectOrigins(urls ...string) error {
+	client := newClient()
+	for _, url := range urls {
+		if err := getOK(client, url); err != nil {
+			return fmt.Errorf("expected the request for %s to succeed: %v", url, err)
+		}
+	}
+
+	log.Printf("Got expected responses for %d origin(s)", len(urls))
+	return nil
+}
+
+// ExpectMisdirected performs a GET request for url and expects either a 200
+// response with body "ok", if the client sent it again on another
+// connection, or the 421 Misdirected Request the harness answers first.
+// This is used for tests where the harness grades the connection the client
+// retries a misdirected request on.
+func ExpectMisdirected(url string) error {
+	resp, err := newClient().Get(url)
+	if err != nil {
+		return fmt.Errorf("expected a response for %s, but got an error: %v", url, err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("expected a complete body, but got an error: %v", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusMisdirectedRequest:
+		log.Printf("Got 421 Misdirected Request for %s; the client did not retry it", url)
+	case resp.StatusCode == http.StatusOK && string(body) == "ok":
+		log.Printf("Got expected response for %s after 421 Misdirected Request", url)
+	default:
+		return fmt.Errorf("expected status 200 with body \"ok\" or 421 for %s, but got %s with body %q", url, resp.Status, body)
+	}
+	return nil
+}
+
+// ExpectResponses performs n GET requests in turn through one client and
+// expects each to get the given status code and the exact response body.
+// This is used for tests where the harness grades the stream identifiers
+// of requests that share a connection.
+func ExpectResponses(n int, expectedStatus int, expectedBody string) error {
+	client := newClient()
+	for i := 1; i <= n; i++ {
+		resp, err := client.Get("https://127.0.0.1:8080")
+		if err != nil {
+			return fmt.Errorf("expected request %d of %d to succeed, but got an error: %v", i, n, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("expected a complete body for request %d of %d, but got an error: %v", i, n, err)
+		}
+		if resp.StatusCode != expectedStatus || string(body) != expectedBody {
+			return fmt.Errorf("expected status %d with body %q for request %d of %d, but got %s with body %q", expectedStatus, expectedBody, i, n, resp.Status, body)
+		}
+	}
+
+	log.Printf("Got %d expected responses", n)
+	return nil
+}
+
+// ExpectParallelResponses performs a first GET request and then n more at
+// once through the same client, and expects a 200 response with body "ok"
+// to each. The first response makes sure the client has applied the
+// server's SETTINGS before the workload starts. This is used for tests
+// where the harness limits how many streams the client may open
+// concurrently.
+func ExpectParallelResponses(n int) error {
+	client := newClient()
+	if err := expectOK(client); err != nil {
+		return fmt.Errorf("expected the first request to succeed: %v", err)
+	}
+	if err := expectParallel(client, n); err != nil {
+		return err
+	}
+
+	log.Printf("Got %d expected responses to parallel requests", n)
+	return nil
+}
+
+// ExpectRetriedResponses performs n GET requests at once through one
+// client, and then later more in turn, and expects a 200 response with
+// body "ok" to each. If tolerated is not empty, a parallel request that
+// fails with an error containing one of tolerated passes too: the client
+// may fail a request a GOAWAY leaves unprocessed instead of sending it
+// again. This is used for tests where the harness shuts the connection
+// down with GOAWAY under the workload and answers the requests it leaves
+// unprocessed only on a new connection.
+func ExpectRetriedResponses(n, later int, tolerated ...string) error {
+	client := newClient()
+	if err := expectParallel(client, n, tolerated...); err != nil {
+		return err
+	}
+	// Leave the client time to process every GOAWAY before it picks a
+	// connection for the later requests.
+	time.Sleep(200 * time.Millisecond)
+	for i := 1; i <= later; i++ {
+		if err := expectOK(client); err != nil {
+			return fmt.Errorf("expected later request %d of %d to succeed: %v", i, later, err)
+		}
+	}
+
+	log.Printf("Got %d expected responses to parallel requests and %d to later ones", n, later)
+	return nil
+}
+
+// expectParallel performs n GET requests at once through client and checks
+// for a 200 response with body "ok" to each, or for an error containing one
+// of tolerated.
+func expectParallel(client *http.Client, n int, tolerated ...string) error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := expectOK(client); err != nil {
+				for _, t := range tolerated {
+					if strings.Contains(err.Error(), t) {
+						log.Printf("Client failed request %d of %d: %v", i+1, n, err)
+						return
+					}
+				}
+				errs[i] = fmt.Errorf("expected request %d of %d to succeed: %v", i+1, n, err)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpectPacedResponses performs n GET requests through one client, gap
+// apart, and expects a 200 response with body "ok" to each. A request that
+// fails with an error containing one of tolerated ends the test instead:
+// the client has closed the connection in a way the test case allows. This
+// is used for tests where the harness holds back or repeats the ACK of the
+// client's SETTINGS.
+func ExpectPacedResponses(n int, gap time.Duration, tolerated ...string) error {
+	client := newClient()
+	for i := 1; i <= n; i++ {
+		if i > 1 {
+			time.Sleep(gap)
+		}
+		err := expectOK(client)
+		if err == nil {
+			continue
+		}
+		for _, t := range tolerated {
+			if strings.Contains(err.Error(), t) {
+				log.Printf("Client closed the connection after %d of %d requests: %v", i-1, n, err)
+				return nil
+			}
+		}
+		return fmt.Errorf("expected request %d of %d to succeed: %v", i, n, err)
+	}
+
+	log.Printf("Got %d expected responses", n)
+	return nil
+}
+
+// expectOK performs a GET request through client and checks for a 200
+// response with body "ok".
+func expectOK(client *http.Client) error {
+	return getOK(client, "https://127.0.0.1:8080")
+}
+
+// getOK performs a GET request for url through client and expects a 200
+// response with body "ok".
+func getOK(client *http.Client, url string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("incomplete body: %v", err)
+	}
+	if resp.StatusCode != 200 || string(body) != "ok" {
+		return fmt.Errorf("got %s with body %q", resp.Status, body)
+	}
+	return nil
+}
+
+// ExpectResetRequest performs a request with method, carrying a short body
+// unless it is a GET, and expects a 200 response with body "ok". If
+// tolerated is not empty, an error containing one of tolerated passes too:
+// the client may fail a request the harness reset instead of sending it
+// again. This is used for tests where the harness resets the request with
+// RST_STREAM and grades the client's retries.
+func ExpectResetRequest(method string, tolerated ...string) error {
+	var body io.Reader
+	if method != http.MethodGet {
+		body = bytes.NewReader([]byte("retry me"))
+	}
+	req, err := http.NewRequest(method, "https://127.0.0.1:8080", body)
+	if err != nil {
+		return err
+	}
+	resp, err := newClient().Do(req)
+	if err == nil {
+		var got []byte
+		got, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil {
+			if resp.StatusCode != 200 || string(got) != "ok" {
+				return fmt.Errorf("expected a 200 response with body \"ok\", but got %s with body %q", resp.Status, got)
+			}
+			log.Printf("Got expected response: %s over %s", resp.Status, resp.Proto)
+			return nil
+		}
+	}
+
+	for _, t := range tolerated {
+		if strings.Contains(err.Error(), t) {
+			log.Printf("Client failed the request: %v", err)
+			return nil
+		}
+	}
+	return fmt.Errorf("expected a 200 response with body \"ok\", but got an error: %v", err)
+}
+
+// ExpectIndexedRequests performs n GET requests in turn through one client
+// and expects a 200 response with body "ok" to each. Every request repeats
+// the same fields and adds a new one, so that the client's encoder both
+// references and evicts dynamic table entries. This is used for tests
+// where the harness audits the client's header compression.
+func ExpectIndexedRequests(n int) error {
+	client := newClient()
+	for i := 1; i <= n; i++ {
+		req, err := http.NewRequest(http.MethodGet, "https://127.0.0.1:8080", nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-Hpack-Repeated", strings.Repeat("r", 64))
+		req.Header.Set("X-Hpack-Request", fmt.Sprintf("%d-%s", i, strings.Repeat("n", 64)))
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("expected request %d of %d to succeed, but got an error: %v", i, n, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("expected a complete body for request %d of %d, but got an error: %v", i, n, err)
+		}
+		if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+			return fmt.Errorf("expected status 200 with body \"ok\" for request %d of %d, but got %s with body %q", i, n, resp.Status, body)
+		}
+	}
+
+	log.Printf("Got %d expected responses to requests with indexed fields", n)
+	return nil
+}
+
+// ExpectRequestWithHeader performs a GET request carrying the header field
+// name with value and expects a 200 response. A client may instead refuse
+// to send a request whose field it cannot carry in HTTP/2, so an error
+// containing one of refusals is accepted too; any other error fails. This
+// is used for tests where the harness grades how the client encodes the
+// field.
+func ExpectRequestWithHeader(name, value string, refusals ...string) error {
+	req, err := http.NewRequest(http.MethodGet, "https://127.0.0.1:8080", nil)
+	if err != nil {
+		return err
+	}
+	req.Header[name] = []string{value}
+	client := newClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		for _, refusal := range refusals {
+			if strings.Contains(err.Error(), refusal) {
+				log.Printf("Client refused to send the request: %v", err)
+				return nil
+			}
+		}
+		return fmt.Errorf("expected a 200 response or a refusal containing one of %v, but got an error: %v", refusals, err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		return fmt.Errorf("expected a complete response body, but got an error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("expected status 200 OK, but got %s", resp.Status)
+	}
+
+	log.Printf("Got expected response to a request with %s: %s", name, value)
+	return nil
+}
+
+// ExpectRequestsWithHeader performs a GET request for each of values in
+// turn through one client, carrying the header field name with that value,
+// and expects a 200 response with body "ok" to each. This is used for tests
+// where the harness checks the value it decodes from each request.
+func ExpectRequestsWithHeader(name string, values ...string) error {
+	client := newClient()
+	for i, value := range values {
+		req, err := http.NewRequest(http.MethodGet, "https://127.0.0.1:8080", nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(name, value)
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("expected request %d of %d to succeed, but got an error: %v", i+1, len(values), err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("expected a complete body for request %d of %d, but got an error: %v", i+1, len(values), err)
+		}
+		if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+			return fmt.Errorf("expected status 200 with body \"ok\" for request %d of %d, but got %s with body %q", i+1, len(values), resp.Status, body)
+		}
+	}
+
+	log.Printf("Got %d expected responses to requests with %s", len(values), name)
+	return nil
+}
+
+// ExpectResponseDigest performs a GET request and expects a 200 response
+// whose body matches the SHA-256 digest the harness sent in x-body-sha256.
+// This is used for tests that serve bodies too large to spell out.
+func ExpectResponseDigest() error {
+	client := newClient()
+	resp, err := client.Get("https://127.0.0.1:8080")
+	if err != nil {
+		return fmt.Errorf("expected a successful request, but got an error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("expected a complete response body, but got an error after %d octets: %v", len(body), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("expected status 200 OK, but got %s", resp.Status)
+	}
+	digest := sha256.Sum256(body)
+	if got, want := hex.EncodeToString(digest[:]), resp.Header.Get("X-Body-Sha256"); got != want {
+		return fmt.Errorf("expected a body with SHA-256 %s, but got %d octets with SHA-256 %s", want, len(body), got)
+	}
+
+	log.Printf("Got expected response: %s with %d octets of body", resp.Status, len(body))
+	return nil
+}
+
+// ExpectUpload performs a POST request with a size-octet body and its
+// SHA-256 digest in x-body-sha256, and expects a 200 response whose
+// x-body-sha256 shows that the harness received the body intact. This is
+// used for tests where the harness grades the DATA frames of the upload.
+func ExpectUpload(size int) error {
+	if err := upload(newClient(), size, int64(size), ""); err != nil {
+		return err
+	}
+	log.Printf("Uploaded %d octets intact", size)
+	return nil
+}
+
+// ExpectUploads performs n POST requests at once through one client, each
+// with a different random body of size octets, and checks every response
+// as ExpectUpload does.
+func ExpectUploads(n, size int) error {
+	client := newClient()
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := upload(client, size, int64(size+i), ""); err != nil {
+				errs[i] = fmt.Errorf("upload %d of %d: %v", i+1, n, err)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Uploaded %d bodies of %d octets intact", n, size)
+	return nil
+}
+
+// ExpectContinuedUpload POSTs a random body of size octets with an
+// Expect: 100-continue header field and checks the response as ExpectUpload
+// does. This is used for tests where the harness holds 100 Continue back.
+func ExpectContinuedUpload(size int) error {
+	if err := upload(newClient(), size, int64(size), "100-continue"); err != nil {
+		return err
+	}
+	log.Printf("Uploaded %d octets intact after 100 Continue", size)
+	return nil
+}
+
+// earlyResponseWait is how long ExpectEarlyResponse leaves the response
+// body unread, so that the RST_STREAM following the response has arrived
+// before the body is read.
+const earlyResponseWait = 200 * time.Millisecond
+
+// ExpectEarlyResponse POSTs a random body of size octets with an
+// Expect: 100-continue header field and expects a complete response with
+// the given status and body, which the harness sends before the request
+// body and follows with RST_STREAM NO_ERROR.
+func ExpectEarlyResponse(size int, expectedStatus int, expectedBody string) error {
+	body := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(body)
+	req, err := http.NewRequest(http.MethodPost, "https://127.0.0.1:8080", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Expect", "100-continue")
+	resp, err := newClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("expected a %d response, but got an error: %v", expectedStatus, err)
+	}
+	defer resp.Body.Close()
+
+	time.Sleep(earlyResponseWait)
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("expected a complete response body, but got an error: %v", err)
+	}
+	if resp.StatusCode != expectedStatus || string(got) != expectedBody {
+		return fmt.Errorf("expected status %d with body %q, but got %s with body %q", expectedStatus, expectedBody, resp.Status, got)
+	}
+	log.Printf("Got expected response before the body was sent: %s", resp.Status)
+	return nil
+}
+
+// upload POSTs a random body of size octets, generated from seed, through
+// client and checks that the harness received it intact. A non-empty
+// expect is sent as the Expect header field.
+func upload(client *http.Client, size int, seed int64, expect string) error {
+	body := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(body)
+	digest := sha256.Sum256(body)
+	sent := hex.EncodeToString(digest[:])
+
+	req, err := http.NewRequest(http.MethodPost, "https://127.0.0.1:8080", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Body-Sha256", sent)
+	if expect != "" {
+		req.Header.Set("Expect", expect)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("expected the upload to succeed, but got an error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		return fmt.Errorf("expected a complete response body, but got an error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("expected status 200 OK, but got %s", resp.Status)
+	}
+	if received := resp.Header.Get("X-Body-Sha256"); received != sent {
+		return fmt.Errorf("uploaded %d octets with SHA-256 %s, but the harness received SHA-256 %s", size, sent, received)
+	}
+	return nil
+}
+
+// ExpectOversizedRequestRefused performs a GET request, which must get a
+// 200 response with body "ok", and then a second one through the same
+// client carrying an x-large header field of size octets. The client must
+// fail the second request itself, as its header list exceeds the server's
+// SETTINGS_MAX_HEADER_LIST_SIZE; the harness reports the request if it is
+// sent anyway.
+func ExpectOversizedRequestRefused(size int) error {
+	client := newClient()
+	if err := expectOK(client); err != nil {
+		return fmt.Errorf("expected the first request to succeed: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://127.0.0.1:8080", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Large", strings.Repeat("l", size))
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		return fmt.Errorf("expected the client to refuse a request with a %d-octet header field, but got %s", size, resp.Status)
+	}
+
+	log.Printf("Client refused the oversized request: %v", err)
+	return nil
+}
+
+// ExpectHeaderLength performs a GET request carrying an x-large header
+// field of size octets and expects a 200 response whose body is size: the
+// length of the value the harness decoded.
+func ExpectHeaderLength(size int) error {
+	req, err := http.NewRequest(http.MethodGet, "https://127.0.0.1:8080", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Large", strings.Repeat("l", size))
+	resp, err := newClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("expected the request to succeed, but got an error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("expected a complete response body, but got an error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != strconv.Itoa(size) {
+		return fmt.Errorf("expected status 200 with body %q, but got %s with body %q", strconv.Itoa(size), resp.Status, body)
+	}
+
+	log.Printf("Harness decoded the %d-octet header field intact", size)
+	return nil
+}
+
+// ExpectResponseHeader performs a GET request and expects the response to
+// carry exactly the given values for the header field name.
+func ExpectResponseHeader(name string, expectedValues ...string) error {
+	client := newClient()
+	resp, err := client.Get("https://127.0.0.1:8080")
+	if err != nil {
+		return fmt.Errorf("expected a successful request, but got an error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		return fmt.Errorf("expected a complete response body, but got an error: %v", err)
+	}
+
+	values := resp.Header.Values(name)
+	if strings.Join(values, "\x00") != strings.Join(expectedValues, "\x00") {
+		return fmt.Errorf("expected %s header values %q, but got %q", name, expectedValues, values)
+	}
+
+	log.Printf("Got expected %s header values: %q", name, values)
+	return nil
+}
+
+// ExpectTrailer performs a GET request, reads the response body and expects
+// it to equal expectedBody, and the response to end with exactly the given
+// values for the trailer field name.
+func ExpectTrailer(expectedBody, name string, expectedValues ...string) error {
+	return ExpectTrailers(expectedBody, http.Header{http.CanonicalHeaderKey(name): expectedValues})
+}
+
+// ExpectTrailers performs a GET request, reads the response body and
+// expects it to equal expectedBody, and the response to end with exactly
+// the values of expected for each of its trailer fields.
+func ExpectTrailers(expectedBody string, expected http.Header) error {
+	client := newClient()
+	resp, err := client.Get("https://127.0.0.1:8080")
+	if err != nil {
+		return fmt.Errorf("expected a successful request, but got an error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("expected a complete response body, but got an error: %v", err)
+	}
+	if string(body) != expectedBody {
+		return fmt.Errorf("expected body %q, but got %q", expectedBody, body)
+	}
+
+	// Trailers are only complete once the body has been read to EOF.
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := resp.Trailer.Values(name)
+		if strings.Join(values, "\x00") != strings.Join(expected[name], "\x00") {
+			return fmt.Errorf("expected %s trailer values %q, but got %q", name, expected[name], values)
+		}
+		log.Printf("Got expected %s trailer values: %q", name, values)
+	}
+	return nil
+}
+
+// ExpectResponseError performs a GET request, reads the response body and
+// checks that the request or the body failed with an error containing one
+// of the expected substrings. This is used for tests where the error is
+// only detectable after the response headers have been delivered. A
+// response with which the harness skipped the test case returns nil too;
+// see Skipped.
+func ExpectResponseError(expectedErrors ...string) error {
+	client := newClient()
+	resp, err := client.Get("https://127.0.0.1:8080")
+	if err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil {
+			if skipped(resp) {
+				return nil
+			}
+			return fmt.Errorf("expected an error, but got a complete response")
+		}
+	}
+
+	for _, expected := range expectedErrors {
+		if strings.Contains(err.Error(), expected) {
+			log.Printf("Got expected error: %v", err)
+			return nil // Test passed
+		}
+	}
+
+	return fmt.Errorf("got an unexpected error: %v, expected one of: %v", err, expectedErrors)
+}
+
+// getHeld performs a GET request and leaves the response body unread for
+// hold before reading it, so that the client cannot return flow-control
+// credit for it in the meantime.
+func getHeld(hold time.Duration) (*http.Response, []byte, error) {
+	client := newClient()
+	resp, err := client.Get("https://127.0.0.1:8080")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	time.Sleep(hold)
+	body, err := io.ReadAll(resp.Body)
+	return resp, body, err
+}
+
+// ExpectHeldResponse performs a GET request, holds the response body unread
+// for hold and then expects it to read completely with the given status.
+// This is used for tests where the server fills the client's receive
+// windows exactly.
+func ExpectHeldResponse(hold time.Duration, expectedStatus int) error {
+	resp, body, err := getHeld(hold)
+	if err != nil {
+		return fmt.Errorf("expected a complete response, but got an error: %v", err)
+	}
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("expected status %d, but got %s", expectedStatus, resp.Status)
+	}
+
+	log.Printf("Got expected response: %s with %d octets of body", resp.Status, len(body))
+	return nil
+}
+
+// ExpectHeldResponseError performs a GET request, holds the response body
+// unread for hold and then expects the request or the body to fail with an
+// error containing one of the expected substrings. This is used for tests
+// where the server overruns the client's receive windows.
+func ExpectHeldResponseError(hold time.Duration, expectedErrors ...string) error {
+	_, _, err := getHeld(hold)
+	if err == nil {
+		return fmt.Errorf("expected an error, but got a complete response")
+	}
+
+	for _, expected := range expectedErrors {
+		if strings.Contains(err.Error(), expected) {
+			log.Printf("Got expected error: %v", err)
+			return nil // Test passed
+		}
+	}
+
+	return fmt.Errorf("got an unexpected error: %v, expected one of: %v", err, expectedErrors)
+}
+
+// pushWait bounds how long a push verifier waits for a pushed response.
+const pushWait = 2 * time.Second
+
+// pushClient returns a client that enables server push, and the transport
+// whose Pushes receives the pushed responses; refuse makes it refuse every
+// push instead. The reference client never enables push, so against it
+// pushClient returns a plain client and a nil transport, and the harness
+// skips the test case.
+func pushClient(refuse bool) (*http.Client, *mutant.Transport) {
+	t, ok := transport.(*mutant.Transport)
+	if !ok {
+		return newClient(), nil
+	}
+	push := &mutant.Transport{
+		Faults:          t.Faults,
+		TLSClientConfig: t.TLSClientConfig,
+		Timeout:         t.Timeout,
+		EnablePush:      true,
+		RefusePush:      refuse,
+		Pushes:          make(chan *http.Response, 4),
+	}
+	return &http.Client{Transport: push}, push
+}
+
+// expectPushSkipped checks that the harness skips the test case for a
+// client that disabled server push, as it has no push to grade.
+func expectPushSkipped(client *http.Client) error {
+	resp, err := client.Get("https://127.0.0.1:8080")
+	if err != nil {
+		return fmt.Errorf("expected the harness to skip the test case, but got an error: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if !skipped(resp) {
+		return fmt.Errorf("expected the harness to skip the test case for a client that disabled server push, but got %s", resp.Status)
+	}
+	return nil
+}
+
+// ExpectPush performs a GET request with server push enabled and expects a
+// 200 response with body "ok" and a pushed response for path with the given
+// body.
+func ExpectPush(path, body string) error {
+	client, push := pushClient(false)
+	if push == nil {
+		return expectPushSkipped(client)
+	}
+	if err := expectOK(client); err != nil {
+		return fmt.Errorf("expected a 200 response with body \"ok\": %v", err)
+	}
+
+	select {
+	case resp := <-push.Pushes:
+		defer resp.Body.Close()
+		got, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("incomplete pushed body: %v", err)
+		}
+		if resp.Request.URL.Path != path || string(got) != body {
+			return fmt.Errorf("expected a push of %s with body %q, but got %s with body %q", path, body, resp.Request.URL.Path, got)
+		}
+		log.Printf("Got expected pushed response for %s: %s", path, resp.Status)
+		return nil
+	case <-time.After(pushWait):
+		return fmt.Errorf("expected a pushed response for %s, but got none", path)
+	}
+}
+
+// ExpectPushRefused performs a GET request through a client that refuses
+// every push and expects a 200 response with body "ok" and no pushed
+// response.
+func ExpectPushRefused() error {
+	client, push := pushClient(true)
+	if push == nil {
+		return expectPushSkipped(client)
+	}
+	if err := expectOK(client); err != nil {
+		return fmt.Errorf("expected a 200 response with body \"ok\": %v", err)
+	}
+
+	select {
+	case resp := <-push.Pushes:
+		resp.Body.Close()
+		return fmt.Errorf("expected the push to be refused, but got a pushed response for %s", resp.Request.URL.Path)
+	case <-time.After(pushWait / 4):
+	}
+	log.Println("Got expected response; the refused push was not delivered")
+	return nil
+}
+
+// ExpectPushError performs a GET request with server push enabled and
+// expects the request or its body to fail with an error containing one of
+// the expected substrings.
+func ExpectPushError(expectedErrors ...string) error {
+	client, push := pushClient(false)
+	if push == nil {
+		return expectPushSkipped(client)
+	}
+	err := expectOK(client)
+	if err == nil {
+		return fmt.Errorf("expected an error, but got a complete response")
+	}
+
+	for _, expected := range expectedErrors {
+		if strings.Contains(err.Error(), expected) {
+			log.Printf("Got expected error: %v", err)
+			return nil // Test passed
+		}
+	}
+
+	return fmt.Errorf("got an unexpected error: %v, expected one of: %v", err, expectedErrors)
+}
+
+// ExpectPushHeld performs a GET request with server push enabled, expects
+// a 200 response with body "ok" and keeps the connection open for hold.
+// This is used for tests where the harness grades how the client answers
+// a promise.
+func ExpectPushHeld(hold time.Duration) error {
+	client, push := pushClient(false)
+	if push == nil {
+		return expectPushSkipped(client)
+	}
+	if err := expectOK(client); err != nil {
+		return fmt.Errorf("expected a 200 response with body \"ok\": %v", err)
+	}
+
+	time.Sleep(hold)
+	log.Println("Got expected response")
+	return nil
+}
@@ -0,0 +1,318 @@
+package verifier
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+)
+
+// webSocketURL is the resource every WebSocket of the verifiers opens.
+const webSocketURL = "https://127.0.0.1:8080/chat"
+
+// WebSocket opcodes (RFC 6455 Section 5.2).
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+)
+
+// wsNormalClosure is the payload of a close frame with status code 1000.
+var wsNormalClosure = []byte{0x03, 0xe8}
+
+// appendMaskedFrame appends a final WebSocket frame masked with a random
+// key, as a client must send it (RFC 6455 Section 5.3).
+func appendMaskedFrame(dst []byte, opcode byte, payload []byte) []byte {
+	dst = append(dst, 0x80|opcode)
+	switch n := len(payload); {
+	case n < 126:
+		dst = append(dst, 0x80|byte(n))
+	case n <= 0xffff:
+		dst = append(dst, 0x80|126)
+		dst = binary.BigEndian.AppendUint16(dst, uint16(n))
+	default:
+		dst = append(dst, 0x80|127)
+		dst = binary.BigEndian.AppendUint64(dst, uint64(n))
+	}
+	var key [4]byte
+	rand.Read(key[:])
+	dst = append(dst, key[:]...)
+	for i, b := range payload {
+		dst = append(dst, b^key[i%4])
+	}
+	return dst
+}
+
+// readWebSocketFrame reads the next WebSocket frame the server sends, which
+// must not be masked. It returns io.EOF if r ends between frames and
+// io.ErrUnexpectedEOF if it ends inside one.
+func readWebSocketFrame(r io.Reader) (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	if head[1]&0x80 != 0 {
+		return 0, nil, fmt.Errorf("masked WebSocket frame from the server")
+	}
+	n := uint64(head[1] & 0x7f)
+	var ext []byte
+	switch n {
+	case 126:
+		ext = make([]byte, 2)
+	case 127:
+		ext = make([]byte, 8)
+	}
+	payload, err := func() ([]byte, error) {
+		if _, err := io.ReadFull(r, ext); err != nil {
+			return nil, err
+		}
+		switch len(ext) {
+		case 2:
+			n = uint64(binary.BigEndian.Uint16(ext))
+		case 8:
+			n = binary.BigEndian.Uint64(ext)
+		}
+		payload := make([]byte, n)
+		_, err := io.ReadFull(r, payload)
+		return payload, err
+	}()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return head[0] & 0x0f, payload, err
+}
+
+// dialWebSocket opens a WebSocket with an extended CONNECT request (RFC 8441
+// Section 4). It returns the response, whose body carries the server's
+// frames, and the writer of the client's frames, which ends the stream when
+// closed.
+func dialWebSocket() (*http.Response, *io.PipeWriter, error) {
+	pr, pw := io.Pipe()
+	req, err := http.NewRequest(http.MethodConnect, webSocketURL, pr)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set(":protocol", "websocket")
+	req.Header.Set("Sec-Websocket-Version", "13")
+	resp, err := newClient().Do(req)
+	if err != nil {
+		pw.Close()
+		return nil, nil, err
+	}
+	return resp, pw, nil
+}
+
+// openWebSocket opens a WebSocket and expects the server to accept it with
+// a 200 response.
+func openWebSocket() (*http.Response, *io.PipeWriter, error) {
+	resp, pw, err := dialWebSocket()
+	if err != nil {
+		return nil, nil, fmt.Errorf("expected the WebSocket to open, but got an error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		pw.Close()
+		return nil, nil, fmt.Errorf("expected status 200 for the WebSocket, but got %s", resp.Status)
+	}
+	return resp, pw, nil
+}
+
+// webSocketMessage returns WebSocket message i of a verifier, of size
+// octets.
+func webSocketMessage(i, size int) []byte {
+	return bytes.Repeat([]byte{byte('a' + i%26)}, size)
+}
+
+// ExpectWebSocketEcho opens a WebSocket, sends text messages of the given
+// sizes one at a time and expects each echoed back intact. It then closes
+// the WebSocket and expects the server to answer the close frame and end
+// the stream.
+func ExpectWebSocketEcho(sizes ...int) error {
+	resp, pw, err := openWebSocket()
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	defer pw.Close()
+
+	for i, size := range sizes {
+		msg := webSocketMessage(i, size)
+		if _, err := pw.Write(appendMaskedFrame(nil, wsText, msg)); err != nil {
+			return fmt.Errorf("failed to send message %d: %v", i+1, err)
+		}
+		opcode, payload, err := readWebSocketFrame(resp.Body)
+		if err != nil {
+			return fmt.Errorf("expected echoed message %d of %d octets, but got an error: %v", i+1, size, err)
+		}
+		if opcode != wsText || !bytes.Equal(payload, msg) {
+			return fmt.Errorf("echoed message %d differs from the one sent: opcode 0x%x, %d octets", i+1, opcode, len(payload))
+		}
+	}
+	if _, err := pw.Write(appendMaskedFrame(nil, wsClose, wsNormalClosure)); err != nil {
+		return fmt.Errorf("failed to send the close frame: %v", err)
+	}
+	if opcode, payload, err := readWebSocketFrame(resp.Body); err != nil || opcode != wsClose || !bytes.Equal(payload, wsNormalClosure) {
+		return fmt.Errorf("expected the server's close frame, but got opcode 0x%x with %d octets and %v", opcode, len(payload), err)
+	}
+	if _, _, err := readWebSocketFrame(resp.Body); err != io.EOF {
+		return fmt.Errorf("expected the stream to end after the close frame, but got %v", err)
+	}
+
+	log.Printf("Got %d WebSocket message(s) echoed and the close handshake completed", len(sizes))
+	return nil
+}
+
+// ExpectWebSocketEchoOrError opens a WebSocket as ExpectWebSocketEcho does
+// and expects either its messages echoed and the close handshake completed,
+// or the WebSocket, or the attempt to open it, to fail with an error
+// containing one of the expected substrings. This is used for tests where
+// the client may ignore a server's mistake or treat it as a connection
+// error, but must not act on it.
+func ExpectWebSocketEchoOrError(expectedErrors ...string) error {
+	err := ExpectWebSocketEcho(5, 200)
+	if err == nil {
+		return nil
+	}
+
+	for _, expected := range expectedErrors {
+		if strings.Contains(err.Error(), expected) {
+			log.Printf("Got expected error: %v", err)
+			return nil // Test passed
+		}
+	}
+
+	return fmt.Errorf("expected the WebSocket to work or an error containing one of %v, but got: %v", expectedErrors, err)
+}
+
+// ExpectWebSocketRefused tries to open a WebSocket and expects the client
+// to refuse to send the extended CONNECT, with an error containing one of
+// the expected substrings.
+func ExpectWebSocketRefused(expectedErrors ...string) error {
+	resp, pw, err := dialWebSocket()
+	if err == nil {
+		resp.Body.Close()
+		pw.Close()
+		return fmt.Errorf("expected the client to refuse the extended CONNECT, but got %s", resp.Status)
+	}
+
+	for _, expected := range expectedErrors {
+		if strings.Contains(err.Error(), expected) {
+			log.Printf("Got expected error: %v", err)
+			return nil // Test passed
+		}
+	}
+
+	return fmt.Errorf("got an unexpected error: %v, expected one of: %v", err, expectedErrors)
+}
+
+// ExpectWebSocketStatus tries to open a WebSocket and expects the server's
+// response to carry expectedStatus and end the stream.
+func ExpectWebSocketStatus(expectedStatus int) error {
+	resp, pw, err := dialWebSocket()
+	if err != nil {
+		return fmt.Errorf("expected a %d response, but got an error: %v", expectedStatus, err)
+	}
+	defer resp.Body.Close()
+	defer pw.Close()
+
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("expected status %d for the WebSocket, but got %s", expectedStatus, resp.Status)
+	}
+	if body, err := io.ReadAll(resp.Body); err != nil || len(body) != 0 {
+		return fmt.Errorf("expected the stream to end with the response, but got %d octets and %v", len(body), err)
+	}
+
+	log.Printf("Got expected status %d for the WebSocket", expectedStatus)
+	return nil
+}
+
+// ExpectWebSocketError opens a WebSocket, sends one text message and
+// expects echoes copies of it back. It then expects the WebSocket, or the
+// attempt to open it, to fail with an error containing one of the expected
+// substrings instead of closing cleanly.
+func ExpectWebSocketError(echoes int, expectedErrors ...string) error {
+	resp, pw, err := dialWebSocket()
+	if err == nil {
+		defer resp.Body.Close()
+		defer pw.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("expected status 200 for the WebSocket, but got %s", resp.Status)
+		}
+		err = func() error {
+			msg := webSocketMessage(0, 64)
+			if _, err := pw.Write(appendMaskedFrame(nil, wsText, msg)); err != nil {
+				return err
+			}
+			for i := 0; i < echoes; i++ {
+				opcode, payload, err := readWebSocketFrame(resp.Body)
+				if err != nil {
+					return fmt.Errorf("expected echoed message %d of %d, but got an error: %v", i+1, echoes, err)
+				}
+				if opcode != wsText || !bytes.Equal(payload, msg) {
+					return fmt.Errorf("echoed message %d differs from the one sent", i+1)
+				}
+			}
+			_, _, err := readWebSocketFrame(resp.Body)
+			return err
+		}()
+		if err == nil {
+			return fmt.Errorf("expected the WebSocket to fail after %d echo(es), but got another frame", echoes)
+		}
+		if err == io.EOF {
+			return fmt.Errorf("expected the WebSocket to fail after %d echo(es), but the stream ended cleanly", echoes)
+		}
+	}
+
+	for _, expected := range expectedErrors {
+		if strings.Contains(err.Error(), expected) {
+			log.Printf("Got expected error: %v", err)
+			return nil // Test passed
+		}
+	}
+
+	return fmt.Errorf("got an unexpected error: %v, expected one of: %v", err, expectedErrors)
+}
+
+// ExpectTunnel opens a tunnel to target with a CONNECT request (RFC 7540
+// Section 8.3) and expects the server to send banner through it first, if
+// it is not empty, and then to echo message. It then closes its side of the
+// tunnel and expects the server to close the other.
+func ExpectTunnel(target, banner, message string) error {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	req, err := http.NewRequest(http.MethodConnect, "https://127.0.0.1:8080", pr)
+	if err != nil {
+		return err
+	}
+	req.Host = target
+	resp, err := newClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("expected the tunnel to open, but got an error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("expected status 200 for the tunnel, but got %s", resp.Status)
+	}
+
+	got := make([]byte, len(banner))
+	if _, err := io.ReadFull(resp.Body, got); err != nil || string(got) != banner {
+		return fmt.Errorf("expected banner %q through the tunnel, but got %q and %v", banner, got, err)
+	}
+	if _, err := pw.Write([]byte(message)); err != nil {
+		return fmt.Errorf("failed to send through the tunnel: %v", err)
+	}
+	got = make([]byte, len(message))
+	if _, err := io.ReadFull(resp.Body, got); err != nil || string(got) != message {
+		return fmt.Errorf("expected %q echoed through the tunnel, but got %q and %v", message, got, err)
+	}
+	pw.Close()
+	if rest, err := io.ReadAll(resp.Body); err != nil || len(rest) != 0 {
+		return fmt.Errorf("expected the server to close the tunnel, but got %d more octets and %v", len(rest), err)
+	}
+
+	log.Printf("Got %d octets echoed through the tunnel to %s", len(message), target)
+	return nil
+}